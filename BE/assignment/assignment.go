@@ -0,0 +1,142 @@
+// Package assignment đưa ticket mới vào hàng đợi của team và tự động phân công cho nhân viên
+// theo các chiến lược round-robin hoặc ít ticket đang mở nhất.
+package assignment
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/workflow"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Các chiến lược phân công
+const (
+	StrategyRoundRobin  = "round_robin"
+	StrategyLeastLoaded = "least_loaded"
+)
+
+var (
+	ErrNoRule      = errors.New("không có quy tắc phân công phù hợp")
+	ErrNoCandidate = errors.New("không có nhân viên sẵn sàng nhận ticket")
+)
+
+// IsValidStrategy kiểm tra tên chiến lược
+func IsValidStrategy(strategy string) bool {
+	return strategy == StrategyRoundRobin || strategy == StrategyLeastLoaded
+}
+
+// FindRule tìm quy tắc đang hoạt động cụ thể nhất cho loại ticket và loại sản phẩm
+func FindRule(db *gorm.DB, categoryID, productTypeID uint) (*models.AssignmentRule, error) {
+	var rule models.AssignmentRule
+	err := db.Where("is_active = ?", true).
+		Where("(category_id = ? OR category_id IS NULL) AND (product_type_id = ? OR product_type_id IS NULL)", categoryID, productTypeID).
+		Order("category_id IS NULL, product_type_id IS NULL, id").
+		First(&rule).Error
+	if err != nil {
+		return nil, ErrNoRule
+	}
+	return &rule, nil
+}
+
+// RouteToTeam tìm team cụ thể nhất nhận ticket theo loại ticket và loại sản phẩm, nil nếu không có
+func RouteToTeam(db *gorm.DB, categoryID, productTypeID uint) *uint {
+	var route models.TeamRoute
+	err := db.Where("(category_id = ? OR category_id IS NULL) AND (product_type_id = ? OR product_type_id IS NULL)", categoryID, productTypeID).
+		Order("category_id IS NULL, product_type_id IS NULL, id").
+		First(&route).Error
+	if err != nil {
+		return nil
+	}
+	return &route.TeamID
+}
+
+// Candidates trả về các nhân viên có thể phân công và đang sẵn sàng, sắp xếp theo ID.
+// Nếu teamID khác nil thì chỉ lấy thành viên của team đó.
+func Candidates(db *gorm.DB, teamID *uint) ([]models.User, error) {
+	var staff []models.User
+	query := models.AssignableStaff(db).Where("is_available = ?", true)
+	if teamID != nil {
+		query = query.Where("id IN (SELECT user_id FROM team_members WHERE team_id = ?)", *teamID)
+	}
+	err := query.Order("id").Find(&staff).Error
+	return staff, err
+}
+
+// AutoAssign chọn nhân viên cho ticket theo quy tắc phù hợp và gán vào ticket.AssignedTo (chưa lưu ticket).
+// Ticket đã thuộc một team thì chỉ chọn trong thành viên team.
+// Trả về ErrNoRule nếu không cấu hình tự động phân công cho ticket này.
+func AutoAssign(ticket *models.Ticket) (*models.User, error) {
+	var chosen *models.User
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		rule, err := FindRule(tx, ticket.CategoryID, ticket.ProductTypeID)
+		if err != nil {
+			return err
+		}
+		// Khóa quy tắc để các ticket tạo đồng thời không cùng chọn một người theo round-robin
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(rule, rule.ID).Error; err != nil {
+			return err
+		}
+		candidates, err := Candidates(tx, ticket.TeamID)
+		if err != nil {
+			return err
+		}
+		if len(candidates) == 0 {
+			return ErrNoCandidate
+		}
+		switch rule.Strategy {
+		case StrategyLeastLoaded:
+			chosen = leastLoaded(tx, candidates)
+		default:
+			chosen = nextRoundRobin(candidates, rule.LastAssignedTo)
+		}
+		return tx.Model(rule).Update("last_assigned_to", chosen.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	ticket.AssignedTo = &chosen.ID
+	return chosen, nil
+}
+
+// nextRoundRobin chọn người kế tiếp sau lastID theo thứ tự ID, quay vòng về đầu danh sách
+func nextRoundRobin(candidates []models.User, lastID *uint) *models.User {
+	if lastID != nil {
+		for i := range candidates {
+			if candidates[i].ID > *lastID {
+				return &candidates[i]
+			}
+		}
+	}
+	return &candidates[0]
+}
+
+// leastLoaded chọn người đang có ít ticket mở nhất, hòa thì chọn ID nhỏ hơn
+func leastLoaded(db *gorm.DB, candidates []models.User) *models.User {
+	ids := make([]uint, 0, len(candidates))
+	for _, c := range candidates {
+		ids = append(ids, c.ID)
+	}
+	type load struct {
+		AssignedTo uint
+		Count      int64
+	}
+	var loads []load
+	db.Model(&models.Ticket{}).
+		Select("assigned_to, COUNT(*) as count").
+		Where("assigned_to IN ? AND status IN ?", ids, []string{workflow.StatusNew, workflow.StatusInProgress, workflow.StatusWaiting}).
+		Group("assigned_to").
+		Scan(&loads)
+	counts := map[uint]int64{}
+	for _, l := range loads {
+		counts[l.AssignedTo] = l.Count
+	}
+	best := &candidates[0]
+	for i := range candidates {
+		if counts[candidates[i].ID] < counts[best.ID] {
+			best = &candidates[i]
+		}
+	}
+	return best
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// Token API dùng cho script/tích hợp, gửi qua header "Authorization: Token <token>".
+// Server chỉ lưu SHA-256 của token, token gốc chỉ hiển thị một lần khi tạo.
+const (
+	apiTokenPrefix = "hdt_"
+	// Số ký tự đầu của token được lưu để người dùng nhận ra token trong danh sách
+	apiTokenDisplayLength = 12
+)
+
+// Quyền của token API; quyền :write bao gồm quyền :read cùng nhóm
+const (
+	ScopeTicketsRead        = "tickets:read"
+	ScopeTicketsWrite       = "tickets:write"
+	ScopeKBRead             = "kb:read"
+	ScopeKBWrite            = "kb:write"
+	ScopeNotificationsRead  = "notifications:read"
+	ScopeNotificationsWrite = "notifications:write"
+)
+
+var APIScopes = []string{
+	ScopeTicketsRead, ScopeTicketsWrite,
+	ScopeKBRead, ScopeKBWrite,
+	ScopeNotificationsRead, ScopeNotificationsWrite,
+}
+
+// IsValidAPIScope kiểm tra quyền token có tồn tại
+func IsValidAPIScope(scope string) bool {
+	for _, s := range APIScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateAPIToken tạo token mới, trả về token gốc và phần đầu để hiển thị
+func GenerateAPIToken() (token, display string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = apiTokenPrefix + hex.EncodeToString(b)
+	return token, token[:apiTokenDisplayLength], nil
+}
+
+// HashAPIToken trả về giá trị lưu trong DB của token
+func HashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Nhóm API token được phép gọi, theo tiền tố đường dẫn
+var apiScopeResources = []struct {
+	prefix   string
+	resource string
+}{
+	{"/user/tickets", "tickets"},
+	{"/user/attachments", "tickets"},
+	{"/admin/tickets", "tickets"},
+	{"/admin/attachments", "tickets"},
+	{"/admin/staff", "tickets"},
+	{"/uploads", "tickets"},
+	{"/user/knowledge-base", "kb"},
+	{"/admin/knowledge-base", "kb"},
+	{"/user/notifications", "notifications"},
+	{"/admin/notifications", "notifications"},
+}
+
+// RequiredScope trả về quyền token cần có để gọi API: GET cần quyền :read, các method khác cần :write.
+// Trả về rỗng nếu token API không được gọi API này (quản lý tài khoản, token, cấu hình hệ thống...).
+func RequiredScope(method, path string) string {
+	for _, r := range apiScopeResources {
+		if path == r.prefix || strings.HasPrefix(path, r.prefix+"/") {
+			if method == http.MethodGet || method == http.MethodHead {
+				return r.resource + ":read"
+			}
+			return r.resource + ":write"
+		}
+	}
+	return ""
+}
+
+// ScopeAllows cho biết danh sách quyền của token có bao gồm quyền cần thiết
+func ScopeAllows(scopes []string, required string) bool {
+	resource, action, _ := strings.Cut(required, ":")
+	for _, s := range scopes {
+		if s == required || (action == "read" && s == resource+":write") {
+			return true
+		}
+	}
+	return false
+}
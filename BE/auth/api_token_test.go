@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateAPIToken(t *testing.T) {
+	token, display, err := GenerateAPIToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, _ := GenerateAPIToken()
+	if !strings.HasPrefix(token, apiTokenPrefix) || len(token) != len(apiTokenPrefix)+64 || token == other {
+		t.Errorf("GenerateAPIToken = %s, %s", token, other)
+	}
+	if display != token[:apiTokenDisplayLength] {
+		t.Errorf("display = %s", display)
+	}
+	if HashAPIToken(token) == HashAPIToken(other) || len(HashAPIToken(token)) != 64 {
+		t.Errorf("HashAPIToken không phân biệt token")
+	}
+}
+
+func TestRequiredScopeAndScopeAllows(t *testing.T) {
+	tests := []struct {
+		method, path string
+		scopes       []string
+		want         string
+		allowed      bool
+	}{
+		{"GET", "/user/tickets", []string{ScopeTicketsRead}, ScopeTicketsRead, true},
+		{"GET", "/user/tickets/5", []string{ScopeTicketsWrite}, ScopeTicketsRead, true},
+		{"POST", "/user/tickets", []string{ScopeTicketsRead}, ScopeTicketsWrite, false},
+		{"PATCH", "/admin/tickets/5/status", []string{ScopeTicketsWrite}, ScopeTicketsWrite, true},
+		{"GET", "/admin/knowledge-base", []string{ScopeTicketsWrite}, ScopeKBRead, false},
+		{"DELETE", "/user/notifications/3", []string{ScopeNotificationsWrite}, ScopeNotificationsWrite, true},
+		{"GET", "/user/ticketsx", []string{ScopeTicketsWrite}, "", false},
+		{"POST", "/user/profile/api-tokens", APIScopes, "", false},
+		{"GET", "/admin/users", APIScopes, "", false},
+	}
+	for _, tt := range tests {
+		got := RequiredScope(tt.method, tt.path)
+		if got != tt.want {
+			t.Errorf("RequiredScope(%s %s) = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+		if allowed := got != "" && ScopeAllows(tt.scopes, got); allowed != tt.allowed {
+			t.Errorf("%s %s với %v: allowed = %v, want %v", tt.method, tt.path, tt.scopes, allowed, tt.allowed)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignedURL(t *testing.T) {
+	t.Setenv("SIGNED_URL_SECRET", "khóa-ký-link")
+	expires := time.Now().Add(time.Hour)
+	sig, err := SignResource("attachment:12", expires)
+	if err != nil {
+		t.Fatalf("SignResource: %v", err)
+	}
+	tests := []struct {
+		name      string
+		resource  string
+		expires   int64
+		signature string
+		want      error
+	}{
+		{"hợp lệ", "attachment:12", expires.Unix(), sig, nil},
+		{"đổi tài nguyên", "attachment:13", expires.Unix(), sig, ErrSignatureInvalid},
+		{"kéo dài thời hạn", "attachment:12", expires.Unix() + 3600, sig, ErrSignatureInvalid},
+		{"sửa chữ ký", "attachment:12", expires.Unix(), "00" + sig[2:], ErrSignatureInvalid},
+		{"chữ ký rỗng", "attachment:12", expires.Unix(), "", ErrSignatureInvalid},
+		{"hết hạn", "attachment:12", time.Now().Add(-time.Minute).Unix(), sig, ErrSignatureExpired},
+	}
+	for _, tt := range tests {
+		if got := VerifyResource(tt.resource, tt.expires, tt.signature); got != tt.want {
+			t.Errorf("%s: VerifyResource = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// Không ký bằng khóa JWT: đổi khóa ký link thì link cũ mất hiệu lực
+	t.Setenv("SIGNED_URL_SECRET", "khóa-khác")
+	if err := VerifyResource("attachment:12", expires.Unix(), sig); err != ErrSignatureInvalid {
+		t.Errorf("đổi khóa: VerifyResource = %v, want ErrSignatureInvalid", err)
+	}
+}
+
+func TestSignedURLWithoutKey(t *testing.T) {
+	t.Setenv("SIGNED_URL_SECRET", "")
+	expires := time.Now().Add(time.Hour)
+	if _, err := SignResource("attachment:1", expires); err != ErrSigningKeyMissing {
+		t.Errorf("SignResource = %v, want ErrSigningKeyMissing", err)
+	}
+	// Chữ ký tính bằng khóa rỗng cũng không được chấp nhận
+	forged := signResource(nil, "attachment:1", expires.Unix())
+	if err := VerifyResource("attachment:1", expires.Unix(), forged); err != ErrSignatureInvalid {
+		t.Errorf("VerifyResource = %v, want ErrSignatureInvalid", err)
+	}
+}
@@ -15,6 +15,62 @@ func StartBackgroundJobs() {
 		}
 	}()
 
+	// Job gửi email trong hàng đợi
+	go func() {
+		for {
+			controllers.ProcessEmailOutbox()
+			time.Sleep(15 * time.Second)
+		}
+	}()
+
+	// Job gửi sự kiện webhook trong hàng đợi
+	go func() {
+		for {
+			controllers.ProcessWebhookDeliveries()
+			time.Sleep(10 * time.Second)
+		}
+	}()
+
+	// Job gửi email tóm tắt thông báo theo lịch của từng người dùng
+	go func() {
+		for {
+			controllers.SendNotificationDigests()
+			time.Sleep(5 * time.Minute)
+		}
+	}()
+
+	// Job xóa nội dung email đã gửi quá thời hạn lưu giữ
+	go func() {
+		for {
+			controllers.PurgeEmailBodies()
+			time.Sleep(1 * time.Hour)
+		}
+	}()
+
+	// Job xóa thông báo đã đọc quá thời hạn lưu giữ
+	go func() {
+		for {
+			controllers.PurgeOldNotifications()
+			time.Sleep(24 * time.Hour)
+		}
+	}()
+
+	// Job quét virus lại các file đính kèm chưa quét được
+	go func() {
+		for {
+			controllers.RescanPendingAttachments()
+			time.Sleep(5 * time.Minute)
+		}
+	}()
+
+	// Job đọc email gửi đến hộp thư hỗ trợ, tạo ticket/bình luận
+	go func() {
+		for {
+			controllers.PollInboundMail()
+			time.Sleep(1 * time.Minute)
+		}
+	}()
+
 	// Job dọn dẹp blacklist định kỳ
 	go func() {
 		for {
@@ -0,0 +1,242 @@
+// Package calendar tính thời gian theo giờ làm việc (khung giờ trong tuần, múi giờ và ngày nghỉ)
+// để SLA, nhắc nhở và thống kê dùng chung một cách đo.
+package calendar
+
+import (
+	"awesomeProject/models"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+	_ "time/tzdata" // Nhúng dữ liệu múi giờ để LoadLocation chạy được trên mọi máy
+)
+
+// Interval là một khung giờ làm việc tính bằng phút kể từ 00:00
+type Interval struct {
+	Start int
+	End   int
+}
+
+// Calendar là lịch làm việc đã được nạp sẵn để tính toán
+type Calendar struct {
+	Location *time.Location
+	Hours    map[time.Weekday][]Interval
+	Holidays map[string]bool
+}
+
+// maxScanDays giới hạn số ngày duyệt khi tính toán, tránh lặp vô hạn với lịch không có giờ làm
+const maxScanDays = 3660
+
+var (
+	current *Calendar
+	mu      sync.RWMutex
+)
+
+// Default trả về lịch làm việc đang áp dụng (nạp từ DB ở lần gọi đầu)
+func Default() *Calendar {
+	mu.RLock()
+	cal := current
+	mu.RUnlock()
+	if cal != nil {
+		return cal
+	}
+	return Reload()
+}
+
+// Reload nạp lại lịch làm việc từ DB, gọi sau khi admin cập nhật giờ làm hoặc ngày nghỉ
+func Reload() *Calendar {
+	cal := &Calendar{Location: time.Local}
+	var record models.BusinessCalendar
+	if models.DB != nil {
+		if err := models.DB.Preload("Hours").Preload("Holidays").First(&record, models.DefaultBusinessCalendarID).Error; err == nil {
+			built, err := FromModel(record)
+			if err != nil {
+				log.Println("[WARN] Lịch làm việc không hợp lệ, dùng giờ thực: ", err)
+			} else {
+				cal = built
+			}
+		}
+	}
+	mu.Lock()
+	current = cal
+	mu.Unlock()
+	return cal
+}
+
+// SetDefault thay lịch làm việc đang áp dụng (vd. lịch cố định khi kiểm thử)
+func SetDefault(cal *Calendar) {
+	mu.Lock()
+	current = cal
+	mu.Unlock()
+}
+
+// FromModel dựng Calendar từ bản ghi trong DB
+func FromModel(record models.BusinessCalendar) (*Calendar, error) {
+	loc, err := time.LoadLocation(record.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("múi giờ '%s' không hợp lệ", record.Timezone)
+	}
+	cal := &Calendar{
+		Location: loc,
+		Hours:    map[time.Weekday][]Interval{},
+		Holidays: map[string]bool{},
+	}
+	for _, h := range record.Hours {
+		iv, err := ParseInterval(h.StartTime, h.EndTime)
+		if err != nil {
+			return nil, err
+		}
+		if h.Weekday < 0 || h.Weekday > 6 {
+			return nil, fmt.Errorf("thứ trong tuần '%d' không hợp lệ", h.Weekday)
+		}
+		wd := time.Weekday(h.Weekday)
+		cal.Hours[wd] = append(cal.Hours[wd], iv)
+	}
+	// Khung giờ chồng nhau sẽ bị tính hai lần trong Add/Between
+	for wd := range cal.Hours {
+		ivs := cal.Hours[wd]
+		sort.Slice(ivs, func(i, j int) bool { return ivs[i].Start < ivs[j].Start })
+		for i := 1; i < len(ivs); i++ {
+			if ivs[i].Start < ivs[i-1].End {
+				return nil, fmt.Errorf("khung giờ %s-%s và %s-%s của %s bị chồng nhau",
+					formatClock(ivs[i-1].Start), formatClock(ivs[i-1].End), formatClock(ivs[i].Start), formatClock(ivs[i].End), weekdayNames[wd])
+			}
+		}
+	}
+	for _, h := range record.Holidays {
+		cal.Holidays[h.Date] = true
+	}
+	return cal, nil
+}
+
+// ParseInterval đọc khung giờ dạng "HH:MM"-"HH:MM"
+func ParseInterval(start, end string) (Interval, error) {
+	s, err := parseClock(start)
+	if err != nil {
+		return Interval{}, err
+	}
+	e, err := parseClock(end)
+	if err != nil {
+		return Interval{}, err
+	}
+	if e <= s {
+		return Interval{}, fmt.Errorf("khung giờ %s-%s không hợp lệ", start, end)
+	}
+	return Interval{Start: s, End: e}, nil
+}
+
+func parseClock(v string) (int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(v, "%d:%d", &h, &m); err != nil || h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, fmt.Errorf("giờ '%s' không hợp lệ", v)
+	}
+	return h*60 + m, nil
+}
+
+func formatClock(m int) string {
+	return fmt.Sprintf("%02d:%02d", m/60, m%60)
+}
+
+var weekdayNames = map[time.Weekday]string{
+	time.Sunday: "Chủ nhật", time.Monday: "thứ Hai", time.Tuesday: "thứ Ba", time.Wednesday: "thứ Tư",
+	time.Thursday: "thứ Năm", time.Friday: "thứ Sáu", time.Saturday: "thứ Bảy",
+}
+
+// hasHours cho biết lịch có khai báo giờ làm hay không; không có thì tính theo giờ thực
+func (c *Calendar) hasHours() bool {
+	for _, ivs := range c.Hours {
+		if len(ivs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// windows trả về các khung làm việc (theo thời gian tuyệt đối) của ngày chứa day
+func (c *Calendar) windows(day time.Time) [][2]time.Time {
+	if c.Holidays[day.Format("2006-01-02")] {
+		return nil
+	}
+	var result [][2]time.Time
+	for _, iv := range c.Hours[day.Weekday()] {
+		start := time.Date(day.Year(), day.Month(), day.Day(), 0, iv.Start, 0, 0, c.Location)
+		end := time.Date(day.Year(), day.Month(), day.Day(), 0, iv.End, 0, 0, c.Location)
+		result = append(result, [2]time.Time{start, end})
+	}
+	return result
+}
+
+// IsWorkingTime kiểm tra t có nằm trong giờ làm việc hay không
+func (c *Calendar) IsWorkingTime(t time.Time) bool {
+	if !c.hasHours() {
+		return true
+	}
+	local := t.In(c.Location)
+	for _, w := range c.windows(local) {
+		if !local.Before(w[0]) && local.Before(w[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Add cộng d giờ làm việc vào start và trả về thời điểm kết quả
+func (c *Calendar) Add(start time.Time, d time.Duration) time.Time {
+	if !c.hasHours() || d <= 0 {
+		return start.Add(d)
+	}
+	cur := start.In(c.Location)
+	remaining := d
+	day := time.Date(cur.Year(), cur.Month(), cur.Day(), 0, 0, 0, 0, c.Location)
+	for i := 0; i < maxScanDays; i++ {
+		for _, w := range c.windows(day.AddDate(0, 0, i)) {
+			from := w[0]
+			if cur.After(from) {
+				from = cur
+			}
+			if !from.Before(w[1]) {
+				continue
+			}
+			available := w[1].Sub(from)
+			if remaining <= available {
+				return from.Add(remaining)
+			}
+			remaining -= available
+		}
+	}
+	return start.Add(d)
+}
+
+// Between trả về số giờ làm việc giữa start và end (âm nếu end trước start)
+func (c *Calendar) Between(start, end time.Time) time.Duration {
+	if end.Before(start) {
+		return -c.Between(end, start)
+	}
+	if !c.hasHours() {
+		return end.Sub(start)
+	}
+	s := start.In(c.Location)
+	e := end.In(c.Location)
+	var total time.Duration
+	day := time.Date(s.Year(), s.Month(), s.Day(), 0, 0, 0, 0, c.Location)
+	for i := 0; i < maxScanDays; i++ {
+		d := day.AddDate(0, 0, i)
+		if d.After(e) {
+			break
+		}
+		for _, w := range c.windows(d) {
+			from, to := w[0], w[1]
+			if s.After(from) {
+				from = s
+			}
+			if e.Before(to) {
+				to = e
+			}
+			if to.After(from) {
+				total += to.Sub(from)
+			}
+		}
+	}
+	return total
+}
@@ -0,0 +1,133 @@
+package calendar
+
+import (
+	"awesomeProject/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Lịch thử: thứ Hai - thứ Sáu 08:00-12:00 và 13:00-17:00 giờ Việt Nam, nghỉ 01/01/2026
+func testCalendar(t *testing.T) *Calendar {
+	t.Helper()
+	var hours []models.BusinessHour
+	for wd := 1; wd <= 5; wd++ {
+		hours = append(hours,
+			models.BusinessHour{Weekday: wd, StartTime: "13:00", EndTime: "17:00"},
+			models.BusinessHour{Weekday: wd, StartTime: "08:00", EndTime: "12:00"})
+	}
+	cal, err := FromModel(models.BusinessCalendar{
+		Timezone: "Asia/Ho_Chi_Minh",
+		Hours:    hours,
+		Holidays: []models.Holiday{{Date: "2026-01-01"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cal
+}
+
+// vn tạo thời điểm theo giờ Việt Nam; 05/01/2026 là thứ Hai
+func vn(day, hour, min int) time.Time {
+	loc, _ := time.LoadLocation("Asia/Ho_Chi_Minh")
+	return time.Date(2026, 1, day, hour, min, 0, 0, loc)
+}
+
+func TestBetween(t *testing.T) {
+	cal := testCalendar(t)
+	tests := []struct {
+		name       string
+		start, end time.Time
+		want       time.Duration
+	}{
+		{"cùng khung giờ", vn(5, 9, 0), vn(5, 11, 30), 150 * time.Minute},
+		{"qua giờ nghỉ trưa", vn(5, 11, 0), vn(5, 14, 0), 2 * time.Hour},
+		{"trọn ngày làm việc", vn(5, 0, 0), vn(6, 0, 0), 8 * time.Hour},
+		{"ngoài giờ làm", vn(5, 18, 0), vn(6, 7, 0), 0},
+		{"qua cuối tuần", vn(9, 16, 0), vn(12, 9, 0), 2 * time.Hour},
+		{"ngày nghỉ lễ", vn(1, 9, 0), vn(1, 16, 0), 0},
+		{"cả tuần", vn(5, 0, 0), vn(12, 0, 0), 40 * time.Hour},
+		{"đảo ngược thì âm", vn(5, 14, 0), vn(5, 11, 0), -2 * time.Hour},
+		{"khác múi giờ đầu vào", vn(5, 9, 0).UTC(), vn(5, 10, 0).UTC(), time.Hour},
+	}
+	for _, tt := range tests {
+		if got := cal.Between(tt.start, tt.end); got != tt.want {
+			t.Errorf("%s: Between = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	cal := testCalendar(t)
+	tests := []struct {
+		name  string
+		start time.Time
+		d     time.Duration
+		want  time.Time
+	}{
+		{"trong khung giờ", vn(5, 9, 0), 2 * time.Hour, vn(5, 11, 0)},
+		{"qua giờ nghỉ trưa", vn(5, 11, 0), 2 * time.Hour, vn(5, 14, 0)},
+		{"bắt đầu ngoài giờ", vn(5, 19, 0), time.Hour, vn(6, 9, 0)},
+		{"qua cuối tuần", vn(9, 16, 0), 2 * time.Hour, vn(12, 9, 0)},
+		{"bỏ qua ngày nghỉ lễ", vn(31, 16, 0).AddDate(0, -1, 0), 2 * time.Hour, vn(2, 9, 0)},
+		{"kết thúc đúng cuối khung", vn(5, 8, 0), 4 * time.Hour, vn(5, 12, 0)},
+		{"không cộng", vn(5, 19, 0), 0, vn(5, 19, 0)},
+	}
+	for _, tt := range tests {
+		got := cal.Add(tt.start, tt.d)
+		if !got.Equal(tt.want) {
+			t.Errorf("%s: Add = %v, want %v", tt.name, got, tt.want)
+		}
+		if tt.d > 0 && cal.Between(tt.start, got) != tt.d {
+			t.Errorf("%s: Between(start, Add(start, d)) = %v, want %v", tt.name, cal.Between(tt.start, got), tt.d)
+		}
+	}
+}
+
+func TestWithoutHoursUsesWallClock(t *testing.T) {
+	cal := &Calendar{Location: time.UTC}
+	start := vn(10, 22, 0)
+	if got := cal.Between(start, start.Add(5*time.Hour)); got != 5*time.Hour {
+		t.Errorf("Between = %v", got)
+	}
+	if got := cal.Add(start, 5*time.Hour); !got.Equal(start.Add(5 * time.Hour)) {
+		t.Errorf("Add = %v", got)
+	}
+	if !cal.IsWorkingTime(start) {
+		t.Error("lịch không có giờ làm thì luôn là giờ làm việc")
+	}
+}
+
+func TestFromModelValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		timezone string
+		hours    [][3]interface{}
+		wantErr  string
+	}{
+		{"hợp lệ", "Asia/Ho_Chi_Minh", [][3]interface{}{{1, "08:00", "12:00"}, {1, "13:00", "17:00"}}, ""},
+		{"khung giờ liền nhau", "UTC", [][3]interface{}{{2, "08:00", "12:00"}, {2, "12:00", "17:00"}}, ""},
+		{"cả ngày", "UTC", [][3]interface{}{{0, "00:00", "24:00"}}, ""},
+		{"cùng giờ ở hai ngày khác nhau", "UTC", [][3]interface{}{{1, "08:00", "17:00"}, {2, "08:00", "17:00"}}, ""},
+		{"chồng nhau", "UTC", [][3]interface{}{{1, "13:00", "17:00"}, {1, "08:00", "13:30"}}, "chồng nhau"},
+		{"trùng lặp", "UTC", [][3]interface{}{{3, "08:00", "17:00"}, {3, "08:00", "17:00"}}, "chồng nhau"},
+		{"nằm trong khung khác", "UTC", [][3]interface{}{{4, "08:00", "17:00"}, {4, "10:00", "11:00"}}, "chồng nhau"},
+		{"kết thúc trước bắt đầu", "UTC", [][3]interface{}{{1, "17:00", "08:00"}}, "không hợp lệ"},
+		{"giờ sai định dạng", "UTC", [][3]interface{}{{1, "8h", "17:00"}}, "không hợp lệ"},
+		{"thứ không hợp lệ", "UTC", [][3]interface{}{{7, "08:00", "17:00"}}, "không hợp lệ"},
+		{"múi giờ không hợp lệ", "Mars/Base", nil, "múi giờ"},
+	}
+	for _, tt := range tests {
+		var hours []models.BusinessHour
+		for _, h := range tt.hours {
+			hours = append(hours, models.BusinessHour{Weekday: h[0].(int), StartTime: h[1].(string), EndTime: h[2].(string)})
+		}
+		_, err := FromModel(models.BusinessCalendar{Timezone: tt.timezone, Hours: hours})
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: err = %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: err = %v, want chứa %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
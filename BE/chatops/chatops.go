@@ -0,0 +1,214 @@
+// Package chatops đăng thông báo ticket lên kênh chat qua incoming webhook của Slack hoặc Mattermost.
+// Slack nhận tin nhắn dạng blocks, Mattermost nhận dạng attachments (tương thích Slack cũ);
+// cả hai đều có trường text làm nội dung dự phòng cho thông báo đẩy.
+package chatops
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Loại kênh chat
+const (
+	ProviderSlack      = "slack"
+	ProviderMattermost = "mattermost"
+)
+
+var Providers = []string{ProviderSlack, ProviderMattermost}
+
+// Các sự kiện có thể đăng lên kênh chat
+const (
+	EventTicketCreated = "ticket_created" // ticket mới
+	EventSLAReminder   = "sla_reminder"   // ticket quá hạn SLA hoặc chưa phản hồi quá lâu
+)
+
+var Events = []string{EventTicketCreated, EventSLAReminder}
+
+// IsValidProvider kiểm tra loại kênh chat được hỗ trợ
+func IsValidProvider(p string) bool {
+	return contains(Providers, p)
+}
+
+// IsValidEvent kiểm tra sự kiện có thể đăng lên kênh chat
+func IsValidEvent(e string) bool {
+	return contains(Events, e)
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Ticket là thông tin ticket hiển thị trong tin nhắn
+type Ticket struct {
+	ID       uint
+	Title    string
+	Status   string
+	Category string
+	Priority string
+	Customer string
+	Assignee string // rỗng = chưa phân công
+	URL      string // link đến trang ticket, rỗng thì không gắn link
+}
+
+// Message là tin nhắn về một sự kiện, được định dạng theo từng loại kênh khi gửi
+type Message struct {
+	Heading string
+	Ticket  Ticket
+	Note    string
+	Color   string // màu thanh bên cạnh tin nhắn (Mattermost)
+}
+
+// NewTicketMessage là tin nhắn báo ticket mới
+func NewTicketMessage(t Ticket) Message {
+	return Message{Heading: "Ticket mới", Ticket: t, Color: "#2eb67d"}
+}
+
+// ReminderMessage là tin nhắn nhắc ticket trễ.
+// reason: sla_breached (quá hạn SLA) hoặc no_response (chưa phản hồi quá 24 giờ làm việc)
+func ReminderMessage(t Ticket, reason string) Message {
+	if reason == "sla_breached" {
+		return Message{Heading: "Ticket quá hạn SLA", Ticket: t, Note: "Ticket đã quá hạn SLA và chưa được xử lý xong.", Color: "#e01e5a"}
+	}
+	return Message{Heading: "Ticket chưa được phản hồi", Ticket: t, Note: "Ticket chưa được phản hồi trong hơn 24 giờ làm việc.", Color: "#ecb22e"}
+}
+
+// TestMessage là tin nhắn thử khi admin kiểm tra cấu hình kênh
+func TestMessage() Message {
+	return Message{Heading: "Tin nhắn thử", Note: "Kênh chat đã được kết nối với hệ thống hỗ trợ.", Color: "#1d9bd1"}
+}
+
+// fallback là nội dung dạng văn bản thuần
+func (m Message) fallback() string {
+	if m.Ticket.ID == 0 {
+		return m.Heading + ": " + m.Note
+	}
+	return fmt.Sprintf("%s #%d: %s", m.Heading, m.Ticket.ID, m.Ticket.Title)
+}
+
+func (m Message) fields() [][2]string {
+	t := m.Ticket
+	if t.ID == 0 {
+		return nil
+	}
+	assignee := t.Assignee
+	if assignee == "" {
+		assignee = "Chưa phân công"
+	}
+	var fields [][2]string
+	for _, f := range [][2]string{
+		{"Loại", t.Category},
+		{"Mức ưu tiên", t.Priority},
+		{"Trạng thái", t.Status},
+		{"Khách hàng", t.Customer},
+		{"Người xử lý", assignee},
+	} {
+		if f[1] != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// escape thoát các ký tự đặc biệt của định dạng mrkdwn
+func escape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// Payload dựng nội dung JSON gửi đến incoming webhook theo loại kênh
+func Payload(provider string, m Message) map[string]interface{} {
+	if provider == ProviderMattermost {
+		return mattermostPayload(m)
+	}
+	return slackPayload(m)
+}
+
+func slackPayload(m Message) map[string]interface{} {
+	title := "*" + escape(m.Heading) + "*"
+	if t := m.Ticket; t.ID != 0 {
+		ticket := fmt.Sprintf("#%d: %s", t.ID, escape(t.Title))
+		if t.URL != "" {
+			ticket = fmt.Sprintf("<%s|#%d: %s>", t.URL, t.ID, escape(t.Title))
+		}
+		title += "\n" + ticket
+	}
+	blocks := []map[string]interface{}{
+		{"type": "section", "text": map[string]string{"type": "mrkdwn", "text": title}},
+	}
+	if fields := m.fields(); len(fields) > 0 {
+		items := make([]map[string]string, 0, len(fields))
+		for _, f := range fields {
+			items = append(items, map[string]string{"type": "mrkdwn", "text": "*" + f[0] + "*\n" + escape(f[1])})
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "section", "fields": items})
+	}
+	if m.Note != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type":     "context",
+			"elements": []map[string]string{{"type": "mrkdwn", "text": escape(m.Note)}},
+		})
+	}
+	return map[string]interface{}{"text": escape(m.fallback()), "blocks": blocks}
+}
+
+// escapeMattermost thoát ký tự đặc biệt như escape và chèn ký tự rộng 0 sau "@"
+// để @all/@channel trong nội dung ticket không gọi cả kênh
+func escapeMattermost(s string) string {
+	return strings.ReplaceAll(escape(s), "@", "@\u200b")
+}
+
+func mattermostPayload(m Message) map[string]interface{} {
+	fields := make([]map[string]interface{}, 0)
+	for _, f := range m.fields() {
+		fields = append(fields, map[string]interface{}{"title": f[0], "value": escapeMattermost(f[1]), "short": true})
+	}
+	attachment := map[string]interface{}{
+		"fallback": escapeMattermost(m.fallback()),
+		"color":    m.Color,
+		"pretext":  escapeMattermost(m.Heading),
+		"text":     escapeMattermost(m.Note),
+		"fields":   fields,
+	}
+	if t := m.Ticket; t.ID != 0 {
+		attachment["title"] = fmt.Sprintf("#%d: %s", t.ID, escapeMattermost(t.Title))
+		if t.URL != "" {
+			attachment["title_link"] = t.URL
+		}
+	}
+	return map[string]interface{}{"text": escapeMattermost(m.fallback()), "attachments": []interface{}{attachment}}
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Post gửi tin nhắn đến incoming webhook của kênh
+func Post(ctx context.Context, provider, webhookURL string, m Message) error {
+	body, err := json.Marshal(Payload(provider, m))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("chatops: kênh chat trả về mã %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package chatops
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const hostileTitle = `<!channel> @all <http://evil.example|bấm vào> & *đậm*`
+
+func hostileTicket(url string) Ticket {
+	return Ticket{
+		ID: 42, Title: hostileTitle, Status: "new", Category: "Lỗi", Priority: "Cao",
+		Customer: "<@U123> Khách", URL: url,
+	}
+}
+
+// payloadText mã hóa payload thành JSON như khi gửi rồi gom mọi chuỗi trong đó để kiểm tra nội dung
+func payloadText(t *testing.T, provider string, m Message) string {
+	t.Helper()
+	data, err := json.Marshal(Payload(provider, m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded interface{}
+	json.Unmarshal(data, &decoded)
+	var b strings.Builder
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch x := v.(type) {
+		case map[string]interface{}:
+			for _, item := range x {
+				walk(item)
+			}
+		case []interface{}:
+			for _, item := range x {
+				walk(item)
+			}
+		case string:
+			b.WriteString(x)
+			b.WriteString("\n")
+		}
+	}
+	walk(decoded)
+	return b.String()
+}
+
+func TestPayloadEscapesTicketText(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		url      string
+		contains []string
+		absent   []string
+	}{
+		{
+			"Slack", ProviderSlack, "",
+			[]string{"#42: &lt;!channel&gt; @all &lt;http://evil.example|bấm vào&gt; &amp; *đậm*", "&lt;@U123&gt; Khách"},
+			[]string{"<!channel>", "<http://evil.example", "<@U123>"},
+		},
+		{
+			"Slack có link ticket", ProviderSlack, "https://support.example/tickets/42",
+			[]string{"<https://support.example/tickets/42|#42: &lt;!channel&gt;"},
+			[]string{"<!channel>", "<http://evil.example"},
+		},
+		{
+			"Mattermost", ProviderMattermost, "https://support.example/tickets/42",
+			[]string{"#42: &lt;!channel&gt; @\u200ball &lt;http://evil.example|bấm vào&gt; &amp; *đậm*", "https://support.example/tickets/42"},
+			[]string{"<!channel>", "<http://evil.example", "<@U123>", "@all", "@U123"},
+		},
+	}
+	for _, tt := range tests {
+		got := payloadText(t, tt.provider, NewTicketMessage(hostileTicket(tt.url)))
+		for _, want := range tt.contains {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: payload thiếu %q:\n%s", tt.name, want, got)
+			}
+		}
+		for _, bad := range tt.absent {
+			if strings.Contains(got, bad) {
+				t.Errorf("%s: payload chứa nội dung chưa escape %q:\n%s", tt.name, bad, got)
+			}
+		}
+	}
+}
+
+func TestPayloadShape(t *testing.T) {
+	msg := ReminderMessage(Ticket{ID: 7, Title: "Máy in", Priority: "Cao"}, "sla_breached")
+
+	slack := Payload(ProviderSlack, msg)
+	blocks, _ := slack["blocks"].([]map[string]interface{})
+	if slack["text"] != "Ticket quá hạn SLA #7: Máy in" || len(blocks) != 3 {
+		t.Errorf("Slack payload = %+v", slack)
+	}
+
+	mm := Payload(ProviderMattermost, msg)
+	attachments, _ := mm["attachments"].([]interface{})
+	if len(attachments) != 1 {
+		t.Fatalf("Mattermost attachments = %+v", mm["attachments"])
+	}
+	a := attachments[0].(map[string]interface{})
+	fields, _ := a["fields"].([]map[string]interface{})
+	if a["color"] != "#e01e5a" || a["title"] != "#7: Máy in" || a["title_link"] != nil || len(fields) != 2 {
+		t.Errorf("Mattermost attachment = %+v", a)
+	}
+
+	// Tin nhắn thử không gắn với ticket nào
+	test := Payload(ProviderMattermost, TestMessage())
+	a = test["attachments"].([]interface{})[0].(map[string]interface{})
+	if _, ok := a["title"]; ok || !strings.HasPrefix(test["text"].(string), "Tin nhắn thử: ") {
+		t.Errorf("Mattermost test payload = %+v", test)
+	}
+}
+
+func TestPost(t *testing.T) {
+	var got map[string]interface{}
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &got)
+		w.WriteHeader(status)
+		io.WriteString(w, "invalid_payload")
+	}))
+	defer srv.Close()
+
+	if err := Post(context.Background(), ProviderSlack, srv.URL, TestMessage()); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if _, ok := got["blocks"]; !ok {
+		t.Errorf("body = %+v, muốn payload Slack", got)
+	}
+	status = http.StatusBadRequest
+	if err := Post(context.Background(), ProviderMattermost, srv.URL, TestMessage()); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("Post khi kênh trả lỗi = %v", err)
+	}
+}
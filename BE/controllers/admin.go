@@ -1,15 +1,19 @@
 package controllers
 
 import (
+	"awesomeProject/calendar"
 	"awesomeProject/models"
+	"awesomeProject/sla"
+	"awesomeProject/workflow"
 	"crypto/sha256"
-	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
 // AdminDashboardStats trả về thống kê tổng quan cho dashboard
@@ -21,35 +25,60 @@ func AdminDashboardStats(c *fiber.Ctx) error {
 	var processingTickets int64
 	var avgProcessingTime float64
 
-	// Base query - filter by role
-	baseQuery := models.DB.Model(&models.Ticket{})
-	if userRole == "staff" {
-		baseQuery = baseQuery.Where("assigned_to = ?", user.ID)
+	// Lọc theo team (?team_id=): staff chỉ được xem team mình là thành viên
+	var teamID *uint
+	if v := c.QueryInt("team_id"); v > 0 {
+		id := uint(v)
+		if userRole == "staff" && !isTeamMember(user.ID, id) {
+			return c.Status(403).JSON(fiber.Map{
+				"message": "Bạn không thuộc team này",
+				"success": false,
+			})
+		}
+		teamID = &id
+	}
+
+	// Điều kiện chung cho mọi thống kê: theo team nếu có lọc, staff không lọc team chỉ thấy ticket được giao
+	var ticketFilter string
+	var ticketArgs []interface{}
+	if teamID != nil {
+		ticketFilter = "tickets.team_id = ?"
+		ticketArgs = []interface{}{*teamID}
+	} else if userRole == "staff" {
+		ticketFilter = "tickets.assigned_to = ?"
+		ticketArgs = []interface{}{user.ID}
+	}
+	dashboardScope := func(db *gorm.DB) *gorm.DB {
+		if ticketFilter == "" {
+			return db
+		}
+		return db.Where(ticketFilter, ticketArgs...)
 	}
 
+	// Base query - filter by role/team
+	baseQuery := models.DB.Model(&models.Ticket{}).Scopes(dashboardScope)
+
 	// Đếm tổng số ticket
 	baseQuery.Count(&totalTickets)
 
 	// Đếm ticket đang xử lý
 	processingQuery := models.DB.Model(&models.Ticket{}).Where("status IN ('Mới', 'Đang xử lý', 'Chờ phản hồi')")
-	if userRole == "staff" {
-		processingQuery = processingQuery.Where("assigned_to = ?", user.ID)
-	}
+	processingQuery = processingQuery.Scopes(dashboardScope)
 	processingQuery.Count(&processingTickets)
 
 	// Tính thời gian xử lý trung bình (chỉ tính các ticket đã xử lý)
 	completedQuery := models.DB.Where("status = 'Đã xử lý' AND resolved_at IS NOT NULL")
-	if userRole == "staff" {
-		completedQuery = completedQuery.Where("assigned_to = ?", user.ID)
-	}
+	completedQuery = completedQuery.Scopes(dashboardScope)
 
 	var completedTickets []models.Ticket
 	completedQuery.Find(&completedTickets)
 
+	// Thời gian xử lý tính theo giờ làm việc của lịch hành chính
+	cal := calendar.Default()
 	var totalProcessingTime time.Duration
 	for _, ticket := range completedTickets {
 		if !ticket.CreatedAt.IsZero() && !ticket.ResolvedAt.IsZero() {
-			totalProcessingTime += ticket.ResolvedAt.Sub(ticket.CreatedAt)
+			totalProcessingTime += cal.Between(ticket.CreatedAt, *ticket.ResolvedAt)
 		}
 	}
 
@@ -62,14 +91,14 @@ func AdminDashboardStats(c *fiber.Ctx) error {
 	var statusQuery string
 	var statusArgs []interface{}
 
-	if userRole == "staff" {
+	if ticketFilter != "" {
 		statusQuery = `
 			SELECT status, COUNT(*) as count 
 			FROM tickets 
-			WHERE assigned_to = ?
+			WHERE ` + ticketFilter + `
 			GROUP BY status
 		`
-		statusArgs = []interface{}{user.ID}
+		statusArgs = ticketArgs
 	} else {
 		statusQuery = `
 		SELECT status, COUNT(*) as count 
@@ -93,17 +122,17 @@ func AdminDashboardStats(c *fiber.Ctx) error {
 	var categoryQuery string
 	var categoryArgs []interface{}
 
-	if userRole == "staff" {
+	if ticketFilter != "" {
 		categoryQuery = `
 			SELECT 
 				COALESCE(ticket_categories.name, 'Không phân loại') as category_name,
 				COUNT(*) as count 
 			FROM tickets 
 			LEFT JOIN ticket_categories ON tickets.category_id = ticket_categories.id
-			WHERE tickets.assigned_to = ?
+			WHERE ` + ticketFilter + `
 			GROUP BY ticket_categories.name
 		`
-		categoryArgs = []interface{}{user.ID}
+		categoryArgs = ticketArgs
 	} else {
 		categoryQuery = `
 			SELECT 
@@ -130,17 +159,17 @@ func AdminDashboardStats(c *fiber.Ctx) error {
 	var productTypeQuery string
 	var productTypeArgs []interface{}
 
-	if userRole == "staff" {
+	if ticketFilter != "" {
 		productTypeQuery = `
 			SELECT 
 				COALESCE(ticket_product_types.name, 'Không phân loại') as product_type_name,
 				COUNT(*) as count 
 			FROM tickets 
 			LEFT JOIN ticket_product_types ON tickets.product_type_id = ticket_product_types.id
-			WHERE tickets.assigned_to = ?
+			WHERE ` + ticketFilter + `
 			GROUP BY ticket_product_types.name
 		`
-		productTypeArgs = []interface{}{user.ID}
+		productTypeArgs = ticketArgs
 	} else {
 		productTypeQuery = `
 			SELECT 
@@ -168,17 +197,13 @@ func AdminDashboardStats(c *fiber.Ctx) error {
 	var ticketsThisMonth int64
 
 	monthlyQuery := models.DB.Model(&models.Ticket{}).Where("created_at >= ?", firstOfMonth)
-	if userRole == "staff" {
-		monthlyQuery = monthlyQuery.Where("assigned_to = ?", user.ID)
-	}
+	monthlyQuery = monthlyQuery.Scopes(dashboardScope)
 	monthlyQuery.Count(&ticketsThisMonth)
 
 	// Thống kê số ticket đã xử lý trong tháng
 	var ticketsResolvedThisMonth int64
 	resolvedMonthlyQuery := models.DB.Model(&models.Ticket{}).Where("status = 'Đã xử lý' AND resolved_at >= ?", firstOfMonth)
-	if userRole == "staff" {
-		resolvedMonthlyQuery = resolvedMonthlyQuery.Where("assigned_to = ?", user.ID)
-	}
+	resolvedMonthlyQuery = resolvedMonthlyQuery.Scopes(dashboardScope)
 	resolvedMonthlyQuery.Count(&ticketsResolvedThisMonth)
 
 	// Thống kê nhân viên xuất sắc (xử lý nhiều nhất all time)
@@ -191,60 +216,60 @@ func AdminDashboardStats(c *fiber.Ctx) error {
 	}
 	var staffStats []StaffStat
 
-	// Lấy tất cả nhân viên (admin và staff) và thống kê ticket của họ (all time)
-	var staffQuery string
+	// Lấy tất cả nhân viên (admin và staff) và số ticket của họ (all time);
+	// thời gian xử lý trung bình tính theo giờ làm việc nên tính sau bằng lịch làm việc
+	var staffTeamJoin string
 	var staffArgs []interface{}
-
+	if teamID != nil {
+		staffTeamJoin = " AND tickets.team_id = ?"
+		staffArgs = []interface{}{*teamID}
+	}
+	staffQuery := `
+		SELECT 
+			users.id as staff_id, 
+			users.name, 
+			users.email, 
+			COALESCE(COUNT(tickets.id), 0) as count
+		FROM users 
+		LEFT JOIN tickets ON users.id = tickets.assigned_to` + staffTeamJoin + `
+		WHERE users.role IN ('admin', 'staff') AND users.is_service_account = ?`
+	staffArgs = append(staffArgs, false)
+	staffLimit := 5
 	if userRole == "staff" {
 		// Staff chỉ thấy thống kê của chính mình
-		staffQuery = `
-			SELECT 
-				users.id as staff_id, 
-				users.name, 
-				users.email, 
-				COALESCE(COUNT(tickets.id), 0) as count,
-				COALESCE(AVG(CASE 
-					WHEN tickets.status = 'Đã xử lý' AND tickets.resolved_at IS NOT NULL 
-					THEN TIMESTAMPDIFF(SECOND, tickets.created_at, tickets.resolved_at)/3600 
-					ELSE NULL 
-				END), 0) as avg_time
-			FROM users 
-			LEFT JOIN tickets ON users.id = tickets.assigned_to 
-			WHERE users.id = ? AND users.role IN ('admin', 'staff')
-			GROUP BY users.id, users.name, users.email
-			ORDER BY count DESC, avg_time ASC
-		`
-		staffArgs = []interface{}{user.ID}
-	} else {
-		// Admin thấy tất cả staff
-		staffQuery = `
-			SELECT 
-				users.id as staff_id, 
-				users.name, 
-				users.email, 
-				COALESCE(COUNT(tickets.id), 0) as count,
-				COALESCE(AVG(CASE 
-					WHEN tickets.status = 'Đã xử lý' AND tickets.resolved_at IS NOT NULL 
-					THEN TIMESTAMPDIFF(SECOND, tickets.created_at, tickets.resolved_at)/3600 
-					ELSE NULL 
-				END), 0) as avg_time
-			FROM users 
-			LEFT JOIN tickets ON users.id = tickets.assigned_to 
-			WHERE users.role IN ('admin', 'staff')
-			GROUP BY users.id, users.name, users.email
-		ORDER BY count DESC, avg_time ASC
-		LIMIT 5
-		`
-	}
-
+		staffQuery += " AND users.id = ?"
+		staffArgs = append(staffArgs, user.ID)
+		staffLimit = 1
+	} else if teamID != nil {
+		// Lọc theo team: chỉ xếp hạng thành viên team trên ticket của team
+		staffQuery += " AND users.id IN (SELECT user_id FROM team_members WHERE team_id = ?)"
+		staffArgs = append(staffArgs, *teamID)
+	}
+	staffQuery += " GROUP BY users.id, users.name, users.email"
 	models.DB.Raw(staffQuery, staffArgs...).Scan(&staffStats)
 
+	staffIDs := make([]uint, len(staffStats))
+	for i, s := range staffStats {
+		staffIDs[i] = s.StaffID
+	}
+	avgTimes := staffAvgResolutionHours(staffIDs, teamID)
+	for i := range staffStats {
+		staffStats[i].AvgTime = avgTimes[staffStats[i].StaffID]
+	}
+	sort.SliceStable(staffStats, func(i, j int) bool {
+		if staffStats[i].Count != staffStats[j].Count {
+			return staffStats[i].Count > staffStats[j].Count
+		}
+		return staffStats[i].AvgTime < staffStats[j].AvgTime
+	})
+	if len(staffStats) > staffLimit {
+		staffStats = staffStats[:staffLimit]
+	}
+
 	// Tính tỷ lệ giải quyết all time
 	var totalResolvedTickets int64
 	resolutionQuery := models.DB.Model(&models.Ticket{}).Where("status IN (?, ?)", "Đã xử lý", "Đã đóng")
-	if userRole == "staff" {
-		resolutionQuery = resolutionQuery.Where("assigned_to = ?", user.ID)
-	}
+	resolutionQuery = resolutionQuery.Scopes(dashboardScope)
 	resolutionQuery.Count(&totalResolvedTickets)
 
 	resolutionRate := float64(0)
@@ -275,23 +300,17 @@ func AdminDashboardStats(c *fiber.Ctx) error {
 
 		// New tickets - đếm tickets được tạo trong ngày
 		newQuery := models.DB.Model(&models.Ticket{}).Where("created_at >= ? AND created_at < ?", startOfDay, endOfDay)
-		if userRole == "staff" {
-			newQuery = newQuery.Where("assigned_to = ?", user.ID)
-		}
+		newQuery = newQuery.Scopes(dashboardScope)
 		newQuery.Count(&newTicketsCount)
 
 		// Pending tickets - đếm tickets có trạng thái pending hiện tại
 		pendingQuery := models.DB.Model(&models.Ticket{}).Where("status IN (?, ?)", "Đang xử lý", "Chờ phản hồi")
-		if userRole == "staff" {
-			pendingQuery = pendingQuery.Where("assigned_to = ?", user.ID)
-		}
+		pendingQuery = pendingQuery.Scopes(dashboardScope)
 		pendingQuery.Count(&pendingTicketsCount)
 
 		// Resolved tickets - đếm tickets được resolved trong ngày
 		resolvedQuery := models.DB.Model(&models.Ticket{}).Where("resolved_at >= ? AND resolved_at < ?", startOfDay, endOfDay)
-		if userRole == "staff" {
-			resolvedQuery = resolvedQuery.Where("assigned_to = ?", user.ID)
-		}
+		resolvedQuery = resolvedQuery.Scopes(dashboardScope)
 		resolvedQuery.Count(&resolvedTicketsCount)
 
 		dailyStats = append(dailyStats, DailyStats{
@@ -320,6 +339,33 @@ func AdminDashboardStats(c *fiber.Ctx) error {
 	})
 }
 
+// staffAvgResolutionHours tính thời gian xử lý trung bình (giờ làm việc) của các ticket đã xử lý theo nhân viên được giao
+func staffAvgResolutionHours(staffIDs []uint, teamID *uint) map[uint]float64 {
+	result := map[uint]float64{}
+	if len(staffIDs) == 0 {
+		return result
+	}
+	var tickets []models.Ticket
+	query := models.DB.Select("id", "assigned_to", "created_at", "resolved_at").
+		Where("assigned_to IN ? AND status = ? AND resolved_at IS NOT NULL", staffIDs, workflow.StatusResolved)
+	if teamID != nil {
+		query = query.Where("team_id = ?", *teamID)
+	}
+	query.Find(&tickets)
+
+	cal := calendar.Default()
+	total := map[uint]time.Duration{}
+	count := map[uint]int{}
+	for _, t := range tickets {
+		total[*t.AssignedTo] += cal.Between(t.CreatedAt, *t.ResolvedAt)
+		count[*t.AssignedTo]++
+	}
+	for id, n := range count {
+		result[id] = total[id].Hours() / float64(n)
+	}
+	return result
+}
+
 // AdminUpdateTicketStatus cập nhật trạng thái và ưu tiên của ticket
 func AdminUpdateTicketStatus(c *fiber.Ctx) error {
 	user := c.Locals("user").(models.User)
@@ -348,17 +394,17 @@ func AdminUpdateTicketStatus(c *fiber.Ctx) error {
 			"success": false,
 		})
 	}
-	// Cập nhật trạng thái
+	before := ticket
+	// Cập nhật trạng thái qua máy trạng thái ticket
 	if input.Status != "" {
-		ticket.Status = input.Status
-		if input.Status == "Đã xử lý" {
-			now := time.Now()
-			ticket.ResolvedAt = &now
+		if err := workflow.Transition(&ticket, input.Status, user.Role, time.Now()); err != nil {
+			return respondTransitionError(c, err)
 		}
 	}
-	// Cập nhật ưu tiên
-	if input.PriorityID > 0 {
+	// Cập nhật ưu tiên (tính lại hạn SLA theo ưu tiên mới)
+	if input.PriorityID > 0 && input.PriorityID != ticket.PriorityID {
 		ticket.PriorityID = input.PriorityID
+		sla.Apply(&ticket, time.Now())
 	}
 	if err := models.DB.Save(&ticket).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -366,16 +412,10 @@ func AdminUpdateTicketStatus(c *fiber.Ctx) error {
 			"success": false,
 		})
 	}
-	// Tạo notification cho user khi trạng thái ticket thay đổi
-	if ticket.UserID > 0 && input.Status != "" {
-		notifData, _ := json.Marshal(fiber.Map{"ticket_id": ticket.ID, "action": "status", "status": ticket.Status})
-		n := models.Notification{
-			UserID:  ticket.UserID,
-			Type:    "ticket_status",
-			Content: "Trạng thái ticket #" + strconv.Itoa(int(ticket.ID)) + " đã thay đổi thành '" + ticket.Status + "'",
-			Data:    string(notifData),
-		}
-		models.DB.Create(&n)
+	recordTicketChanges(before, ticket, &user.ID)
+	// Báo cho chủ ticket khi trạng thái ticket thay đổi
+	if ticket.Status != before.Status {
+		notifyTicketOwnerStatus(ticket, before.Status, user)
 	}
 	return c.JSON(fiber.Map{
 		"message": "Cập nhật ticket thành công",
@@ -395,7 +435,7 @@ func AdminGetTicketDetail(c *fiber.Ctx) error {
 			"success": false,
 		})
 	}
-	if user.Role == "staff" && (ticket.AssignedTo == nil || *ticket.AssignedTo != user.ID) {
+	if user.Role == "staff" && !canStaffViewTicket(user, ticket) {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"message": "Bạn không có quyền truy cập ticket này",
 			"success": false,
@@ -420,17 +460,23 @@ func AdminGetTicketDetail(c *fiber.Ctx) error {
 		pri = fiber.Map{"id": nil, "name": "Không xác định"}
 	}
 	resp := fiber.Map{
-		"id":              ticket.ID,
-		"title":           ticket.Title,
-		"description":     ticket.Description,
-		"category":        cat,
-		"status":          ticket.Status,
-		"priority":        pri,
-		"created_at":      ticket.CreatedAt,
-		"updated_at":      ticket.UpdatedAt,
-		"resolved_at":     ticket.ResolvedAt,
-		"attachment_path": ticket.AttachmentPath,
-		"product_type":    prod,
+		"id":                  ticket.ID,
+		"title":               ticket.Title,
+		"description":         ticket.Description,
+		"category":            cat,
+		"status":              ticket.Status,
+		"priority":            pri,
+		"created_at":          ticket.CreatedAt,
+		"updated_at":          ticket.UpdatedAt,
+		"resolved_at":         ticket.ResolvedAt,
+		"first_response_at":   ticket.FirstResponseAt,
+		"closed_at":           ticket.ClosedAt,
+		"reopened_at":         ticket.ReopenedAt,
+		"reopen_count":        ticket.ReopenCount,
+		"allowed_transitions": workflow.AllowedTransitions(ticket.Status, user.Role),
+		"attachment_path":     ticket.AttachmentPath,
+		"product_type":        prod,
+		"team_id":             ticket.TeamID,
 		"user": fiber.Map{
 			"id":    ticket.User.ID,
 			"name":  ticket.User.Name,
@@ -438,6 +484,11 @@ func AdminGetTicketDetail(c *fiber.Ctx) error {
 			"role":  ticket.User.Role,
 		},
 	}
+	addSLAFields(resp, ticket, time.Now())
+	var attachments []models.Attachment
+	models.DB.Scopes(models.AttachmentsOf(models.AttachmentOwnerTicket, ticket.ID), models.AttachmentsVisibleTo(user.Role)).Order("id").Find(&attachments)
+	resp["attachments"] = attachmentsResponse(attachments)
+	resp["thumbnail_url"] = firstThumbnailURL(attachments)
 	if ticket.Assigned != nil {
 		resp["assigned"] = fiber.Map{
 			"id":    ticket.Assigned.ID,
@@ -484,14 +535,15 @@ func AdminListUsers(c *fiber.Ctx) error {
 	result := make([]fiber.Map, 0, len(users))
 	for _, u := range users {
 		result = append(result, fiber.Map{
-			"id":          u.ID,
-			"name":        u.Name,
-			"email":       u.Email,
-			"phone":       u.Phone,
-			"role":        u.Role,
-			"is_verified": u.IsVerified,
-			"created_at":  u.CreatedAt,
-			"updated_at":  u.UpdatedAt,
+			"id":                 u.ID,
+			"name":               u.Name,
+			"email":              u.Email,
+			"phone":              u.Phone,
+			"role":               u.Role,
+			"is_verified":        u.IsVerified,
+			"is_service_account": u.IsServiceAccount,
+			"created_at":         u.CreatedAt,
+			"updated_at":         u.UpdatedAt,
 		})
 	}
 	return c.JSON(fiber.Map{
@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"awesomeProject/auth"
+	"awesomeProject/models"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	maxAPITokensPerUser  = 20  // số token còn hiệu lực tối đa của một người dùng
+	maxAPITokenLifetime  = 365 // số ngày hiệu lực tối đa khi đặt hạn
+	serviceAccountDomain = "service-account.local"
+)
+
+type apiTokenInput struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"` // 0 = không hết hạn
+}
+
+func (in *apiTokenInput) validate() string {
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Name == "" || len([]rune(in.Name)) > 100 {
+		return "Tên token không hợp lệ"
+	}
+	if len(in.Scopes) == 0 {
+		return "Chưa chọn quyền cho token"
+	}
+	for _, s := range in.Scopes {
+		if !auth.IsValidAPIScope(s) {
+			return "Quyền không hợp lệ: " + s
+		}
+	}
+	if in.ExpiresInDays < 0 || in.ExpiresInDays > maxAPITokenLifetime {
+		return "Thời hạn token không hợp lệ"
+	}
+	return ""
+}
+
+// createAPIToken tạo token cho owner; token gốc chỉ trả về một lần tại đây.
+// msg là lỗi do dữ liệu người dùng, err là lỗi hệ thống.
+func createAPIToken(owner models.User, in apiTokenInput, createdBy uint) (models.APIToken, string, string, error) {
+	var active int64
+	models.DB.Model(&models.APIToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", owner.ID, time.Now()).
+		Count(&active)
+	if active >= maxAPITokensPerUser {
+		return models.APIToken{}, "", "Đã đạt số token tối đa, hãy thu hồi token không dùng", nil
+	}
+	raw, prefix, err := auth.GenerateAPIToken()
+	if err != nil {
+		return models.APIToken{}, "", "", err
+	}
+	token := models.APIToken{
+		UserID:    owner.ID,
+		Name:      in.Name,
+		Prefix:    prefix,
+		TokenHash: auth.HashAPIToken(raw),
+		Scopes:    in.Scopes,
+		CreatedBy: createdBy,
+	}
+	if in.ExpiresInDays > 0 {
+		expires := time.Now().AddDate(0, 0, in.ExpiresInDays)
+		token.ExpiresAt = &expires
+	}
+	if err := models.DB.Create(&token).Error; err != nil {
+		return models.APIToken{}, "", "", err
+	}
+	return token, raw, "", nil
+}
+
+// revokeAPIToken thu hồi token, token đã thu hồi giữ nguyên thời điểm thu hồi cũ
+func revokeAPIToken(token *models.APIToken) error {
+	if token.RevokedAt != nil {
+		return nil
+	}
+	now := time.Now()
+	token.RevokedAt = &now
+	return models.DB.Model(token).Update("revoked_at", now).Error
+}
+
+// ----------- TOKEN API CỦA NGƯỜI DÙNG -----------
+
+// GetMyAPITokens - Danh sách token API của tôi và các quyền có thể cấp
+func GetMyAPITokens(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	tokens := []models.APIToken{}
+	models.DB.Where("user_id = ?", user.ID).Order("id DESC").Find(&tokens)
+	return c.JSON(fiber.Map{"tokens": tokens, "scopes": auth.APIScopes})
+}
+
+// CreateMyAPIToken - Tạo token API; token chỉ hiển thị một lần trong phản hồi
+func CreateMyAPIToken(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	var input apiTokenInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Dữ liệu không hợp lệ"})
+	}
+	if msg := input.validate(); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"error": msg})
+	}
+	token, raw, msg, err := createAPIToken(user, input, user.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Không thể tạo token"})
+	}
+	if msg != "" {
+		return c.Status(400).JSON(fiber.Map{"error": msg})
+	}
+	return c.JSON(fiber.Map{"success": true, "token": raw, "item": token})
+}
+
+// RevokeMyAPIToken - Thu hồi token API của tôi
+func RevokeMyAPIToken(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	var token models.APIToken
+	if err := models.DB.Where("user_id = ?", user.ID).First(&token, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy token"})
+	}
+	if err := revokeAPIToken(&token); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Không thể thu hồi token"})
+	}
+	return c.JSON(fiber.Map{"success": true, "item": token})
+}
+
+// ----------- TÀI KHOẢN DỊCH VỤ (ADMIN) -----------
+
+func serviceAccountResponse(u models.User) fiber.Map {
+	return fiber.Map{
+		"id":         u.ID,
+		"name":       u.Name,
+		"email":      u.Email,
+		"role":       u.Role,
+		"created_at": u.CreatedAt,
+	}
+}
+
+// GetServiceAccounts - Danh sách tài khoản dịch vụ
+func GetServiceAccounts(c *fiber.Ctx) error {
+	var users []models.User
+	models.DB.Where("is_service_account = ?", true).Order("name").Find(&users)
+	data := make([]fiber.Map, 0, len(users))
+	for _, u := range users {
+		data = append(data, serviceAccountResponse(u))
+	}
+	return c.JSON(fiber.Map{"data": data, "scopes": auth.APIScopes})
+}
+
+// CreateServiceAccount - Tạo tài khoản dịch vụ cho tích hợp. Tài khoản không có mật khẩu,
+// không nhận thông báo và không được phân công ticket; chỉ gọi API bằng token.
+func CreateServiceAccount(c *fiber.Ctx) error {
+	var input struct {
+		Name string `json:"name"`
+		Role string `json:"role"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": "Dữ liệu không hợp lệ"})
+	}
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		return c.Status(400).JSON(fiber.Map{"message": "Tên không hợp lệ"})
+	}
+	if input.Role != "customer" && input.Role != "staff" && input.Role != "admin" {
+		return c.Status(400).JSON(fiber.Map{"message": "Role không hợp lệ"})
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể tạo", "error": err.Error()})
+	}
+	user := models.User{
+		Name:             input.Name,
+		Email:            "svc-" + hex.EncodeToString(b) + "@" + serviceAccountDomain,
+		PasswordHash:     "!", // không khớp giá trị băm nào nên không đăng nhập bằng mật khẩu được
+		Role:             input.Role,
+		IsVerified:       true,
+		IsServiceAccount: true,
+	}
+	if err := models.DB.Create(&user).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể tạo", "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "Tạo thành công", "item": serviceAccountResponse(user)})
+}
+
+// findServiceAccount tìm tài khoản dịch vụ theo id trong đường dẫn
+func findServiceAccount(c *fiber.Ctx) (models.User, bool) {
+	var user models.User
+	err := models.DB.Where("is_service_account = ?", true).First(&user, c.Params("id")).Error
+	return user, err == nil
+}
+
+// DeleteServiceAccount - Xóa tài khoản dịch vụ và thu hồi mọi token của tài khoản
+func DeleteServiceAccount(c *fiber.Ctx) error {
+	user, ok := findServiceAccount(c)
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy"})
+	}
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.APIToken{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&user).Error
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể xóa"})
+	}
+	return c.JSON(fiber.Map{"message": "Đã xóa"})
+}
+
+// GetServiceAccountTokens - Danh sách token của một tài khoản dịch vụ
+func GetServiceAccountTokens(c *fiber.Ctx) error {
+	user, ok := findServiceAccount(c)
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy"})
+	}
+	tokens := []models.APIToken{}
+	models.DB.Where("user_id = ?", user.ID).Order("id DESC").Find(&tokens)
+	return c.JSON(fiber.Map{"data": tokens})
+}
+
+// CreateServiceAccountToken - Tạo token cho tài khoản dịch vụ; token chỉ hiển thị một lần trong phản hồi
+func CreateServiceAccountToken(c *fiber.Ctx) error {
+	admin := c.Locals("user").(models.User)
+	user, ok := findServiceAccount(c)
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy"})
+	}
+	var input apiTokenInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": "Dữ liệu không hợp lệ"})
+	}
+	if msg := input.validate(); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"message": msg})
+	}
+	token, raw, msg, err := createAPIToken(user, input, admin.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể tạo token", "error": err.Error()})
+	}
+	if msg != "" {
+		return c.Status(400).JSON(fiber.Map{"message": msg})
+	}
+	return c.JSON(fiber.Map{"message": "Tạo thành công", "token": raw, "item": token})
+}
+
+// RevokeAPIToken - Admin thu hồi token API bất kỳ (của người dùng hoặc tài khoản dịch vụ)
+func RevokeAPIToken(c *fiber.Ctx) error {
+	var token models.APIToken
+	if err := models.DB.First(&token, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy"})
+	}
+	if err := revokeAPIToken(&token); err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể thu hồi token"})
+	}
+	return c.JSON(fiber.Map{"message": "Đã thu hồi token", "item": token})
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"awesomeProject/assignment"
+	"awesomeProject/models"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// notifyTicketAssignee tạo notification cho nhân viên vừa được phân công ticket
+func notifyTicketAssignee(ticket models.Ticket, staff models.User) {
+	notify(staff, notice{
+		Type:    models.NotifyTicketAssign,
+		Ticket:  ticket,
+		Content: fmt.Sprintf("Bạn được phân công ticket #%d: %s", ticket.ID, ticket.Title),
+		Data:    models.NotificationData{TicketID: ticket.ID, AssignedTo: staff.ID},
+	})
+}
+
+// UpdateStaffAvailability bật/tắt trạng thái sẵn sàng nhận ticket tự động (admin cho mọi người, staff cho chính mình)
+func UpdateStaffAvailability(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID nhân viên không hợp lệ"})
+	}
+	if user.Role != "admin" && uint(id) != user.ID {
+		return c.Status(403).JSON(fiber.Map{"error": "Bạn chỉ được cập nhật trạng thái của chính mình"})
+	}
+	var input struct {
+		IsAvailable *bool `json:"is_available"`
+	}
+	if err := c.BodyParser(&input); err != nil || input.IsAvailable == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Dữ liệu không hợp lệ"})
+	}
+	var staff models.User
+	if err := models.AssignableStaff(models.DB).First(&staff, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy nhân viên"})
+	}
+	if err := models.DB.Model(&staff).Update("is_available", *input.IsAvailable).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Không thể cập nhật trạng thái"})
+	}
+	return c.JSON(fiber.Map{"success": true, "id": staff.ID, "is_available": *input.IsAvailable})
+}
+
+type assignmentRuleInput struct {
+	Name          string `json:"name"`
+	CategoryID    *uint  `json:"category_id"`
+	ProductTypeID *uint  `json:"product_type_id"`
+	Strategy      string `json:"strategy"`
+	IsActive      *bool  `json:"is_active"`
+}
+
+func (in assignmentRuleInput) validate() string {
+	if in.Name == "" {
+		return "Tên không hợp lệ"
+	}
+	if !assignment.IsValidStrategy(in.Strategy) {
+		return "Chiến lược phân công phải là round_robin hoặc least_loaded"
+	}
+	if in.CategoryID != nil && *in.CategoryID > 0 {
+		if err := models.DB.First(&models.TicketCategory{}, *in.CategoryID).Error; err != nil {
+			return "Loại ticket không tồn tại"
+		}
+	}
+	if in.ProductTypeID != nil && *in.ProductTypeID > 0 {
+		if err := models.DB.First(&models.TicketProductType{}, *in.ProductTypeID).Error; err != nil {
+			return "Loại sản phẩm không tồn tại"
+		}
+	}
+	return ""
+}
+
+// ----------- ASSIGNMENT RULE -----------
+func GetAllAssignmentRules(c *fiber.Ctx) error {
+	var items []models.AssignmentRule
+	models.DB.Order("id").Find(&items)
+	return c.JSON(fiber.Map{"data": items})
+}
+
+func CreateAssignmentRule(c *fiber.Ctx) error {
+	var input assignmentRuleInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": "Dữ liệu không hợp lệ"})
+	}
+	if msg := input.validate(); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"message": msg})
+	}
+	item := models.AssignmentRule{
+		Name:          input.Name,
+		CategoryID:    normalizeOptionalID(input.CategoryID),
+		ProductTypeID: normalizeOptionalID(input.ProductTypeID),
+		Strategy:      input.Strategy,
+		IsActive:      input.IsActive == nil || *input.IsActive,
+	}
+	if err := models.DB.Create(&item).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể tạo", "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "Tạo thành công", "item": item})
+}
+
+func UpdateAssignmentRule(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var item models.AssignmentRule
+	if err := models.DB.First(&item, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy"})
+	}
+	var input assignmentRuleInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": "Dữ liệu không hợp lệ"})
+	}
+	if msg := input.validate(); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"message": msg})
+	}
+	item.Name = input.Name
+	item.CategoryID = normalizeOptionalID(input.CategoryID)
+	item.ProductTypeID = normalizeOptionalID(input.ProductTypeID)
+	item.Strategy = input.Strategy
+	if input.IsActive != nil {
+		item.IsActive = *input.IsActive
+	}
+	if err := models.DB.Save(&item).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể cập nhật", "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "Cập nhật thành công", "item": item})
+}
+
+func DeleteAssignmentRule(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if err := models.DB.Delete(&models.AssignmentRule{}, id).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể xóa"})
+	}
+	return c.JSON(fiber.Map{"message": "Đã xóa"})
+}
@@ -0,0 +1,27 @@
+package controllers
+
+import "testing"
+
+func TestCreateAssignmentRuleIsActive(t *testing.T) {
+	useDryRunDB(t)
+	tests := []struct {
+		name   string
+		active string
+		want   bool
+	}{
+		{"không gửi is_active thì mặc định bật", "", true},
+		{"bật", `,"is_active":true`, true},
+		{"tạo ở trạng thái tắt", `,"is_active":false`, false},
+	}
+	for _, tt := range tests {
+		body := `{"name":"Chia đều","strategy":"round_robin"` + tt.active + `}`
+		status, out := postJSON(t, CreateAssignmentRule, body)
+		if status != 200 {
+			t.Fatalf("%s: status = %d, body = %v", tt.name, status, out)
+		}
+		item, _ := out["item"].(map[string]any)
+		if got, _ := item["is_active"].(bool); got != tt.want {
+			t.Errorf("%s: is_active = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
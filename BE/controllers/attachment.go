@@ -0,0 +1,231 @@
+package controllers
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/storage"
+	"awesomeProject/upload"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"mime/multipart"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// uploadedFiles lấy file đính kèm từ form (nhiều file qua "attachments", vẫn nhận trường cũ "attachment")
+// và kiểm tra từng file theo chính sách upload của ngữ cảnh
+func uploadedFiles(c *fiber.Ctx, context string) ([]*upload.File, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, nil
+	}
+	headers := append([]*multipart.FileHeader{}, form.File["attachments"]...)
+	headers = append(headers, form.File["attachment"]...)
+	policy := upload.PolicyFor(context)
+	if err := upload.CheckCount(len(headers), policy); err != nil {
+		return nil, err
+	}
+	files := make([]*upload.File, 0, len(headers))
+	for _, fh := range headers {
+		f, err := upload.Validate(fh, policy)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// respondUploadError trả lỗi kiểm tra file cho người dùng
+func respondUploadError(c *fiber.Ctx, err error) error {
+	var ue *upload.Error
+	if errors.As(err, &ue) {
+		return c.Status(400).JSON(fiber.Map{"error": ue.Message, "code": ue.Code})
+	}
+	return c.Status(400).JSON(fiber.Map{"error": "File đính kèm không hợp lệ"})
+}
+
+// storedFileName đặt tên lưu trữ duy nhất, chỉ gồm ký tự ASCII an toàn
+func storedFileName(prefix string, index int, f *upload.File) string {
+	return prefix + "_" + strconv.FormatInt(time.Now().UnixNano(), 10) + "_" + strconv.Itoa(index) + "_" + f.SafeName + f.Ext
+}
+
+// storeUploads lưu các file đã kiểm tra vào backend lưu trữ dưới thư mục dir và trả về bản ghi Attachment
+// (chưa có chủ sở hữu, chưa lưu DB). Nếu một file lỗi thì các file đã lưu trước đó bị xóa.
+func storeUploads(files []*upload.File, dir, prefix string) ([]models.Attachment, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	items := make([]models.Attachment, 0, len(files))
+	for i, f := range files {
+		storedName := storedFileName(prefix, i, f)
+		key := path.Join(dir, storedName)
+		size, checksum, err := putUploadedFile(f, key)
+		if err != nil {
+			removeAttachmentFiles(items)
+			return nil, err
+		}
+		items = append(items, models.Attachment{
+			OriginalName: f.DisplayName,
+			StoredName:   storedName,
+			Path:         key,
+			Size:         size,
+			MimeType:     f.MimeType,
+			Checksum:     checksum,
+		})
+	}
+	return items, nil
+}
+
+// putUploadedFile ghi file đã kiểm tra vào backend lưu trữ đồng thời tính sha256
+func putUploadedFile(f *upload.File, key string) (int64, string, error) {
+	src, err := f.Open()
+	if err != nil {
+		return 0, "", err
+	}
+	defer src.Close()
+	h := sha256.New()
+	if err := storage.Default().Put(key, io.TeeReader(src, h), f.Size, f.MimeType); err != nil {
+		return 0, "", err
+	}
+	return f.Size, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// createAttachments gắn các file đã lưu vào ticket/bình luận và ghi vào DB
+func createAttachments(items []models.Attachment, ownerType string, ownerID, ticketID, uploaderID uint) ([]models.Attachment, error) {
+	if len(items) == 0 {
+		return items, nil
+	}
+	for i := range items {
+		items[i].OwnerType = ownerType
+		items[i].OwnerID = ownerID
+		items[i].TicketID = ticketID
+		items[i].UploadedBy = &uploaderID
+	}
+	if err := models.DB.Create(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func removeAttachmentFiles(items []models.Attachment) {
+	for _, a := range items {
+		if err := storage.Default().Delete(a.Path); err != nil {
+			log.Printf("[STORAGE] Không xóa được %s: %v", a.Path, err)
+		}
+		if a.ThumbnailPath != "" {
+			storage.Default().Delete(a.ThumbnailPath)
+		}
+	}
+}
+
+// deleteTicketAttachments xóa toàn bộ file đính kèm của ticket và các bình luận của nó
+func deleteTicketAttachments(ticketID uint) {
+	var items []models.Attachment
+	models.DB.Where("ticket_id = ?", ticketID).Find(&items)
+	removeAttachmentFiles(items)
+	models.DB.Where("ticket_id = ?", ticketID).Delete(&models.Attachment{})
+}
+
+func attachmentResponse(a models.Attachment) fiber.Map {
+	return fiber.Map{
+		"id":            a.ID,
+		"owner_type":    a.OwnerType,
+		"owner_id":      a.OwnerID,
+		"original_name": a.OriginalName,
+		"size":          a.Size,
+		"mime_type":     a.MimeType,
+		"checksum":      a.Checksum,
+		"scan_status":   a.ScanStatus,
+		"url":           attachmentDownloadURL(a),
+		"thumbnail_url": thumbnailURL(a),
+		"uploaded_by":   a.UploadedBy,
+		"created_at":    a.CreatedAt,
+	}
+}
+
+func attachmentsResponse(items []models.Attachment) []fiber.Map {
+	result := make([]fiber.Map, 0, len(items))
+	for _, a := range items {
+		result = append(result, attachmentResponse(a))
+	}
+	return result
+}
+
+// commentAttachments lấy file đính kèm của nhiều bình luận, nhóm theo ID bình luận
+func commentAttachments(commentIDs []uint, role string) map[uint][]models.Attachment {
+	grouped := make(map[uint][]models.Attachment)
+	if len(commentIDs) == 0 {
+		return grouped
+	}
+	var items []models.Attachment
+	models.DB.Scopes(models.AttachmentsVisibleTo(role)).Where("owner_type = ? AND owner_id IN ?", models.AttachmentOwnerComment, commentIDs).Order("id").Find(&items)
+	for _, a := range items {
+		grouped[a.OwnerID] = append(grouped[a.OwnerID], a)
+	}
+	return grouped
+}
+
+// GetTicketAttachments - Danh sách file đính kèm của ticket và các bình luận người dùng được xem
+func GetTicketAttachments(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	var ticket models.Ticket
+	if err := models.DB.First(&ticket, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy ticket"})
+	}
+	if !canViewTicket(user, ticket) {
+		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy ticket"})
+	}
+	var items []models.Attachment
+	err := models.DB.Scopes(models.AttachmentsVisibleTo(user.Role)).Where("ticket_id = ?", ticket.ID).
+		Where("(owner_type = ? OR (owner_type = ? AND owner_id IN (?)))", models.AttachmentOwnerTicket, models.AttachmentOwnerComment,
+			models.DB.Model(&models.TicketComment{}).Select("id").Scopes(models.VisibleComments(user.Role)).Where("ticket_id = ?", ticket.ID)).
+		Order("id").Find(&items).Error
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Không lấy được danh sách file đính kèm"})
+	}
+	return c.JSON(fiber.Map{"attachments": attachmentsResponse(items)})
+}
+
+// DeleteAttachment - Xóa file đính kèm (admin hoặc chính người tải lên)
+func DeleteAttachment(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	var a models.Attachment
+	if err := models.DB.First(&a, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy file đính kèm"})
+	}
+	var ticket models.Ticket
+	if err := models.DB.First(&ticket, a.TicketID).Error; err != nil || !canViewTicket(user, ticket) {
+		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy file đính kèm"})
+	}
+	if user.Role != "admin" && (a.UploadedBy == nil || *a.UploadedBy != user.ID) {
+		return c.Status(403).JSON(fiber.Map{"error": "Bạn không có quyền xóa file đính kèm này"})
+	}
+	if err := models.DB.Delete(&a).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Không thể xóa file đính kèm"})
+	}
+	removeAttachmentFiles([]models.Attachment{a})
+	syncLegacyAttachmentPath(a.OwnerType, a.OwnerID)
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// syncLegacyAttachmentPath cập nhật attachment_path cũ của ticket/bình luận theo file đính kèm đầu tiên còn lại
+func syncLegacyAttachmentPath(ownerType string, ownerID uint) {
+	var first models.Attachment
+	models.DB.Scopes(models.AttachmentsOf(ownerType, ownerID)).Order("id").Limit(1).Find(&first)
+	switch ownerType {
+	case models.AttachmentOwnerTicket:
+		path := ""
+		if first.ID != 0 {
+			path = first.URL()
+		}
+		models.DB.Model(&models.Ticket{}).Where("id = ?", ownerID).Update("attachment_path", path)
+	case models.AttachmentOwnerComment:
+		models.DB.Model(&models.TicketComment{}).Where("id = ?", ownerID).Update("attachment_path", first.Path)
+	}
+}
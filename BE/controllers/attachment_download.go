@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"awesomeProject/auth"
+	"awesomeProject/models"
+	"awesomeProject/storage"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Thời hạn mặc định/tối đa của URL tải file đã ký
+const (
+	defaultSignedURLTTL = 15 * time.Minute
+	maxSignedURLTTL     = 24 * time.Hour
+)
+
+// canViewAttachment: người xem phải xem được ticket, khách hàng không tải được file của ghi chú nội bộ
+func canViewAttachment(user models.User, a models.Attachment) bool {
+	var ticket models.Ticket
+	if err := models.DB.First(&ticket, a.TicketID).Error; err != nil || !canViewTicket(user, ticket) {
+		return false
+	}
+	if a.OwnerType == models.AttachmentOwnerComment {
+		var count int64
+		models.DB.Model(&models.TicketComment{}).Scopes(models.VisibleComments(user.Role)).Where("id = ?", a.OwnerID).Count(&count)
+		return count > 0
+	}
+	return true
+}
+
+// serveFile trả nội dung file từ backend lưu trữ với tên gốc; ảnh/PDF hiển thị trực tiếp, loại khác tải về.
+// Nếu bật STORAGE_PRESIGNED_DOWNLOADS và backend hỗ trợ thì chuyển hướng sang URL tải trực tiếp có hạn dùng.
+func serveFile(c *fiber.Ctx, key, name, mimeType string) error {
+	store := storage.Default()
+	if os.Getenv("STORAGE_PRESIGNED_DOWNLOADS") == "true" {
+		if link, err := store.Presign(key, defaultSignedURLTTL); err == nil {
+			return c.Redirect(link, fiber.StatusFound)
+		}
+	}
+	rc, info, err := store.Get(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy file"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Không đọc được file"})
+	}
+	if mimeType == "" {
+		mimeType = info.ContentType
+	}
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	disposition := "attachment"
+	if strings.HasPrefix(mimeType, "image/") || mimeType == "application/pdf" {
+		disposition = "inline"
+	}
+	c.Set(fiber.HeaderContentType, mimeType)
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType(disposition, map[string]string{"filename": name}))
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return c.SendStream(rc, int(info.Size))
+}
+
+func serveAttachment(c *fiber.Ctx, a models.Attachment) error {
+	if !a.IsAvailable() {
+		return c.Status(403).JSON(fiber.Map{"error": "File đang được kiểm tra virus hoặc đã bị cách ly", "scan_status": a.ScanStatus})
+	}
+	return serveFile(c, a.Path, a.OriginalName, a.MimeType)
+}
+
+// attachmentDownloadURL là URL tải file qua API có xác thực
+func attachmentDownloadURL(a models.Attachment) string {
+	return fmt.Sprintf("/user/attachments/%d/download", a.ID)
+}
+
+// signedAttachmentURL tạo URL tải file không cần đăng nhập, hết hạn sau ttl (dùng cho email).
+// Nếu có APP_BASE_URL thì trả về URL tuyệt đối.
+func signedAttachmentURL(a models.Attachment, ttl time.Duration) (string, time.Time, error) {
+	expires := time.Now().Add(ttl)
+	sig, err := auth.SignResource(attachmentResource(a.ID), expires)
+	if err != nil {
+		return "", expires, err
+	}
+	link := fmt.Sprintf("%s/files/attachments/%d?expires=%d&signature=%s",
+		strings.TrimRight(os.Getenv("APP_BASE_URL"), "/"), a.ID, expires.Unix(), sig)
+	return link, expires, nil
+}
+
+// signedURLTTL là thời hạn URL đã ký theo số phút người dùng yêu cầu, mặc định 15 phút, tối đa 24 giờ
+func signedURLTTL(minutes int) time.Duration {
+	ttl := defaultSignedURLTTL
+	if minutes > 0 {
+		ttl = time.Duration(minutes) * time.Minute
+	}
+	if ttl > maxSignedURLTTL {
+		ttl = maxSignedURLTTL
+	}
+	return ttl
+}
+
+func attachmentResource(id uint) string {
+	return "attachment:" + strconv.FormatUint(uint64(id), 10)
+}
+
+// DownloadAttachment - Tải file đính kèm theo quyền xem ticket
+func DownloadAttachment(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	var a models.Attachment
+	if err := models.DB.First(&a, c.Params("id")).Error; err != nil || !canViewAttachment(user, a) {
+		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy file đính kèm"})
+	}
+	return serveAttachment(c, a)
+}
+
+// GetAttachmentSignedURL - Tạo URL tải file có chữ ký, hết hạn sau expires_in phút (mặc định 15, tối đa 24 giờ)
+func GetAttachmentSignedURL(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	var a models.Attachment
+	if err := models.DB.First(&a, c.Params("id")).Error; err != nil || !canViewAttachment(user, a) {
+		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy file đính kèm"})
+	}
+	link, expires, err := signedAttachmentURL(a, signedURLTTL(c.QueryInt("expires_in")))
+	if err != nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Chưa cấu hình khóa ký liên kết tải file"})
+	}
+	return c.JSON(fiber.Map{"url": link, "expires_at": expires})
+}
+
+// DownloadSignedAttachment - Tải file qua URL đã ký, không cần đăng nhập
+func DownloadSignedAttachment(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy file đính kèm"})
+	}
+	expires, _ := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err := auth.VerifyResource(attachmentResource(uint(id)), expires, c.Query("signature")); err != nil {
+		return c.Status(403).JSON(fiber.Map{"error": "Liên kết tải file không hợp lệ hoặc đã hết hạn"})
+	}
+	var a models.Attachment
+	if err := models.DB.First(&a, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy file đính kèm"})
+	}
+	return serveAttachment(c, a)
+}
+
+// DownloadUploadedFile - Giữ tương thích đường dẫn cũ /uploads/... nhưng bắt buộc đăng nhập và kiểm tra quyền
+func DownloadUploadedFile(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	rel, err := url.PathUnescape(c.Params("*"))
+	if err != nil || rel == "" {
+		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy file"})
+	}
+	filePath := path.Join("uploads", path.Clean("/"+rel))
+
+	if strings.HasPrefix(filePath, "uploads/knowledge/") {
+		var doc models.KnowledgeBase
+		if err := models.DB.Where("file_path = ?", "/"+filePath).First(&doc).Error; err != nil {
+			return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy file"})
+		}
+		if !doc.IsPublished && user.Role != "admin" && user.Role != "staff" {
+			return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy file"})
+		}
+		return serveFile(c, filePath, path.Base(filePath), mime.TypeByExtension(path.Ext(filePath)))
+	}
+
+	var a models.Attachment
+	if err := models.DB.Where("path = ?", filePath).First(&a).Error; err != nil || !canViewAttachment(user, a) {
+		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy file"})
+	}
+	return serveAttachment(c, a)
+}
@@ -0,0 +1,24 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignedURLTTL(t *testing.T) {
+	tests := []struct {
+		minutes int
+		want    time.Duration
+	}{
+		{0, 15 * time.Minute},
+		{-5, 15 * time.Minute},
+		{60, time.Hour},
+		{24 * 60, 24 * time.Hour},
+		{7 * 24 * 60, 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := signedURLTTL(tt.minutes); got != tt.want {
+			t.Errorf("signedURLTTL(%d) = %v, want %v", tt.minutes, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/scanner"
+	"awesomeProject/storage"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Số file tối đa quét lại trong một lượt chạy nền
+const rescanBatchSize = 100
+
+// scanAttachment quét virus một file đã lưu và cập nhật trạng thái.
+// Quét lỗi (clamd không phản hồi...) thì giữ trạng thái pending để job nền quét lại.
+func scanAttachment(a *models.Attachment) {
+	now := time.Now()
+	s := scanner.Default()
+	if s == nil {
+		a.ScanStatus = models.ScanSkipped
+		a.ScannedAt = &now
+		models.DB.Model(a).Select("scan_status", "scanned_at").Updates(a)
+		return
+	}
+	rc, _, err := storage.Default().Get(a.Path)
+	if errors.Is(err, storage.ErrNotFound) {
+		// File không còn trong kho lưu trữ thì không có gì để quét, tránh job nền thử lại mãi
+		log.Printf("[SCANNER] File #%d (%s) không tồn tại, bỏ qua", a.ID, a.Path)
+		a.ScanStatus = models.ScanSkipped
+		a.ScannedAt = &now
+		models.DB.Model(a).Select("scan_status", "scanned_at").Updates(a)
+		return
+	}
+	if err != nil {
+		log.Printf("[SCANNER] Không đọc được file #%d (%s): %v", a.ID, a.Path, err)
+		return
+	}
+	result, err := s.Scan(rc)
+	rc.Close()
+	if !applyScanResult(a, result, err, now) {
+		log.Printf("[SCANNER] Quét file #%d lỗi: %v", a.ID, err)
+		return
+	}
+	models.DB.Model(a).Select("scan_status", "scan_signature", "scanned_at").Updates(a)
+}
+
+// applyScanResult ghi kết quả quét vào file đính kèm; quét lỗi thì giữ nguyên trạng thái (pending) và trả về false
+func applyScanResult(a *models.Attachment, result scanner.Result, err error, now time.Time) bool {
+	if err != nil {
+		return false
+	}
+	a.ScannedAt = &now
+	if result.Infected {
+		a.ScanStatus = models.ScanQuarantined
+		a.ScanSignature = result.Signature
+	} else {
+		a.ScanStatus = models.ScanClean
+	}
+	return true
+}
+
+// scanAttachments đưa các file vừa tải lên vào quét virus và tạo ảnh thu nhỏ chạy nền, không chặn request.
+// File giữ trạng thái pending (chưa tải/xem được) đến khi quét xong; quét lỗi thì job nền quét lại.
+func scanAttachments(items []models.Attachment, ticket models.Ticket) {
+	items = append([]models.Attachment(nil), items...)
+	go func() {
+		for i := range items {
+			processAttachment(&items[i], &ticket)
+		}
+	}()
+}
+
+// Số file quét đồng thời tối đa, tránh dồn quá nhiều kết nối INSTREAM tới clamd khi nhiều người cùng tải lên
+const maxConcurrentScans = 4
+
+var (
+	scanSlots = make(chan struct{}, maxConcurrentScans)
+	scanning  sync.Map // ID các file đang được xử lý, để request tải lên và job nền không quét trùng
+)
+
+// processAttachment quét virus một file, báo admin khi phát hiện virus và tạo ảnh thu nhỏ cho file sạch.
+// ticket nil thì tự lấy khi cần. File đang được xử lý ở luồng khác hoặc đã quét xong thì bỏ qua.
+func processAttachment(a *models.Attachment, ticket *models.Ticket) {
+	if _, busy := scanning.LoadOrStore(a.ID, true); busy {
+		return
+	}
+	defer scanning.Delete(a.ID)
+	scanSlots <- struct{}{}
+	defer func() { <-scanSlots }()
+	defer func() {
+		// Nội dung file do người dùng tải lên, lỗi khi giải mã không được làm dừng server
+		if r := recover(); r != nil {
+			log.Printf("[SCANNER] Xử lý file #%d lỗi: %v", a.ID, r)
+		}
+	}()
+
+	// Job nền có thể đã đọc file trước khi luồng khác quét xong
+	if err := models.DB.First(a, a.ID).Error; err != nil || a.ScanStatus != models.ScanPending {
+		return
+	}
+	scanAttachment(a)
+	if a.ScanStatus == models.ScanQuarantined {
+		if ticket == nil {
+			ticket = &models.Ticket{}
+			if err := models.DB.First(ticket, a.TicketID).Error; err != nil {
+				return
+			}
+		}
+		notifyAttachmentQuarantined(*a, *ticket)
+	}
+	generateThumbnail(a)
+}
+
+// notifyAttachmentQuarantined tạo notification cho tất cả admin khi một file bị cách ly
+func notifyAttachmentQuarantined(a models.Attachment, ticket models.Ticket) {
+	notifyUsers(adminUsers(), 0, notice{
+		Type:    models.NotifyAttachmentQuarantined,
+		Ticket:  ticket,
+		Content: fmt.Sprintf("Phát hiện virus %s trong file %s của ticket #%d, file đã bị cách ly", a.ScanSignature, a.OriginalName, ticket.ID),
+		Data:    models.NotificationData{TicketID: ticket.ID, AttachmentID: a.ID},
+	})
+}
+
+// RescanPendingAttachments quét lại các file chưa quét được (clamd lỗi, dữ liệu cũ chuyển sang) - chạy nền
+func RescanPendingAttachments() {
+	var items []models.Attachment
+	models.DB.Where("scan_status = ?", models.ScanPending).Order("id").Limit(rescanBatchSize).Find(&items)
+	for i := range items {
+		processAttachment(&items[i], nil)
+	}
+}
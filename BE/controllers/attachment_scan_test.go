@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/scanner"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestApplyScanResult(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name          string
+		result        scanner.Result
+		err           error
+		wantApplied   bool
+		wantStatus    string
+		wantSignature string
+		wantAvailable bool
+	}{
+		{name: "clean", wantApplied: true, wantStatus: models.ScanClean, wantAvailable: true},
+		{name: "infected", result: scanner.Result{Infected: true, Signature: "Eicar-Test-Signature"}, wantApplied: true, wantStatus: models.ScanQuarantined, wantSignature: "Eicar-Test-Signature"},
+		{name: "clamd error", err: errors.New("scanner: clamd error: INSTREAM size limit exceeded. ERROR"), wantStatus: models.ScanPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := models.Attachment{ScanStatus: models.ScanPending}
+			if got := applyScanResult(&a, tt.result, tt.err, now); got != tt.wantApplied {
+				t.Errorf("applyScanResult = %v, want %v", got, tt.wantApplied)
+			}
+			if a.ScanStatus != tt.wantStatus || a.ScanSignature != tt.wantSignature {
+				t.Errorf("status = %q signature = %q, want %q %q", a.ScanStatus, a.ScanSignature, tt.wantStatus, tt.wantSignature)
+			}
+			if a.IsAvailable() != tt.wantAvailable {
+				t.Errorf("IsAvailable = %v, want %v", a.IsAvailable(), tt.wantAvailable)
+			}
+			if tt.wantApplied != (a.ScannedAt != nil) {
+				t.Errorf("ScannedAt = %v", a.ScannedAt)
+			}
+		})
+	}
+}
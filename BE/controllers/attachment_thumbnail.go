@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/storage"
+	"awesomeProject/thumbnail"
+	"bytes"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Thư mục lưu ảnh thu nhỏ
+const thumbnailDir = "uploads/thumbnails"
+
+// generateThumbnail tạo ảnh thu nhỏ cho file ảnh/PDF đã qua kiểm tra virus và lưu đường dẫn vào attachment
+func generateThumbnail(a *models.Attachment) {
+	if !a.IsAvailable() || a.ThumbnailPath != "" || !thumbnail.Supported(a.MimeType) {
+		return
+	}
+	store := storage.Default()
+	rc, _, err := store.Get(a.Path)
+	if err != nil {
+		return
+	}
+	data, err := thumbnail.Generate(rc, a.MimeType)
+	rc.Close()
+	if err != nil {
+		if err != thumbnail.ErrNoPreview {
+			log.Printf("[THUMBNAIL] Không tạo được ảnh thu nhỏ cho file #%d: %v", a.ID, err)
+		}
+		return
+	}
+	key := path.Join(thumbnailDir, strings.TrimSuffix(a.StoredName, path.Ext(a.StoredName))+".jpg")
+	if err := store.Put(key, bytes.NewReader(data), int64(len(data)), "image/jpeg"); err != nil {
+		log.Printf("[THUMBNAIL] Không lưu được ảnh thu nhỏ cho file #%d: %v", a.ID, err)
+		return
+	}
+	a.ThumbnailPath = key
+	models.DB.Model(a).Update("thumbnail_path", key)
+}
+
+// thumbnailURL là URL ảnh thu nhỏ qua API có xác thực, rỗng nếu không có
+func thumbnailURL(a models.Attachment) string {
+	if a.ThumbnailPath == "" {
+		return ""
+	}
+	return fmt.Sprintf("/user/attachments/%d/thumbnail", a.ID)
+}
+
+// firstThumbnailURLs trả về ảnh thu nhỏ của file đính kèm đầu tiên (có ảnh thu nhỏ) theo từng ticket/bình luận
+func firstThumbnailURLs(ownerType string, ownerIDs []uint, role string) map[uint]string {
+	urls := make(map[uint]string)
+	if len(ownerIDs) == 0 {
+		return urls
+	}
+	var items []models.Attachment
+	models.DB.Scopes(models.AttachmentsVisibleTo(role)).
+		Where("owner_type = ? AND owner_id IN ? AND thumbnail_path <> ''", ownerType, ownerIDs).
+		Order("id").Find(&items)
+	for _, a := range items {
+		if _, ok := urls[a.OwnerID]; !ok {
+			urls[a.OwnerID] = thumbnailURL(a)
+		}
+	}
+	return urls
+}
+
+// GetAttachmentThumbnail - Ảnh thu nhỏ của file đính kèm, cùng quyền với tải file gốc
+func GetAttachmentThumbnail(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	var a models.Attachment
+	if err := models.DB.First(&a, c.Params("id")).Error; err != nil || !canViewAttachment(user, a) || a.ThumbnailPath == "" {
+		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy ảnh thu nhỏ"})
+	}
+	if !a.IsAvailable() {
+		return c.Status(403).JSON(fiber.Map{"error": "File đang được kiểm tra virus hoặc đã bị cách ly", "scan_status": a.ScanStatus})
+	}
+	return serveFile(c, a.ThumbnailPath, "thumbnail.jpg", "image/jpeg")
+}
+
+// ticketThumbnailURLs lấy ảnh thu nhỏ đại diện của từng ticket trong danh sách
+func ticketThumbnailURLs(tickets []models.Ticket, role string) map[uint]string {
+	ids := make([]uint, 0, len(tickets))
+	for _, t := range tickets {
+		ids = append(ids, t.ID)
+	}
+	return firstThumbnailURLs(models.AttachmentOwnerTicket, ids, role)
+}
+
+// firstThumbnailURL là ảnh thu nhỏ của file đầu tiên có ảnh thu nhỏ trong danh sách
+func firstThumbnailURL(items []models.Attachment) string {
+	for _, a := range items {
+		if a.ThumbnailPath != "" {
+			return thumbnailURL(a)
+		}
+	}
+	return ""
+}
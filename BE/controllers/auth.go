@@ -2,16 +2,16 @@ package controllers
 
 import (
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"math/rand"
-	"os"
 	"regexp"
 	"strings"
 	"unicode"
 
-	"gopkg.in/gomail.v2"
-
 	"awesomeProject/auth"
+	"awesomeProject/emailtemplate"
+	"awesomeProject/mailer"
 	"awesomeProject/models"
 
 	"github.com/gofiber/fiber/v2"
@@ -165,8 +165,8 @@ func getErrorMessage(errCode string, lang string) string {
 			"vi": "Mật khẩu phải chứa ít nhất một ký tự đặc biệt (!@#$%^&*()_+-=[]{}|;':\",./<>?)",
 		},
 		"SMTP_CONFIG_ERROR": {
-			"en": "Missing SMTP configuration (SMTP_HOST, SMTP_USER, SMTP_PASS)",
-			"vi": "Thiếu cấu hình SMTP (SMTP_HOST, SMTP_USER, SMTP_PASS)",
+			"en": "Missing SMTP configuration (SMTP_HOST, SMTP_FROM)",
+			"vi": "Thiếu cấu hình SMTP (SMTP_HOST, SMTP_FROM)",
 		},
 		"EMAIL_SEND_ERROR": {
 			"en": "Cannot send verification email. Please try again later!",
@@ -230,87 +230,40 @@ func getErrorMessage(errCode string, lang string) string {
 	return errCode
 }
 
-// Gửi email xác thực thực tế bằng gomail
-func sendVerificationEmail(to, token string) error {
-	smtpHost := os.Getenv("SMTP_HOST")
-	smtpPort := os.Getenv("SMTP_PORT")
-	smtpUser := os.Getenv("SMTP_USER")
-	smtpPass := os.Getenv("SMTP_PASS")
-	from := os.Getenv("SMTP_FROM")
-	if from == "" {
-		from = smtpUser
-	}
-
-	if smtpHost == "" || smtpUser == "" || smtpPass == "" {
-		return fmt.Errorf("SMTP_CONFIG_ERROR")
-	}
-
-	port := 587
-	if smtpPort != "" {
-		fmt.Sscanf(smtpPort, "%d", &port)
+// Gửi email xác thực (qua hàng đợi email)
+func sendVerificationEmail(to, lang, token string) error {
+	if err := mailer.Configured(); err != nil {
+		return err
 	}
-
-	m := gomail.NewMessage()
-	m.SetHeader("From", from)
-	m.SetHeader("To", to)
-	m.SetHeader("Subject", "Xác thực tài khoản Support System")
-	m.SetBody("text/html", fmt.Sprintf("<p>Chào bạn,</p><p>Mã xác thực tài khoản của bạn là: <b>%s</b></p><p>Vui lòng nhập mã này để hoàn tất đăng ký.</p>", token))
-
-	d := gomail.NewDialer(smtpHost, port, smtpUser, smtpPass)
-
-	err := d.DialAndSend(m)
+	msg, err := renderEmail(emailtemplate.Verification, lang, map[string]interface{}{"Code": token})
 	if err != nil {
 		return err
 	}
-
-	return nil
+	return queueEmail(outgoingEmail{
+		Kind:    models.EmailKindVerification,
+		To:      to,
+		Subject: msg.Subject,
+		Body:    msg.HTML,
+	})
 }
 
-// Gửi email reset password
-func sendResetPasswordEmail(to, token string) error {
-	smtpHost := os.Getenv("SMTP_HOST")
-	smtpUser := os.Getenv("SMTP_USER")
-	smtpPass := os.Getenv("SMTP_PASS")
-	from := os.Getenv("SMTP_FROM")
-	if from == "" {
-		from = smtpUser
-	}
-
-	if smtpHost == "" || smtpUser == "" || smtpPass == "" {
-		return fmt.Errorf("SMTP_CONFIG_ERROR")
-	}
-
-	m := gomail.NewMessage()
-	m.SetHeader("From", from)
-	m.SetHeader("To", to)
-	m.SetHeader("Subject", "[Support System] Reset Password")
-	m.SetBody("text/html", fmt.Sprintf(`
-		<html>
-		<body>
-			<h2>Reset Password</h2>
-			<p>You have requested to reset your password.</p>
-			<p>Your reset code is: <strong>%s</strong></p>
-			<p>Please use this code to reset your password.</p>
-			<p>If you didn't request this, please ignore this email.</p>
-			<br>
-			<p>Best regards,</p>
-			<p>Support System Team</p>
-		</body>
-		</html>
-	`, token))
-
-	d := gomail.NewDialer(smtpHost, 587, smtpUser, smtpPass)
-	d.SSL = false
-
-	err := d.DialAndSend(m)
+// Gửi email reset password (qua hàng đợi email)
+func sendResetPasswordEmail(to, lang, token string) error {
+	if err := mailer.Configured(); err != nil {
+		return err
+	}
+	msg, err := renderEmail(emailtemplate.ResetPassword, lang, map[string]interface{}{"Code": token})
 	if err != nil {
 		return err
 	}
-
-	return nil
+	return queueEmail(outgoingEmail{
+		Kind:    models.EmailKindResetPassword,
+		To:      to,
+		Subject: msg.Subject,
+		Body:    msg.HTML,
+	})
 }
 
-// Register handles user registration.
 func Register(c *fiber.Ctx) error {
 	type RegisterInput struct {
 		Name     string `form:"name"`
@@ -372,6 +325,7 @@ func Register(c *fiber.Ctx) error {
 		Role:         "customer",
 		IsVerified:   false,
 		VerifyToken:  verifyToken,
+		Language:     emailtemplate.NormalizeLanguage(lang),
 	}
 
 	if err := models.DB.Create(&user).Error; err != nil {
@@ -382,9 +336,9 @@ func Register(c *fiber.Ctx) error {
 	}
 
 	// Gửi email xác thực
-	if err := sendVerificationEmail(user.Email, verifyToken); err != nil {
+	if err := sendVerificationEmail(user.Email, user.Language, verifyToken); err != nil {
 		msg := getErrorMessage("EMAIL_SEND_ERROR", lang)
-		if strings.Contains(err.Error(), "SMTP_CONFIG_ERROR") {
+		if errors.Is(err, mailer.ErrNotConfigured) {
 			msg = getErrorMessage("SMTP_CONFIG_ERROR", lang)
 		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -422,7 +376,8 @@ func Login(c *fiber.Ctx) error {
 	}
 
 	var user models.User
-	if err := models.DB.Where("email = ?", strings.ToLower(input.Email)).First(&user).Error; err != nil {
+	// Tài khoản dịch vụ chỉ dùng token API, không đăng nhập bằng mật khẩu
+	if err := models.DB.Where("email = ? AND is_service_account = ?", strings.ToLower(input.Email), false).First(&user).Error; err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{ // 401 Unauthorized
 			"message": getErrorMessage("INVALID_LOGIN_CREDENTIALS", lang),
 			"success": false,
@@ -736,9 +691,9 @@ func ResendVerificationEmail(c *fiber.Ctx) error {
 	}
 
 	// Gửi email xác thực
-	if err := sendVerificationEmail(user.Email, verifyToken); err != nil {
+	if err := sendVerificationEmail(user.Email, user.Language, verifyToken); err != nil {
 		msg := "Không thể gửi email xác thực. Vui lòng thử lại sau!"
-		if strings.Contains(err.Error(), "SMTP") || strings.Contains(err.Error(), "cấu hình") {
+		if errors.Is(err, mailer.ErrNotConfigured) {
 			msg = "Lỗi cấu hình SMTP: " + err.Error()
 		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -793,12 +748,14 @@ func ForgotPassword(c *fiber.Ctx) error {
 	}
 
 	// Gửi email reset password
-	go func() {
-		err := sendResetPasswordEmail(user.Email, resetToken)
-		if err != nil {
-			fmt.Printf("[MAIL ERROR] To: %s | Subject: Reset Password | Error: %v\n", user.Email, err)
-		}
-	}()
+	// Email theo ngôn ngữ đang dùng trên giao diện, không có thì theo ngôn ngữ của tài khoản
+	emailLang := user.Language
+	if input.Language != "" {
+		emailLang = input.Language
+	}
+	if err := sendResetPasswordEmail(user.Email, emailLang, resetToken); err != nil {
+		fmt.Printf("[MAIL ERROR] To: %s | Subject: Reset Password | Error: %v\n", user.Email, err)
+	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": getErrorMessage("RESET_PASSWORD_EMAIL_SENT", lang),
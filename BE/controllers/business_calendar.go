@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"awesomeProject/calendar"
+	"awesomeProject/models"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// GetBusinessCalendar trả về lịch làm việc đang áp dụng (giờ làm trong tuần, múi giờ, ngày nghỉ)
+func GetBusinessCalendar(c *fiber.Ctx) error {
+	var cal models.BusinessCalendar
+	err := models.DB.
+		Preload("Hours", func(db *gorm.DB) *gorm.DB { return db.Order("weekday, start_time") }).
+		Preload("Holidays", func(db *gorm.DB) *gorm.DB { return db.Order("date") }).
+		First(&cal, models.DefaultBusinessCalendarID).Error
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy lịch làm việc"})
+	}
+	return c.JSON(fiber.Map{"data": cal})
+}
+
+// UpdateBusinessCalendar cập nhật múi giờ và thay toàn bộ khung giờ làm việc trong tuần
+func UpdateBusinessCalendar(c *fiber.Ctx) error {
+	var input struct {
+		Name     string `json:"name"`
+		Timezone string `json:"timezone"`
+		Hours    []struct {
+			Weekday   int    `json:"weekday"`
+			StartTime string `json:"start_time"`
+			EndTime   string `json:"end_time"`
+		} `json:"hours"`
+	}
+	if err := c.BodyParser(&input); err != nil || input.Timezone == "" {
+		return c.Status(400).JSON(fiber.Map{"message": "Dữ liệu không hợp lệ"})
+	}
+	var cal models.BusinessCalendar
+	if err := models.DB.First(&cal, models.DefaultBusinessCalendarID).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy lịch làm việc"})
+	}
+	if input.Name != "" {
+		cal.Name = input.Name
+	}
+	cal.Timezone = input.Timezone
+	hours := make([]models.BusinessHour, 0, len(input.Hours))
+	for _, h := range input.Hours {
+		hours = append(hours, models.BusinessHour{CalendarID: cal.ID, Weekday: h.Weekday, StartTime: h.StartTime, EndTime: h.EndTime})
+	}
+	// Kiểm tra múi giờ và khung giờ trước khi lưu
+	if _, err := calendar.FromModel(models.BusinessCalendar{Timezone: cal.Timezone, Hours: hours}); err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+	}
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&cal).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("calendar_id = ?", cal.ID).Delete(&models.BusinessHour{}).Error; err != nil {
+			return err
+		}
+		if len(hours) > 0 {
+			return tx.Create(&hours).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể cập nhật", "error": err.Error()})
+	}
+	calendar.Reload()
+	cal.Hours = hours
+	return c.JSON(fiber.Map{"message": "Cập nhật thành công", "item": cal})
+}
+
+// ----------- HOLIDAY -----------
+func GetHolidays(c *fiber.Ctx) error {
+	var items []models.Holiday
+	query := models.DB.Where("calendar_id = ?", models.DefaultBusinessCalendarID).Order("date")
+	if year := c.Query("year"); year != "" {
+		query = query.Where("date LIKE ?", year+"-%")
+	}
+	query.Find(&items)
+	return c.JSON(fiber.Map{"data": items})
+}
+
+func CreateHoliday(c *fiber.Ctx) error {
+	var input struct {
+		Date string `json:"date"`
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": "Dữ liệu không hợp lệ"})
+	}
+	if _, err := time.Parse("2006-01-02", input.Date); err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": "Ngày không hợp lệ (định dạng YYYY-MM-DD)"})
+	}
+	item := models.Holiday{CalendarID: models.DefaultBusinessCalendarID, Date: input.Date, Name: input.Name}
+	if err := models.DB.Create(&item).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể tạo", "error": err.Error()})
+	}
+	calendar.Reload()
+	return c.JSON(fiber.Map{"message": "Tạo thành công", "item": item})
+}
+
+func DeleteHoliday(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if err := models.DB.Delete(&models.Holiday{}, id).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể xóa"})
+	}
+	calendar.Reload()
+	return c.JSON(fiber.Map{"message": "Đã xóa"})
+}
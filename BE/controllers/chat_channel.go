@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"awesomeProject/chatops"
+	"awesomeProject/models"
+	"context"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// notifyChat đăng sự kiện của ticket lên các kênh chat có luật khớp với loại ticket/mức ưu tiên.
+// Gửi ở goroutine riêng để không làm chậm thao tác trên ticket; lỗi chỉ ghi log.
+func notifyChat(event string, ticket models.Ticket, reason string) {
+	var channels []models.ChatChannel
+	if err := models.DB.Preload("Routes").Where("is_active = ?", true).Find(&channels).Error; err != nil {
+		log.Printf("[CHAT] Không đọc được danh sách kênh chat: %v", err)
+		return
+	}
+	var targets []models.ChatChannel
+	for _, ch := range channels {
+		for _, r := range ch.Routes {
+			if r.Matches(event, ticket) {
+				targets = append(targets, ch)
+				break
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+	info := chatTicket(ticket)
+	msg := chatops.NewTicketMessage(info)
+	if event == chatops.EventSLAReminder {
+		msg = chatops.ReminderMessage(info, reason)
+	}
+	go func() {
+		for _, ch := range targets {
+			if err := chatops.Post(context.Background(), ch.Provider, ch.WebhookURL, msg); err != nil {
+				log.Printf("[CHAT] Đăng tin lên kênh %s thất bại: %v", ch.Name, err)
+			}
+		}
+	}()
+}
+
+// chatTicket lấy thông tin hiển thị của ticket cho tin nhắn chat
+func chatTicket(t models.Ticket) chatops.Ticket {
+	models.DB.Preload("Category").Preload("Priority").Preload("User").Preload("Assigned").First(&t, t.ID)
+	ct := chatops.Ticket{
+		ID:       t.ID,
+		Title:    t.Title,
+		Status:   t.Status,
+		Category: t.Category.Name,
+		Priority: t.Priority.Name,
+		Customer: t.User.Name,
+	}
+	if t.Assigned != nil {
+		ct.Assignee = t.Assigned.Name
+	}
+	if base := strings.TrimRight(os.Getenv("APP_FRONTEND_URL"), "/"); base != "" {
+		ct.URL = base + "/admin/tickets/" + strconv.Itoa(int(t.ID))
+	}
+	return ct
+}
+
+// ----------- KÊNH CHAT (ADMIN) -----------
+
+type chatRouteInput struct {
+	Event      string `json:"event"`
+	CategoryID *uint  `json:"category_id"`
+	PriorityID *uint  `json:"priority_id"`
+}
+
+type chatChannelInput struct {
+	Name       string           `json:"name"`
+	Provider   string           `json:"provider"`
+	WebhookURL string           `json:"webhook_url"`
+	IsActive   *bool            `json:"is_active"`
+	Routes     []chatRouteInput `json:"routes"`
+}
+
+func (in *chatChannelInput) validate() string {
+	in.Name = strings.TrimSpace(in.Name)
+	in.WebhookURL = strings.TrimSpace(in.WebhookURL)
+	if in.Name == "" {
+		return "Tên không hợp lệ"
+	}
+	if !chatops.IsValidProvider(in.Provider) {
+		return "Loại kênh chat không hợp lệ"
+	}
+	u, err := url.Parse(in.WebhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(in.WebhookURL) > 500 {
+		return "Webhook URL phải là địa chỉ http/https hợp lệ"
+	}
+	for _, r := range in.Routes {
+		if !chatops.IsValidEvent(r.Event) {
+			return "Sự kiện không hợp lệ: " + r.Event
+		}
+		if r.CategoryID != nil && *r.CategoryID > 0 {
+			if err := models.DB.First(&models.TicketCategory{}, *r.CategoryID).Error; err != nil {
+				return "Loại ticket không tồn tại"
+			}
+		}
+		if r.PriorityID != nil && *r.PriorityID > 0 {
+			if err := models.DB.First(&models.TicketPriority{}, *r.PriorityID).Error; err != nil {
+				return "Mức độ ưu tiên không tồn tại"
+			}
+		}
+	}
+	return ""
+}
+
+// saveChatChannel lưu kênh chat cùng các luật định tuyến (thay thế toàn bộ)
+func saveChatChannel(item *models.ChatChannel, in chatChannelInput) error {
+	return models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Routes").Save(item).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("channel_id = ?", item.ID).Delete(&models.ChatRoute{}).Error; err != nil {
+			return err
+		}
+		item.Routes = nil
+		for _, r := range in.Routes {
+			route := models.ChatRoute{
+				ChannelID:  item.ID,
+				Event:      r.Event,
+				CategoryID: normalizeOptionalID(r.CategoryID),
+				PriorityID: normalizeOptionalID(r.PriorityID),
+			}
+			if err := tx.Create(&route).Error; err != nil {
+				return err
+			}
+			item.Routes = append(item.Routes, route)
+		}
+		return nil
+	})
+}
+
+// GetChatChannels - Danh sách kênh chat cùng các loại kênh và sự kiện hỗ trợ
+func GetChatChannels(c *fiber.Ctx) error {
+	var items []models.ChatChannel
+	models.DB.Preload("Routes").Order("name").Find(&items)
+	return c.JSON(fiber.Map{"data": items, "providers": chatops.Providers, "events": chatops.Events})
+}
+
+func CreateChatChannel(c *fiber.Ctx) error {
+	var input chatChannelInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": "Dữ liệu không hợp lệ"})
+	}
+	if msg := input.validate(); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"message": msg})
+	}
+	item := models.ChatChannel{
+		Name:       input.Name,
+		Provider:   input.Provider,
+		WebhookURL: input.WebhookURL,
+		IsActive:   input.IsActive == nil || *input.IsActive,
+	}
+	if err := saveChatChannel(&item, input); err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể tạo", "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "Tạo thành công", "item": item})
+}
+
+func UpdateChatChannel(c *fiber.Ctx) error {
+	var item models.ChatChannel
+	if err := models.DB.First(&item, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy"})
+	}
+	var input chatChannelInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": "Dữ liệu không hợp lệ"})
+	}
+	if msg := input.validate(); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"message": msg})
+	}
+	item.Name = input.Name
+	item.Provider = input.Provider
+	item.WebhookURL = input.WebhookURL
+	if input.IsActive != nil {
+		item.IsActive = *input.IsActive
+	}
+	if err := saveChatChannel(&item, input); err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể cập nhật", "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "Cập nhật thành công", "item": item})
+}
+
+func DeleteChatChannel(c *fiber.Ctx) error {
+	var item models.ChatChannel
+	if err := models.DB.First(&item, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy"})
+	}
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("channel_id = ?", item.ID).Delete(&models.ChatRoute{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&item).Error
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể xóa"})
+	}
+	return c.JSON(fiber.Map{"message": "Đã xóa"})
+}
+
+// TestChatChannel - Gửi tin nhắn thử lên kênh chat để kiểm tra cấu hình
+func TestChatChannel(c *fiber.Ctx) error {
+	var item models.ChatChannel
+	if err := models.DB.First(&item, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy"})
+	}
+	if err := chatops.Post(c.Context(), item.Provider, item.WebhookURL, chatops.TestMessage()); err != nil {
+		return c.Status(502).JSON(fiber.Map{"message": "Gửi thử thất bại: " + err.Error(), "success": false})
+	}
+	return c.JSON(fiber.Map{"message": "Gửi thử thành công", "success": true})
+}
@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"awesomeProject/mailer"
+	"awesomeProject/models"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Cấu hình worker gửi email
+const (
+	emailMaxAttempts    = 8                // số lần gửi tối đa trước khi chuyển sang dead
+	emailRetryBase      = time.Minute      // thời gian chờ sau lần lỗi đầu, nhân đôi sau mỗi lần lỗi
+	emailRetryMax       = 6 * time.Hour    // thời gian chờ tối đa giữa hai lần gửi
+	emailSendingTimeout = 10 * time.Minute // email kẹt ở trạng thái sending quá lâu (server dừng giữa chừng) được gửi lại
+	emailBatchSize      = 20
+	// Số ngày giữ nội dung email đã gửi/bỏ gửi, đổi qua EMAIL_BODY_RETENTION_DAYS
+	defaultEmailBodyRetentionDays = 7
+)
+
+// outgoingEmail là email cần gửi
+type outgoingEmail struct {
+	Kind    string
+	To      string
+	Subject string
+	Body    string // HTML
+	Headers map[string]string
+}
+
+// queueEmail ghi email vào hàng đợi; worker ProcessEmailOutbox sẽ gửi
+func queueEmail(e outgoingEmail) error {
+	item := models.EmailOutbox{
+		Kind:          e.Kind,
+		ToAddress:     e.To,
+		Subject:       truncateRunes(e.Subject, 255),
+		Body:          e.Body,
+		Status:        models.EmailPending,
+		MaxAttempts:   emailMaxAttempts,
+		NextAttemptAt: time.Now(),
+	}
+	item.SetHeaders(e.Headers)
+	return models.DB.Create(&item).Error
+}
+
+// emailRetryDelay: 1 phút, 2 phút, 4 phút... tối đa 6 giờ
+func emailRetryDelay(attempts int) time.Duration {
+	delay := emailRetryBase
+	for i := 1; i < attempts && delay < emailRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > emailRetryMax {
+		delay = emailRetryMax
+	}
+	return delay
+}
+
+// ProcessEmailOutbox gửi các email đến hạn trong hàng đợi
+func ProcessEmailOutbox() {
+	now := time.Now()
+	// Email bị kẹt do server dừng khi đang gửi
+	models.DB.Model(&models.EmailOutbox{}).
+		Where("status = ? AND updated_at < ?", models.EmailSending, now.Add(-emailSendingTimeout)).
+		Updates(map[string]interface{}{"status": models.EmailFailed, "next_attempt_at": now})
+
+	var items []models.EmailOutbox
+	models.DB.Where("status IN ? AND next_attempt_at <= ?", []string{models.EmailPending, models.EmailFailed}, now).
+		Order("next_attempt_at").Limit(emailBatchSize).Find(&items)
+	for _, item := range items {
+		// Nhận email bằng cập nhật có điều kiện để không gửi trùng khi chạy nhiều server
+		claim := models.DB.Model(&models.EmailOutbox{}).Where("id = ? AND status = ?", item.ID, item.Status).
+			Update("status", models.EmailSending)
+		if claim.Error != nil || claim.RowsAffected == 0 {
+			continue
+		}
+		item.Attempts++
+		if err := deliverEmail(item); err != nil {
+			status := models.EmailFailed
+			if item.Attempts >= item.MaxAttempts {
+				status = models.EmailDead
+				log.Printf("[MAIL ERROR] Bỏ gửi email #%d đến %s sau %d lần: %v", item.ID, item.ToAddress, item.Attempts, err)
+			}
+			models.DB.Model(&item).Updates(map[string]interface{}{
+				"status":          status,
+				"attempts":        item.Attempts,
+				"last_error":      err.Error(),
+				"next_attempt_at": time.Now().Add(emailRetryDelay(item.Attempts)),
+			})
+			continue
+		}
+		sentAt := time.Now()
+		updates := map[string]interface{}{
+			"status":     models.EmailSent,
+			"attempts":   item.Attempts,
+			"last_error": "",
+			"sent_at":    &sentAt,
+		}
+		if models.SensitiveEmailKind(item.Kind) {
+			updates["body"] = ""
+		}
+		models.DB.Model(&item).Updates(updates)
+	}
+}
+
+// PurgeEmailBodies xóa nội dung các email đã gửi xong hoặc đã bỏ gửi quá EMAIL_BODY_RETENTION_DAYS ngày
+// (mặc định 7); email xác thực/đặt lại mật khẩu không gửi được thì xóa nội dung sau một ngày - chạy nền
+func PurgeEmailBodies() {
+	days := defaultEmailBodyRetentionDays
+	if v, err := strconv.Atoi(os.Getenv("EMAIL_BODY_RETENTION_DAYS")); err == nil && v > 0 {
+		days = v
+	}
+	now := time.Now()
+	result := models.DB.Model(&models.EmailOutbox{}).
+		Where("body <> '' AND status IN ? AND updated_at < ?", []string{models.EmailSent, models.EmailDead}, now.AddDate(0, 0, -days)).
+		Update("body", "")
+	if result.Error != nil {
+		log.Printf("[MAIL ERROR] Xóa nội dung email cũ thất bại: %v", result.Error)
+	}
+	models.DB.Model(&models.EmailOutbox{}).
+		Where("body <> '' AND kind IN ? AND created_at < ?", []string{models.EmailKindVerification, models.EmailKindResetPassword}, now.Add(-24*time.Hour)).
+		Updates(map[string]interface{}{"body": "", "status": models.EmailDead, "last_error": "Mã đã hết hạn, không gửi nữa"})
+}
+
+// deliverEmail gửi một email qua mailer đã cấu hình
+func deliverEmail(item models.EmailOutbox) error {
+	return mailer.Default().Send(mailer.Message{
+		To:      item.ToAddress,
+		Subject: item.Subject,
+		HTML:    item.Body,
+		Headers: item.HeaderMap(),
+	})
+}
+
+// ----------- EMAIL OUTBOX (ADMIN) -----------
+
+// GetEmailOutbox - Danh sách email trong hàng đợi, lọc theo status (pending, sending, sent, failed, dead), kind, to
+func GetEmailOutbox(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	query := models.DB.Model(&models.EmailOutbox{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if to := c.Query("to"); to != "" {
+		query = query.Where("to_address LIKE ?", "%"+to+"%")
+	}
+	var total int64
+	query.Count(&total)
+	var items []models.EmailOutbox
+	if err := query.Order("id DESC").Limit(limit).Offset((page - 1) * limit).Find(&items).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không lấy được danh sách email"})
+	}
+	return c.JSON(fiber.Map{
+		"data": items,
+		"pagination": fiber.Map{
+			"total": total,
+			"pages": int((total + int64(limit) - 1) / int64(limit)),
+		},
+	})
+}
+
+// RetryEmailOutbox - Gửi lại email lỗi hoặc đã bỏ gửi (dead)
+func RetryEmailOutbox(c *fiber.Ctx) error {
+	var item models.EmailOutbox
+	if err := models.DB.First(&item, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy"})
+	}
+	if item.Status != models.EmailFailed && item.Status != models.EmailDead {
+		return c.Status(400).JSON(fiber.Map{"message": "Chỉ gửi lại được email lỗi"})
+	}
+	if item.Body == "" {
+		return c.Status(400).JSON(fiber.Map{"message": "Nội dung email đã bị xóa, không thể gửi lại"})
+	}
+	item.Status = models.EmailPending
+	item.Attempts = 0
+	item.NextAttemptAt = time.Now()
+	if err := models.DB.Model(&item).Select("status", "attempts", "next_attempt_at").Updates(&item).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể gửi lại email"})
+	}
+	return c.JSON(fiber.Map{"message": "Đã đưa email vào hàng đợi gửi lại", "item": item})
+}
+
+// GetMailerHealth - Kiểm tra cấu hình và kết nối máy chủ gửi email
+func GetMailerHealth(c *fiber.Ctx) error {
+	if err := mailer.Default().Check(); err != nil {
+		return c.Status(503).JSON(fiber.Map{"message": "Không kết nối được máy chủ gửi email: " + err.Error(), "healthy": false})
+	}
+	return c.JSON(fiber.Map{"message": "Kết nối máy chủ gửi email bình thường", "healthy": true})
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"awesomeProject/emailtemplate"
+	"awesomeProject/models"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// emailTemplateSource lấy mẫu admin tùy chỉnh nếu có, không thì mẫu mặc định
+func emailTemplateSource(key, lang string) (emailtemplate.Source, bool, error) {
+	var custom models.EmailTemplate
+	if err := models.DB.Where("template_key = ? AND language = ?", key, lang).First(&custom).Error; err == nil {
+		return emailtemplate.Source{Subject: custom.Subject, Body: custom.Body}, true, nil
+	}
+	src, err := emailtemplate.Default(key, lang)
+	return src, false, err
+}
+
+// renderEmail dựng email theo ngôn ngữ người nhận. Mẫu tùy chỉnh lỗi thì dùng mẫu mặc định để email vẫn được gửi.
+func renderEmail(key, lang string, data map[string]interface{}) (emailtemplate.Message, error) {
+	lang = emailtemplate.NormalizeLanguage(lang)
+	src, custom, err := emailTemplateSource(key, lang)
+	if err != nil {
+		return emailtemplate.Message{}, err
+	}
+	msg, err := emailtemplate.Render(src, data)
+	if err != nil && custom {
+		log.Printf("[MAIL ERROR] Mẫu email %s/%s lỗi, dùng mẫu mặc định: %v", key, lang, err)
+		if src, err = emailtemplate.Default(key, lang); err == nil {
+			msg, err = emailtemplate.Render(src, data)
+		}
+	}
+	return msg, err
+}
+
+// emailTemplateParams đọc và kiểm tra loại email, ngôn ngữ trên URL
+func emailTemplateParams(c *fiber.Ctx) (string, string, bool) {
+	key, lang := c.Params("key"), c.Params("lang")
+	return key, lang, emailtemplate.IsValidKey(key) && emailtemplate.IsValidLanguage(lang)
+}
+
+// ----------- EMAIL TEMPLATE (ADMIN) -----------
+
+// GetEmailTemplates - Danh sách loại email, dữ liệu mẫu và nội dung đang dùng theo từng ngôn ngữ
+func GetEmailTemplates(c *fiber.Ctx) error {
+	var customs []models.EmailTemplate
+	if err := models.DB.Find(&customs).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không lấy được danh sách mẫu email"})
+	}
+	customByKey := map[string]models.EmailTemplate{}
+	for _, t := range customs {
+		customByKey[t.Key+"/"+t.Language] = t
+	}
+	var data []fiber.Map
+	for _, info := range emailtemplate.Catalog() {
+		var templates []fiber.Map
+		for _, lang := range emailtemplate.Languages {
+			item := fiber.Map{"language": lang, "is_custom": false}
+			if t, ok := customByKey[info.Key+"/"+lang]; ok {
+				item["is_custom"] = true
+				item["subject"] = t.Subject
+				item["body"] = t.Body
+				item["updated_at"] = t.UpdatedAt
+			} else if src, err := emailtemplate.Default(info.Key, lang); err == nil {
+				item["subject"] = src.Subject
+				item["body"] = src.Body
+			}
+			templates = append(templates, item)
+		}
+		data = append(data, fiber.Map{
+			"key":         info.Key,
+			"description": info.Description,
+			"sample":      info.Sample,
+			"templates":   templates,
+		})
+	}
+	return c.JSON(fiber.Map{"data": data})
+}
+
+// UpdateEmailTemplate - Lưu mẫu tùy chỉnh cho một loại email và ngôn ngữ
+func UpdateEmailTemplate(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	key, lang, ok := emailTemplateParams(c)
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy mẫu email"})
+	}
+	var input emailtemplate.Source
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": "Dữ liệu không hợp lệ"})
+	}
+	input.Subject = strings.TrimSpace(input.Subject)
+	if len([]rune(input.Subject)) > 255 {
+		return c.Status(400).JSON(fiber.Map{"message": "Tiêu đề tối đa 255 ký tự"})
+	}
+	if err := emailtemplate.Validate(key, input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": "Mẫu email không hợp lệ: " + err.Error()})
+	}
+	var item models.EmailTemplate
+	models.DB.Where("template_key = ? AND language = ?", key, lang).First(&item)
+	item.Key = key
+	item.Language = lang
+	item.Subject = input.Subject
+	item.Body = input.Body
+	item.UpdatedBy = user.ID
+	if err := models.DB.Save(&item).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể lưu mẫu email"})
+	}
+	return c.JSON(fiber.Map{"message": "Đã lưu mẫu email", "item": item})
+}
+
+// ResetEmailTemplate - Xóa mẫu tùy chỉnh, quay về mẫu mặc định
+func ResetEmailTemplate(c *fiber.Ctx) error {
+	key, lang, ok := emailTemplateParams(c)
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy mẫu email"})
+	}
+	if err := models.DB.Where("template_key = ? AND language = ?", key, lang).Delete(&models.EmailTemplate{}).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể khôi phục mẫu email"})
+	}
+	return c.JSON(fiber.Map{"message": "Đã khôi phục mẫu email mặc định"})
+}
+
+// PreviewEmailTemplate - Xem trước email với dữ liệu mẫu. Gửi kèm subject/body để xem bản đang sửa,
+// bỏ trống thì xem mẫu đang dùng.
+func PreviewEmailTemplate(c *fiber.Ctx) error {
+	key, lang, ok := emailTemplateParams(c)
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy mẫu email"})
+	}
+	var input emailtemplate.Source
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Dữ liệu không hợp lệ"})
+		}
+	}
+	src, _, err := emailTemplateSource(key, lang)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy mẫu email"})
+	}
+	if input.Subject != "" {
+		src.Subject = input.Subject
+	}
+	if input.Body != "" {
+		src.Body = input.Body
+	}
+	msg, err := emailtemplate.Render(src, emailtemplate.Sample(key))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": "Mẫu email không hợp lệ: " + err.Error()})
+	}
+	return c.JSON(fiber.Map{"data": msg})
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/realtime"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	eventsKeepAlive     = 25 * time.Second // gửi dòng chú thích định kỳ để proxy không đóng kết nối rảnh
+	eventsMaxTickets    = 20               // số ticket tối đa theo dõi trên một kết nối
+	eventsRetryInterval = 5000             // thời gian (ms) trình duyệt chờ trước khi tự kết nối lại
+)
+
+// StreamEvents - Kết nối Server-Sent Events nhận thông báo mới của người dùng và thay đổi trên các ticket đang xem.
+// Xác thực bằng cookie JWT như các API khác (EventSource với withCredentials).
+// Query tickets=1,2,3: các ticket đang xem để nhận bình luận mới và thay đổi trạng thái.
+func StreamEvents(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	topics := []string{realtime.UserTopic(user.ID)}
+	for i, raw := range strings.Split(c.Query("tickets"), ",") {
+		if i >= eventsMaxTickets {
+			break
+		}
+		id, err := strconv.ParseUint(strings.TrimSpace(raw), 10, 64)
+		if err != nil || id == 0 {
+			continue
+		}
+		var ticket models.Ticket
+		if err := models.DB.First(&ticket, id).Error; err != nil || !canViewTicket(user, ticket) {
+			continue
+		}
+		topics = append(topics, realtime.TicketTopic(ticket.ID))
+		if user.Role == "admin" || user.Role == "staff" {
+			topics = append(topics, realtime.TicketStaffTopic(ticket.ID))
+		}
+	}
+	sub := realtime.Default().Subscribe(topics...)
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // tắt buffer của nginx
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+		keepAlive := time.NewTicker(eventsKeepAlive)
+		defer keepAlive.Stop()
+		fmt.Fprintf(w, "retry: %d\n\n", eventsRetryInterval)
+		if err := w.Flush(); err != nil {
+			return
+		}
+		for {
+			select {
+			case ev, ok := <-sub.Events():
+				if !ok {
+					return
+				}
+				data, err := json.Marshal(ev.Data)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+			// Client đóng kết nối thì Flush báo lỗi
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+	return nil
+}
@@ -0,0 +1,350 @@
+package controllers
+
+import (
+	"awesomeProject/assignment"
+	"awesomeProject/inbound"
+	"awesomeProject/mailer"
+	"awesomeProject/models"
+	"awesomeProject/sla"
+	"awesomeProject/upload"
+	"awesomeProject/workflow"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PollInboundMail đọc email mới từ hộp thư hỗ trợ (nếu có cấu hình) và tạo ticket/bình luận
+func PollInboundMail() {
+	src, err := inbound.FromEnv()
+	if err != nil {
+		log.Printf("[INBOUND] Cấu hình hộp thư không hợp lệ: %v", err)
+		return
+	}
+	if src == nil {
+		return
+	}
+	if err := src.Poll(ProcessInboundEmail); err != nil {
+		log.Printf("[INBOUND] Không đọc được hộp thư: %v", err)
+	}
+}
+
+// ProcessInboundEmail xử lý một email gửi đến: email trả lời ticket có sẵn thành bình luận,
+// email mới thành ticket mới. Email đã xử lý (cùng Message-ID) được bỏ qua.
+func ProcessInboundEmail(raw []byte) error {
+	msg, err := inbound.Parse(raw)
+	if err != nil {
+		// Email dị dạng đọc lại cũng không được
+		return inbound.Permanent(err)
+	}
+	var count int64
+	models.DB.Model(&models.InboundEmail{}).Where("message_id = ?", msg.MessageID).Count(&count)
+	if count > 0 {
+		return nil
+	}
+	record := models.InboundEmail{
+		MessageID:   truncateRunes(msg.MessageID, 255),
+		FromAddress: truncateRunes(msg.FromAddress, 255),
+		Subject:     truncateRunes(msg.Subject, 255),
+		ReceivedAt:  msg.Date,
+	}
+	if reason := inboundIgnoreReason(msg); reason != "" {
+		record.Status = models.InboundIgnored
+		record.Note = reason
+		return models.DB.Create(&record).Error
+	}
+
+	// Email trả lời đến địa chỉ riêng của ticket: đăng bình luận dưới tên người nhận email gốc
+	ticket, user, viaReplyAddress, reject := ticketFromReplyAddress(msg)
+	if !viaReplyAddress && reject == "" {
+		var created bool
+		if user, created, err = inboundSender(msg); err != nil {
+			return err
+		}
+		reject = inboundSenderRejectReason(user, created, msg.SenderAuthenticated(os.Getenv("INBOUND_AUTHSERV_ID")))
+		if reject == "" && !created {
+			ticket = findInboundTicket(msg, user)
+		}
+	}
+	if reject != "" {
+		log.Printf("[INBOUND] Từ chối email %s từ %s: %s", msg.MessageID, msg.FromAddress, reject)
+		record.Status = models.InboundIgnored
+		record.Note = reject
+		return models.DB.Create(&record).Error
+	}
+	record.UserID = &user.ID
+	if ticket != nil {
+		comment, err := addInboundComment(msg, ticket, user)
+		if err != nil {
+			return err
+		}
+		record.Status = models.InboundCommentAdded
+		record.TicketID = &ticket.ID
+		record.CommentID = &comment.ID
+	} else {
+		ticket, err := createInboundTicket(msg, user)
+		if err != nil {
+			return err
+		}
+		record.Status = models.InboundTicketCreated
+		record.TicketID = &ticket.ID
+	}
+	if err := models.DB.Create(&record).Error; err != nil {
+		log.Printf("[INBOUND] Không ghi được nhật ký email %s: %v", msg.MessageID, err)
+	}
+	return nil
+}
+
+// inboundIgnoreReason trả về lý do bỏ qua email, rỗng nếu cần xử lý
+func inboundIgnoreReason(msg *inbound.Message) string {
+	if msg.FromAddress == "" {
+		return "Không xác định được người gửi"
+	}
+	if msg.AutoReply {
+		return "Email trả lời tự động"
+	}
+	local := strings.SplitN(msg.FromAddress, "@", 2)[0]
+	if local == "mailer-daemon" || local == "postmaster" {
+		return "Thông báo lỗi gửi thư"
+	}
+	// Email do chính hệ thống gửi quay lại hộp thư (vd hộp thư gửi và nhận là một)
+	if own := mailer.From(); own != "" && strings.EqualFold(own, msg.FromAddress) {
+		return "Email do hệ thống gửi"
+	}
+	return ""
+}
+
+// inboundSenderRejectReason trả về lý do từ chối email gửi thẳng vào hộp thư hỗ trợ (không qua địa chỉ trả lời có chữ ký).
+// Địa chỉ From có thể bị giả mạo nên email của tài khoản đã có chỉ được nhận khi máy chủ nhận thư xác thực được người gửi,
+// email của admin/staff thì không bao giờ nhận theo đường này.
+func inboundSenderRejectReason(user models.User, created, authenticated bool) string {
+	if user.Role != "customer" || user.IsServiceAccount {
+		return "Email của tài khoản nhân viên chỉ được nhận qua địa chỉ trả lời của ticket"
+	}
+	if !created && !authenticated {
+		return "Người gửi chưa được xác thực (SPF/DKIM) nên không ghi nhận email dưới tài khoản khách hàng có sẵn"
+	}
+	return ""
+}
+
+// inboundSender tìm user theo địa chỉ gửi, chưa có thì tạo tài khoản khách hàng chưa xác thực
+// (mật khẩu ngẫu nhiên, khách hàng dùng quên mật khẩu để đăng nhập). created cho biết tài khoản vừa được tạo.
+func inboundSender(msg *inbound.Message) (user models.User, created bool, err error) {
+	if err := models.DB.Where("email = ?", msg.FromAddress).First(&user).Error; err == nil {
+		return user, false, nil
+	}
+	name := msg.FromName
+	if name == "" {
+		name = strings.SplitN(msg.FromAddress, "@", 2)[0]
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return user, false, err
+	}
+	user = models.User{
+		Name:         truncateRunes(name, 100),
+		Email:        msg.FromAddress,
+		PasswordHash: fmt.Sprintf("%x", sha256.Sum256(secret)),
+		Role:         "customer",
+		IsVerified:   false,
+	}
+	if err := models.DB.Create(&user).Error; err != nil {
+		// Có thể vừa được tạo bởi request khác
+		if err := models.DB.Where("email = ?", msg.FromAddress).First(&user).Error; err == nil {
+			return user, false, nil
+		}
+		return user, false, err
+	}
+	log.Printf("[INBOUND] Tạo tài khoản khách hàng %s từ email", user.Email)
+	return user, true, nil
+}
+
+// ticketFromReplyAddress nhận diện email gửi đến địa chỉ trả lời riêng (plus-addressing) của ticket.
+// Địa chỉ có chữ ký hợp lệ nhưng người gửi khác người nhận email gốc hoặc không còn quyền
+// phản hồi ticket thì trả về lý do từ chối.
+func ticketFromReplyAddress(msg *inbound.Message) (*models.Ticket, models.User, bool, string) {
+	var user models.User
+	ticketID, userID, ok := inbound.ParseReplyAddress(msg.To)
+	if !ok {
+		return nil, user, false, ""
+	}
+	var ticket models.Ticket
+	if err := models.DB.First(&ticket, ticketID).Error; err != nil {
+		return nil, user, false, "Ticket của địa chỉ trả lời không tồn tại"
+	}
+	if err := models.DB.First(&user, userID).Error; err != nil || !canCommentTicket(user, ticket) {
+		return nil, user, false, "Người nhận email gốc không còn quyền phản hồi ticket"
+	}
+	if !strings.EqualFold(user.Email, msg.FromAddress) {
+		return nil, user, false, fmt.Sprintf("Email trả lời ticket #%d gửi từ %s thay vì %s", ticket.ID, msg.FromAddress, user.Email)
+	}
+	return &ticket, user, true, ""
+}
+
+// findInboundTicket tìm ticket mà email đang trả lời theo Message-ID của luồng thư (email hệ thống gửi
+// hoặc email đã nhận trước đó). Chỉ dùng khi người gửi đã được xác thực; người gửi phải có quyền phản hồi ticket.
+func findInboundTicket(msg *inbound.Message, user models.User) *models.Ticket {
+	var ticketID uint
+	ids := msg.ThreadIDs()
+	for _, id := range ids {
+		if ticketID = inbound.TicketFromMessageID(id); ticketID != 0 {
+			break
+		}
+	}
+	if ticketID == 0 && len(ids) > 0 {
+		var prev models.InboundEmail
+		if err := models.DB.Where("message_id IN ? AND ticket_id IS NOT NULL", ids).Order("id DESC").First(&prev).Error; err == nil {
+			ticketID = *prev.TicketID
+		}
+	}
+	if ticketID == 0 {
+		return nil
+	}
+	var ticket models.Ticket
+	if err := models.DB.First(&ticket, ticketID).Error; err != nil || !canCommentTicket(user, ticket) {
+		return nil
+	}
+	return &ticket
+}
+
+// createInboundTicket tạo ticket mới từ email với loại ticket/sản phẩm/mức ưu tiên mặc định
+func createInboundTicket(msg *inbound.Message, user models.User) (*models.Ticket, error) {
+	categoryID := inboundDefaultID("INBOUND_DEFAULT_CATEGORY_ID", &models.TicketCategory{})
+	productTypeID := inboundDefaultID("INBOUND_DEFAULT_PRODUCT_TYPE_ID", &models.TicketProductType{})
+	priorityID := inboundDefaultID("INBOUND_DEFAULT_PRIORITY_ID", &models.TicketPriority{})
+	if categoryID == 0 || productTypeID == 0 || priorityID == 0 {
+		return nil, errors.New("chưa có loại ticket, loại sản phẩm hoặc mức độ ưu tiên mặc định cho ticket từ email")
+	}
+
+	uploads, note, err := storeInboundAttachments(msg, upload.ContextTicket, "uploads/tickets", "ticket")
+	if err != nil {
+		return nil, err
+	}
+	var attachmentPath string
+	if len(uploads) > 0 {
+		attachmentPath = uploads[0].URL()
+	}
+	title := strings.TrimSpace(msg.Subject)
+	if title == "" {
+		title = "(Không có tiêu đề)"
+	}
+	ticket := models.Ticket{
+		UserID:         user.ID,
+		Title:          truncateRunes(title, 255),
+		Description:    inboundContent(msg.Body(), note),
+		CategoryID:     categoryID,
+		ProductTypeID:  productTypeID,
+		PriorityID:     priorityID,
+		Status:         workflow.StatusNew,
+		AttachmentPath: attachmentPath,
+	}
+	ticket.TeamID = assignment.RouteToTeam(models.DB, ticket.CategoryID, ticket.ProductTypeID)
+	sla.Apply(&ticket, time.Now())
+	if err := models.DB.Create(&ticket).Error; err != nil {
+		removeAttachmentFiles(uploads)
+		return nil, err
+	}
+	attachments, err := createAttachments(uploads, models.AttachmentOwnerTicket, ticket.ID, ticket.ID, user.ID)
+	if err != nil {
+		removeAttachmentFiles(uploads)
+		models.DB.Delete(&ticket)
+		return nil, err
+	}
+	scanAttachments(attachments, ticket)
+	onTicketCreated(&ticket, user)
+	return &ticket, nil
+}
+
+// addInboundComment thêm nội dung mới của email trả lời (bỏ phần trích dẫn) thành bình luận của ticket
+func addInboundComment(msg *inbound.Message, ticket *models.Ticket, user models.User) (*models.TicketComment, error) {
+	uploads, note, err := storeInboundAttachments(msg, upload.ContextComment, "uploads/comments", "comment")
+	if err != nil {
+		return nil, err
+	}
+	var attachmentPath string
+	if len(uploads) > 0 {
+		attachmentPath = uploads[0].Path
+	}
+	comment := models.TicketComment{
+		TicketID:       ticket.ID,
+		UserID:         user.ID,
+		Content:        inboundContent(inbound.StripQuoted(msg.Body()), note),
+		AttachmentPath: attachmentPath,
+		CreatedAt:      time.Now(),
+	}
+	if err := models.DB.Create(&comment).Error; err != nil {
+		removeAttachmentFiles(uploads)
+		return nil, err
+	}
+	attachments, err := createAttachments(uploads, models.AttachmentOwnerComment, comment.ID, ticket.ID, user.ID)
+	if err != nil {
+		removeAttachmentFiles(uploads)
+		models.DB.Delete(&comment)
+		return nil, err
+	}
+	scanAttachments(attachments, *ticket)
+	onCommentCreated(ticket, &comment, user)
+	return &comment, nil
+}
+
+// storeInboundAttachments kiểm tra và lưu file đính kèm email theo chính sách upload.
+// File không hợp lệ bị bỏ qua (không chặn cả email) và được liệt kê trong note.
+func storeInboundAttachments(msg *inbound.Message, context, dir, prefix string) ([]models.Attachment, string, error) {
+	policy := upload.PolicyFor(context)
+	var files []*upload.File
+	var skipped []string
+	for _, a := range msg.Attachments {
+		if policy.MaxFiles > 0 && len(files) >= policy.MaxFiles {
+			skipped = append(skipped, fmt.Sprintf("%s: vượt quá %d file cho phép", upload.SanitizeDisplayName(a.Filename), policy.MaxFiles))
+			continue
+		}
+		f, err := upload.ValidateBytes(a.Filename, a.Data, policy)
+		if err != nil {
+			skipped = append(skipped, err.Error())
+			continue
+		}
+		files = append(files, f)
+	}
+	items, err := storeUploads(files, dir, prefix)
+	if err != nil {
+		return nil, "", err
+	}
+	note := ""
+	if len(skipped) > 0 {
+		note = "[Tệp đính kèm không được lưu]\n- " + strings.Join(skipped, "\n- ")
+	}
+	return items, note, nil
+}
+
+func inboundContent(body, note string) string {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		body = "(Email không có nội dung)"
+	}
+	if note != "" {
+		body += "\n\n" + note
+	}
+	return body
+}
+
+// inboundDefaultID lấy ID mặc định từ biến môi trường, nếu không có thì dùng bản ghi đầu tiên
+func inboundDefaultID(envKey string, model interface{}) uint {
+	if id, err := strconv.ParseUint(os.Getenv(envKey), 10, 64); err == nil && id > 0 {
+		return uint(id)
+	}
+	var id uint
+	models.DB.Model(model).Select("id").Order("id").Limit(1).Scan(&id)
+	return id
+}
+
+func truncateRunes(s string, max int) string {
+	if r := []rune(s); len(r) > max {
+		return string(r[:max])
+	}
+	return s
+}
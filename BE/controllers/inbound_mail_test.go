@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"awesomeProject/models"
+	"testing"
+)
+
+func TestInboundSenderRejectReason(t *testing.T) {
+	customer := models.User{Role: "customer"}
+	staff := models.User{Role: "staff"}
+	service := models.User{Role: "customer", IsServiceAccount: true}
+	tests := []struct {
+		name          string
+		user          models.User
+		created       bool
+		authenticated bool
+		reject        bool
+	}{
+		{"khách hàng mới từ email", customer, true, false, false},
+		{"khách hàng có sẵn, người gửi đã xác thực", customer, false, true, false},
+		{"khách hàng có sẵn, From có thể bị giả mạo", customer, false, false, true},
+		{"nhân viên dù đã xác thực", staff, false, true, true},
+		{"tài khoản dịch vụ", service, false, true, true},
+	}
+	for _, tt := range tests {
+		if got := inboundSenderRejectReason(tt.user, tt.created, tt.authenticated); (got != "") != tt.reject {
+			t.Errorf("%s: inboundSenderRejectReason = %q, want reject = %v", tt.name, got, tt.reject)
+		}
+	}
+}
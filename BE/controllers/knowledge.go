@@ -2,9 +2,10 @@ package controllers
 
 import (
 	"awesomeProject/models"
+	"awesomeProject/upload"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"mime/multipart"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -77,13 +78,11 @@ func AdminCreateKnowledgeBase(c *fiber.Ctx) error {
 	filePath := ""
 	file, err := c.FormFile("file")
 	if err == nil && file != nil {
-		os.MkdirAll("uploads/knowledge", 0755)
-		filename := fmt.Sprintf("knowledge_%d_%s", time.Now().UnixNano(), file.Filename)
-		savePath := filepath.Join("uploads/knowledge", filename)
-		if err := c.SaveFile(file, savePath); err != nil {
-			return c.Status(500).JSON(fiber.Map{"message": "Không thể lưu file"})
+		saved, err := saveKnowledgeFile(file)
+		if err != nil {
+			return respondKnowledgeFileError(c, err)
 		}
-		filePath = "/uploads/knowledge/" + filename
+		filePath = saved
 	}
 	// Sinh slug tự động từ title, đảm bảo unique
 	rawSlug := c.FormValue("slug")
@@ -128,13 +127,11 @@ func AdminUpdateKnowledgeBase(c *fiber.Ctx) error {
 	isPublished := c.FormValue("is_published") == "true"
 	file, err := c.FormFile("file")
 	if err == nil && file != nil {
-		os.MkdirAll("uploads/knowledge", 0755)
-		filename := fmt.Sprintf("knowledge_%d_%s", time.Now().UnixNano(), file.Filename)
-		savePath := filepath.Join("uploads/knowledge", filename)
-		if err := c.SaveFile(file, savePath); err != nil {
-			return c.Status(500).JSON(fiber.Map{"message": "Không thể lưu file"})
+		saved, err := saveKnowledgeFile(file)
+		if err != nil {
+			return respondKnowledgeFileError(c, err)
 		}
-		doc.FilePath = "/uploads/knowledge/" + filename
+		doc.FilePath = saved
 	}
 	// Sinh slug tự động từ title, đảm bảo unique (trừ chính bản ghi này)
 	rawSlug := c.FormValue("slug")
@@ -171,3 +168,24 @@ func AdminDeleteKnowledgeBase(c *fiber.Ctx) error {
 	}
 	return c.JSON(fiber.Map{"message": "Đã xóa tài liệu"})
 }
+
+// saveKnowledgeFile kiểm tra file tài liệu theo chính sách upload knowledge base và lưu với tên không dấu
+func saveKnowledgeFile(fh *multipart.FileHeader) (string, error) {
+	f, err := upload.Validate(fh, upload.PolicyFor(upload.ContextKnowledge))
+	if err != nil {
+		return "", err
+	}
+	filename := fmt.Sprintf("knowledge_%d_%s%s", time.Now().UnixNano(), f.SafeName, f.Ext)
+	if _, _, err := putUploadedFile(f, "uploads/knowledge/"+filename); err != nil {
+		return "", err
+	}
+	return "/uploads/knowledge/" + filename, nil
+}
+
+func respondKnowledgeFileError(c *fiber.Ctx, err error) error {
+	var ue *upload.Error
+	if errors.As(err, &ue) {
+		return c.Status(400).JSON(fiber.Map{"message": ue.Message, "code": ue.Code})
+	}
+	return c.Status(500).JSON(fiber.Map{"message": "Không thể lưu file"})
+}
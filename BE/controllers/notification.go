@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"awesomeProject/models"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	notificationPageSize    = 20
+	notificationMaxPageSize = 100
+	// Số ngày giữ thông báo đã đọc, đổi qua NOTIFICATION_RETENTION_DAYS
+	defaultNotificationRetentionDays = 90
+)
+
+// notificationScope giới hạn thông báo của người dùng; staff chỉ thấy thông báo về ticket đang được giao cho mình
+func notificationScope(user models.User) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("notifications.user_id = ? AND notifications.digest_only = ?", user.ID, false)
+		if user.Role == "staff" {
+			db = db.Where("JSON_EXTRACT(notifications.data, '$.ticket_id') IN (SELECT id FROM tickets WHERE assigned_to = ?)", user.ID)
+		}
+		return db
+	}
+}
+
+// GetNotifications - Danh sách thông báo mới nhất trước, phân trang theo cursor.
+// Query: cursor (next_cursor của trang trước), limit, type (nhiều loại cách nhau dấu phẩy),
+// read (true/false), archived (true: chỉ xem thông báo đã lưu trữ)
+func GetNotifications(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	limit := c.QueryInt("limit", notificationPageSize)
+	if limit < 1 || limit > notificationMaxPageSize {
+		limit = notificationPageSize
+	}
+	query := models.DB.Model(&models.Notification{}).Scopes(notificationScope(user))
+	if c.Query("archived") == "true" {
+		query = query.Where("archived_at IS NOT NULL")
+	} else {
+		query = query.Where("archived_at IS NULL")
+	}
+	if types := c.Query("type"); types != "" {
+		query = query.Where("type IN ?", strings.Split(types, ","))
+	}
+	switch c.Query("read") {
+	case "true":
+		query = query.Where("is_read = ?", true)
+	case "false":
+		query = query.Where("is_read = ?", false)
+	}
+	if cursor := c.QueryInt("cursor"); cursor > 0 {
+		query = query.Where("id < ?", cursor)
+	}
+	notifs := []models.Notification{}
+	if err := query.Order("id DESC").Limit(limit + 1).Find(&notifs).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Không lấy được danh sách thông báo"})
+	}
+	var nextCursor *uint
+	if len(notifs) > limit {
+		notifs = notifs[:limit]
+		nextCursor = &notifs[limit-1].ID
+	}
+	return c.JSON(fiber.Map{"notifications": notifs, "next_cursor": nextCursor})
+}
+
+// GetUnreadNotificationCount - Số thông báo chưa đọc (không tính thông báo đã lưu trữ), tổng và theo loại
+func GetUnreadNotificationCount(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	var rows []struct {
+		Type  string
+		Count int64
+	}
+	if err := models.DB.Model(&models.Notification{}).Scopes(notificationScope(user)).
+		Where("is_read = ? AND archived_at IS NULL", false).
+		Select("type, COUNT(*) AS count").Group("type").Scan(&rows).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Không đếm được thông báo"})
+	}
+	var total int64
+	byType := fiber.Map{}
+	for _, r := range rows {
+		total += r.Count
+		byType[r.Type] = r.Count
+	}
+	return c.JSON(fiber.Map{"unread": total, "by_type": byType})
+}
+
+// ReadNotification - Đánh dấu đã đọc một thông báo
+func ReadNotification(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	var notif models.Notification
+	if err := models.DB.Scopes(notificationScope(user)).First(&notif, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy thông báo"})
+	}
+	if err := models.DB.Model(&notif).Update("is_read", true).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Không thể cập nhật thông báo"})
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// DeleteNotification - Xóa một thông báo
+func DeleteNotification(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	var notif models.Notification
+	if err := models.DB.Scopes(notificationScope(user)).First(&notif, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy thông báo"})
+	}
+	if err := models.DB.Delete(&notif).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Không thể xóa thông báo"})
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// bulkNotificationQuery đọc body {"ids": [...]} hoặc {"all": true} và trả về truy vấn các thông báo được chọn
+func bulkNotificationQuery(c *fiber.Ctx) (*gorm.DB, bool) {
+	user := c.Locals("user").(models.User)
+	var input struct {
+		IDs []uint `json:"ids"`
+		All bool   `json:"all"`
+	}
+	if err := c.BodyParser(&input); err != nil || (!input.All && len(input.IDs) == 0) {
+		return nil, false
+	}
+	query := models.DB.Model(&models.Notification{}).Scopes(notificationScope(user))
+	if !input.All {
+		query = query.Where("id IN ?", input.IDs)
+	}
+	return query, true
+}
+
+// ReadNotifications - Đánh dấu đã đọc các thông báo được chọn hoặc tất cả
+func ReadNotifications(c *fiber.Ctx) error {
+	query, ok := bulkNotificationQuery(c)
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "Chưa chọn thông báo"})
+	}
+	result := query.Where("is_read = ?", false).Update("is_read", true)
+	if result.Error != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Không thể cập nhật thông báo"})
+	}
+	return c.JSON(fiber.Map{"success": true, "updated": result.RowsAffected})
+}
+
+// ArchiveNotifications - Lưu trữ các thông báo được chọn hoặc tất cả: ẩn khỏi hộp thông báo nhưng vẫn xem lại được
+func ArchiveNotifications(c *fiber.Ctx) error {
+	query, ok := bulkNotificationQuery(c)
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "Chưa chọn thông báo"})
+	}
+	result := query.Where("archived_at IS NULL").Update("archived_at", time.Now())
+	if result.Error != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Không thể lưu trữ thông báo"})
+	}
+	return c.JSON(fiber.Map{"success": true, "updated": result.RowsAffected})
+}
+
+// UnarchiveNotifications - Đưa thông báo đã lưu trữ trở lại hộp thông báo
+func UnarchiveNotifications(c *fiber.Ctx) error {
+	query, ok := bulkNotificationQuery(c)
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "Chưa chọn thông báo"})
+	}
+	result := query.Where("archived_at IS NOT NULL").Update("archived_at", nil)
+	if result.Error != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Không thể cập nhật thông báo"})
+	}
+	return c.JSON(fiber.Map{"success": true, "updated": result.RowsAffected})
+}
+
+// DeleteNotifications - Xóa các thông báo được chọn hoặc tất cả
+func DeleteNotifications(c *fiber.Ctx) error {
+	query, ok := bulkNotificationQuery(c)
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "Chưa chọn thông báo"})
+	}
+	result := query.Delete(&models.Notification{})
+	if result.Error != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Không thể xóa thông báo"})
+	}
+	return c.JSON(fiber.Map{"success": true, "deleted": result.RowsAffected})
+}
+
+// PurgeOldNotifications xóa thông báo đã đọc (hoặc chỉ dùng cho email tóm tắt) quá thời hạn lưu giữ - chạy nền
+func PurgeOldNotifications() {
+	days := defaultNotificationRetentionDays
+	if v, err := strconv.Atoi(os.Getenv("NOTIFICATION_RETENTION_DAYS")); err == nil && v > 0 {
+		days = v
+	}
+	result := models.DB.Where("(is_read = ? OR digest_only = ?) AND created_at < ?", true, true, time.Now().AddDate(0, 0, -days)).
+		Delete(&models.Notification{})
+	if result.Error != nil {
+		log.Printf("[NOTIFICATION] Xóa thông báo cũ thất bại: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("[NOTIFICATION] Đã xóa %d thông báo đã đọc quá %d ngày", result.RowsAffected, days)
+	}
+}
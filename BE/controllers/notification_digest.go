@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"awesomeProject/emailtemplate"
+	"awesomeProject/models"
+	"log"
+	"time"
+	_ "time/tzdata" // dữ liệu múi giờ cho máy chủ không cài sẵn tzdata
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Số thông báo tối đa liệt kê trong một email tóm tắt
+const digestMaxItems = 200
+
+// digestSetting trả về cài đặt tóm tắt của người dùng, chưa có thì trả về mặc định (tắt)
+func digestSetting(userID uint) models.DigestSetting {
+	setting := models.DigestSetting{UserID: userID, Frequency: models.DigestOff, Hour: 8, Timezone: models.DefaultDigestTimezone}
+	models.DB.Where("user_id = ?", userID).First(&setting)
+	return setting
+}
+
+// digestEnabled cho biết người dùng đang nhận email tóm tắt thay cho email từng thông báo
+func digestEnabled(userID uint) bool {
+	var count int64
+	models.DB.Model(&models.DigestSetting{}).Where("user_id = ? AND frequency <> ?", userID, models.DigestOff).Count(&count)
+	return count > 0
+}
+
+func digestLocation(s models.DigestSetting) *time.Location {
+	if loc, err := time.LoadLocation(s.Timezone); err == nil {
+		return loc
+	}
+	return time.Local
+}
+
+// digestSlot trả về mốc gửi gần nhất (không sau now) theo lịch tóm tắt và múi giờ của người dùng
+func digestSlot(s models.DigestSetting, now time.Time) time.Time {
+	loc := digestLocation(s)
+	local := now.In(loc)
+	if s.Frequency == models.DigestHourly {
+		return time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), 0, 0, 0, loc)
+	}
+	slot := time.Date(local.Year(), local.Month(), local.Day(), s.Hour, 0, 0, 0, loc)
+	if slot.After(local) {
+		slot = slot.AddDate(0, 0, -1)
+	}
+	return slot
+}
+
+// digestNotificationTypes trả về các loại thông báo người dùng bật nhận qua email
+func digestNotificationTypes(user models.User) []string {
+	var types []string
+	for _, info := range models.NotificationTypes {
+		if _, email := notificationChannels(user, info.Type); email {
+			types = append(types, info.Type)
+		}
+	}
+	return types
+}
+
+// SendNotificationDigests gửi email tóm tắt cho người dùng đã đến lịch - chạy nền
+func SendNotificationDigests() {
+	now := time.Now()
+	var settings []models.DigestSetting
+	models.DB.Where("frequency IN ?", []string{models.DigestHourly, models.DigestDaily}).Find(&settings)
+	for _, s := range settings {
+		slot := digestSlot(s, now)
+		if s.LastSentAt != nil && !s.LastSentAt.Before(slot) {
+			continue
+		}
+		if err := sendNotificationDigest(s, slot); err != nil {
+			// Để lần chạy sau gửi lại
+			log.Printf("[DIGEST] Không gửi được email tóm tắt cho user %d: %v", s.UserID, err)
+			continue
+		}
+		models.DB.Model(&s).Update("last_sent_at", slot)
+	}
+}
+
+// sendNotificationDigest gộp thông báo chưa đọc từ lần tóm tắt trước đến mốc until thành một email, nhóm theo ticket
+func sendNotificationDigest(s models.DigestSetting, until time.Time) error {
+	var user models.User
+	if err := models.DB.First(&user, s.UserID).Error; err != nil || user.Email == "" || !user.IsVerified {
+		return nil
+	}
+	var from time.Time
+	if s.LastSentAt != nil {
+		from = *s.LastSentAt
+	} else if s.Frequency == models.DigestHourly {
+		from = until.Add(-time.Hour)
+	} else {
+		from = until.AddDate(0, 0, -1)
+	}
+	// Chỉ gộp các loại thông báo người dùng chọn nhận qua email
+	types := digestNotificationTypes(user)
+	if len(types) == 0 {
+		return nil
+	}
+	query := models.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND is_read = ? AND type IN ? AND created_at > ? AND created_at <= ?", user.ID, false, types, from, until)
+	var total int64
+	query.Count(&total)
+	if total == 0 {
+		return nil
+	}
+	var notifs []models.Notification
+	if err := query.Order("created_at, id").Limit(digestMaxItems).Find(&notifs).Error; err != nil {
+		return err
+	}
+
+	// Nhóm theo ticket, giữ thứ tự thông báo đầu tiên của mỗi ticket
+	loc := digestLocation(s)
+	var order []uint
+	items := map[uint][]map[string]interface{}{}
+	for _, n := range notifs {
+		ticketID := n.Data.TicketID
+		if _, ok := items[ticketID]; !ok {
+			order = append(order, ticketID)
+		}
+		items[ticketID] = append(items[ticketID], map[string]interface{}{
+			"Time":    n.CreatedAt.In(loc).Format("15:04 02/01"),
+			"Content": n.Content,
+		})
+	}
+	var tickets []models.Ticket
+	models.DB.Unscoped().Where("id IN ?", order).Find(&tickets)
+	titles := map[uint]string{}
+	for _, t := range tickets {
+		titles[t.ID] = t.Title
+	}
+	var groups []map[string]interface{}
+	for _, id := range order {
+		groups = append(groups, map[string]interface{}{"TicketID": id, "Title": titles[id], "Items": items[id]})
+	}
+
+	msg, err := renderEmail(emailtemplate.NotificationDigest, user.Language, map[string]interface{}{
+		"Name":      user.Name,
+		"Count":     total,
+		"Frequency": s.Frequency,
+		"Tickets":   groups,
+	})
+	if err != nil {
+		return err
+	}
+	return queueEmail(outgoingEmail{Kind: models.EmailKindDigest, To: user.Email, Subject: msg.Subject, Body: msg.HTML})
+}
+
+// GetMyDigestSetting - Cài đặt email tóm tắt thông báo
+func GetMyDigestSetting(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	return c.JSON(fiber.Map{"success": true, "digest": digestSetting(user.ID)})
+}
+
+// UpdateMyDigestSetting - Bật/tắt email tóm tắt (off, hourly, daily), giờ gửi hằng ngày và múi giờ
+func UpdateMyDigestSetting(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	var input struct {
+		Frequency string `json:"frequency"`
+		Hour      *int   `json:"hour"`
+		Timezone  string `json:"timezone"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Dữ liệu cập nhật không hợp lệ.",
+			"success": false,
+		})
+	}
+	setting := digestSetting(user.ID)
+	wasOff := setting.Frequency == models.DigestOff
+	if input.Frequency != "" {
+		switch input.Frequency {
+		case models.DigestOff, models.DigestHourly, models.DigestDaily:
+			setting.Frequency = input.Frequency
+		default:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "Tần suất tóm tắt phải là off, hourly hoặc daily.",
+				"success": false,
+			})
+		}
+	}
+	if input.Hour != nil {
+		if *input.Hour < 0 || *input.Hour > 23 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "Giờ gửi tóm tắt phải từ 0 đến 23.",
+				"success": false,
+			})
+		}
+		setting.Hour = *input.Hour
+	}
+	if input.Timezone != "" {
+		if _, err := time.LoadLocation(input.Timezone); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "Múi giờ không hợp lệ.",
+				"success": false,
+			})
+		}
+		setting.Timezone = input.Timezone
+	}
+	// Vừa bật thì chỉ tóm tắt thông báo từ bây giờ, không gửi lại thông báo cũ
+	if wasOff && setting.Frequency != models.DigestOff {
+		now := time.Now()
+		setting.LastSentAt = &now
+	}
+	if err := models.DB.Save(&setting).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Không thể cập nhật cài đặt tóm tắt.",
+			"success": false,
+		})
+	}
+	return c.JSON(fiber.Map{"success": true, "message": "Cập nhật cài đặt tóm tắt thành công!", "digest": setting})
+}
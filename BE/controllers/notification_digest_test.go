@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"awesomeProject/models"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestUpdateMyDigestSettingFirstSaveHour(t *testing.T) {
+	useDryRunDB(t)
+	tests := []struct {
+		name string
+		body string
+		want float64
+	}{
+		{"lần lưu đầu chọn nửa đêm", `{"frequency":"daily","hour":0}`, 0},
+		{"lần lưu đầu chọn 7 giờ", `{"frequency":"daily","hour":7}`, 7},
+		{"không gửi giờ thì dùng mặc định 8 giờ", `{"frequency":"daily"}`, 8},
+	}
+	for _, tt := range tests {
+		app := fiber.New()
+		app.Put("/", func(c *fiber.Ctx) error {
+			user := models.User{}
+			user.ID = 1
+			c.Locals("user", user)
+			return c.Next()
+		}, UpdateMyDigestSetting)
+		req := httptest.NewRequest("PUT", "/", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s: app.Test: %v", tt.name, err)
+		}
+		var out struct {
+			Digest map[string]any `json:"digest"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			t.Fatalf("%s: decode: %v", tt.name, err)
+		}
+		resp.Body.Close()
+		if got, _ := out.Digest["hour"].(float64); got != tt.want {
+			t.Errorf("%s: hour = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"awesomeProject/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// notificationTypesForRole trả về các loại thông báo mà vai trò này có thể nhận
+func notificationTypesForRole(role string) []models.NotificationTypeInfo {
+	var types []models.NotificationTypeInfo
+	for _, info := range models.NotificationTypes {
+		for _, r := range info.Roles {
+			if r == role {
+				types = append(types, info)
+				break
+			}
+		}
+	}
+	return types
+}
+
+// GetMyNotificationPreferences - Kênh nhận (trong ứng dụng, email) của từng loại thông báo
+func GetMyNotificationPreferences(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	var prefs []models.NotificationPreference
+	models.DB.Where("user_id = ?", user.ID).Find(&prefs)
+	saved := map[string]models.NotificationPreference{}
+	for _, p := range prefs {
+		saved[p.Type] = p
+	}
+	result := []fiber.Map{}
+	for _, info := range notificationTypesForRole(user.Role) {
+		inApp, email := models.DefaultNotificationPreference(info.Type, user.Role)
+		if p, ok := saved[info.Type]; ok {
+			inApp, email = p.InApp, p.Email
+		}
+		result = append(result, fiber.Map{
+			"type":        info.Type,
+			"description": info.Description,
+			"in_app":      inApp,
+			"email":       email,
+		})
+	}
+	return c.JSON(fiber.Map{"success": true, "preferences": result})
+}
+
+// UpdateMyNotificationPreferences - Cập nhật kênh nhận thông báo; hai kênh cùng tắt là không nhận loại thông báo đó
+func UpdateMyNotificationPreferences(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	type PreferenceInput struct {
+		Type  string `json:"type"`
+		InApp bool   `json:"in_app"`
+		Email bool   `json:"email"`
+	}
+	var input struct {
+		Preferences []PreferenceInput `json:"preferences"`
+	}
+	if err := c.BodyParser(&input); err != nil || len(input.Preferences) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Dữ liệu cập nhật không hợp lệ.",
+			"success": false,
+		})
+	}
+	allowed := map[string]bool{}
+	for _, info := range notificationTypesForRole(user.Role) {
+		allowed[info.Type] = true
+	}
+	for _, p := range input.Preferences {
+		if !allowed[p.Type] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "Loại thông báo không hợp lệ: " + p.Type,
+				"success": false,
+			})
+		}
+	}
+	for _, p := range input.Preferences {
+		var pref models.NotificationPreference
+		models.DB.Where("user_id = ? AND type = ?", user.ID, p.Type).First(&pref)
+		pref.UserID = user.ID
+		pref.Type = p.Type
+		pref.InApp = p.InApp
+		pref.Email = p.Email
+		if err := models.DB.Save(&pref).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"message": "Không thể cập nhật cài đặt thông báo.",
+				"success": false,
+			})
+		}
+	}
+	return GetMyNotificationPreferences(c)
+}
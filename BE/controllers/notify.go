@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"awesomeProject/emailtemplate"
+	"awesomeProject/models"
+	"awesomeProject/realtime"
+	"fmt"
+)
+
+// notice là một thông báo về ticket, gửi cho từng người nhận qua các kênh họ đã chọn
+type notice struct {
+	Type    string
+	Ticket  models.Ticket
+	Content string // nội dung thông báo trong ứng dụng; rỗng thì chỉ gửi email
+	Data    models.NotificationData
+	// Mẫu email riêng và dữ liệu theo người nhận (tên, ngôn ngữ); không có thì dùng mẫu thông báo chung
+	EmailKey  string
+	EmailData func(to models.User) map[string]interface{}
+}
+
+// notificationChannels trả về kênh nhận một loại thông báo của người dùng
+func notificationChannels(user models.User, notifType string) (inApp, email bool) {
+	var pref models.NotificationPreference
+	if err := models.DB.Where("user_id = ? AND type = ?", user.ID, notifType).First(&pref).Error; err == nil {
+		return pref.InApp, pref.Email
+	}
+	return models.DefaultNotificationPreference(notifType, user.Role)
+}
+
+// canEmailUser: chỉ gửi email đến tài khoản đã xác thực; khách hàng tạo ticket qua email
+// chưa xác thực tài khoản nhưng vẫn nhận email về ticket của mình
+func canEmailUser(user models.User, ticket models.Ticket) bool {
+	if user.Email == "" {
+		return false
+	}
+	return user.IsVerified || (user.ID == ticket.UserID && ticketCreatedByEmail(ticket.ID))
+}
+
+// notify gửi thông báo cho một người dùng theo lựa chọn kênh nhận của họ
+func notify(to models.User, n notice) {
+	inApp, email := notificationChannels(to, n.Type)
+	// Chế độ tóm tắt: thông báo muốn nhận qua email được lưu lại và gộp vào email tóm tắt theo lịch;
+	// người nhận tắt thông báo trong ứng dụng cho loại này thì bản ghi chỉ dùng cho email tóm tắt.
+	// Email không có nội dung thông báo (vd xác nhận ticket vừa tạo) vẫn gửi ngay.
+	digestOnly := false
+	if email && n.Content != "" && digestEnabled(to.ID) {
+		digestOnly, email = !inApp, false
+	}
+	if (inApp || digestOnly) && n.Content != "" {
+		notif := models.Notification{UserID: to.ID, Type: n.Type, Content: n.Content, Data: n.Data, DigestOnly: digestOnly}
+		// Đẩy thông báo mới đến các kết nối realtime của người nhận
+		if err := models.DB.Create(&notif).Error; err == nil && !digestOnly {
+			realtime.Publish(realtime.UserTopic(to.ID), "notification", notif)
+		}
+	}
+	if !email || !canEmailUser(to, n.Ticket) {
+		return
+	}
+	key := n.EmailKey
+	var data map[string]interface{}
+	if key != "" && n.EmailData != nil {
+		data = n.EmailData(to)
+	} else if n.Content != "" {
+		key = emailtemplate.Notification
+		data = ticketEmailData(n.Ticket, to.Name)
+		data["Content"] = n.Content
+	} else {
+		return
+	}
+	if err := sendTicketEmail(n.Ticket, to, key, data); err != nil {
+		fmt.Printf("[MAIL ERROR] To: %s | Template: %s | Error: %v\n", to.Email, key, err)
+	}
+}
+
+// notifyUsers gửi cùng một thông báo cho nhiều người, bỏ qua người thực hiện hành động
+func notifyUsers(recipients []models.User, actorID uint, n notice) {
+	for _, r := range recipients {
+		if r.ID != actorID {
+			notify(r, n)
+		}
+	}
+}
+
+// adminUsers trả về tất cả admin (không tính tài khoản dịch vụ)
+func adminUsers() []models.User {
+	var admins []models.User
+	models.DB.Where("role = ? AND is_service_account = ?", "admin", false).Find(&admins)
+	return admins
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"awesomeProject/emailtemplate"
 	"awesomeProject/models"
 	"bytes"
 	"encoding/base64"
@@ -38,6 +39,7 @@ func GetMyProfile(c *fiber.Ctx) error {
 			"role":               user.Role,
 			"is_verified":        user.IsVerified,
 			"two_factor_enabled": user.TwoFactorEnabled,
+			"language":           user.Language,
 			"created_at":         user.CreatedAt,
 			"updated_at":         user.UpdatedAt,
 		},
@@ -55,9 +57,10 @@ func UpdateMyProfile(c *fiber.Ctx) error {
 	}
 
 	type UpdateProfileInput struct {
-		Name  string `json:"name"`
-		Phone string `json:"phone"`
-		Email string `json:"email"` // Cho phép cập nhật email, nhưng cần xử lý unique
+		Name     string `json:"name"`
+		Phone    string `json:"phone"`
+		Email    string `json:"email"`    // Cho phép cập nhật email, nhưng cần xử lý unique
+		Language string `json:"language"` // Ngôn ngữ nhận email, bỏ trống thì giữ nguyên
 	}
 
 	var input UpdateProfileInput
@@ -71,6 +74,15 @@ func UpdateMyProfile(c *fiber.Ctx) error {
 	// Cập nhật thông tin
 	user.Name = strings.TrimSpace(input.Name)
 	user.Phone = input.Phone
+	if input.Language != "" {
+		if !emailtemplate.IsValidLanguage(input.Language) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "Ngôn ngữ không được hỗ trợ.",
+				"success": false,
+			})
+		}
+		user.Language = input.Language
+	}
 
 	// Xử lý cập nhật email (nếu có và khác email cũ)
 	if strings.ToLower(input.Email) != user.Email {
@@ -105,6 +117,7 @@ func UpdateMyProfile(c *fiber.Ctx) error {
 			"role":               user.Role,
 			"is_verified":        user.IsVerified,
 			"two_factor_enabled": user.TwoFactorEnabled,
+			"language":           user.Language,
 			"created_at":         user.CreatedAt,
 			"updated_at":         user.UpdatedAt,
 		},
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/sla"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// addSLAFields bổ sung thông tin SLA vào response của ticket
+func addSLAFields(m fiber.Map, t models.Ticket, now time.Time) {
+	st := sla.Evaluate(t, now)
+	m["sla_due_at"] = st.DueAt
+	m["sla_breached"] = st.Breached
+	m["sla_paused"] = st.Paused
+	m["sla_remaining_seconds"] = st.RemainingSeconds
+	m["first_response_due_at"] = t.FirstResponseDueAt
+	m["resolution_due_at"] = t.ResolutionDueAt
+}
+
+type slaPolicyInput struct {
+	Name                 string `json:"name"`
+	PriorityID           uint   `json:"priority_id"`
+	CategoryID           *uint  `json:"category_id"`
+	FirstResponseMinutes int    `json:"first_response_minutes"`
+	ResolutionMinutes    int    `json:"resolution_minutes"`
+	IsActive             *bool  `json:"is_active"`
+}
+
+func (in slaPolicyInput) validate() string {
+	if in.Name == "" || in.PriorityID == 0 {
+		return "Tên và mức độ ưu tiên là bắt buộc"
+	}
+	if in.FirstResponseMinutes <= 0 || in.ResolutionMinutes <= 0 {
+		return "Thời hạn phản hồi và xử lý phải lớn hơn 0"
+	}
+	if in.FirstResponseMinutes > in.ResolutionMinutes {
+		return "Thời hạn phản hồi không được lớn hơn thời hạn xử lý"
+	}
+	if err := models.DB.First(&models.TicketPriority{}, in.PriorityID).Error; err != nil {
+		return "Mức độ ưu tiên không tồn tại"
+	}
+	if in.CategoryID != nil && *in.CategoryID > 0 {
+		if err := models.DB.First(&models.TicketCategory{}, *in.CategoryID).Error; err != nil {
+			return "Loại ticket không tồn tại"
+		}
+	}
+	return ""
+}
+
+// ----------- SLA POLICY -----------
+func GetAllSLAPolicies(c *fiber.Ctx) error {
+	var items []models.SLAPolicy
+	models.DB.Preload("Priority").Preload("Category").Order("id").Find(&items)
+	return c.JSON(fiber.Map{"data": items})
+}
+
+func CreateSLAPolicy(c *fiber.Ctx) error {
+	var input slaPolicyInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": "Dữ liệu không hợp lệ"})
+	}
+	if msg := input.validate(); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"message": msg})
+	}
+	item := models.SLAPolicy{
+		Name:                 input.Name,
+		PriorityID:           input.PriorityID,
+		CategoryID:           normalizeOptionalID(input.CategoryID),
+		FirstResponseMinutes: input.FirstResponseMinutes,
+		ResolutionMinutes:    input.ResolutionMinutes,
+		IsActive:             input.IsActive == nil || *input.IsActive,
+	}
+	if err := models.DB.Create(&item).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể tạo", "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "Tạo thành công", "item": item})
+}
+
+func UpdateSLAPolicy(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var item models.SLAPolicy
+	if err := models.DB.First(&item, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy"})
+	}
+	var input slaPolicyInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": "Dữ liệu không hợp lệ"})
+	}
+	if msg := input.validate(); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"message": msg})
+	}
+	item.Name = input.Name
+	item.PriorityID = input.PriorityID
+	item.CategoryID = normalizeOptionalID(input.CategoryID)
+	item.FirstResponseMinutes = input.FirstResponseMinutes
+	item.ResolutionMinutes = input.ResolutionMinutes
+	if input.IsActive != nil {
+		item.IsActive = *input.IsActive
+	}
+	if err := models.DB.Save(&item).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể cập nhật", "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "Cập nhật thành công", "item": item})
+}
+
+func DeleteSLAPolicy(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if err := models.DB.Delete(&models.SLAPolicy{}, id).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể xóa"})
+	}
+	return c.JSON(fiber.Map{"message": "Đã xóa"})
+}
+
+// normalizeOptionalID coi ID bằng 0 là không chọn
+func normalizeOptionalID(id *uint) *uint {
+	if id == nil || *id == 0 {
+		return nil
+	}
+	return id
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"awesomeProject/models"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// useDryRunDB thay models.DB bằng kết nối DryRun: GORM dựng câu lệnh (kể cả gán giá trị mặc định vào struct) nhưng không chạm tới MySQL
+func useDryRunDB(t *testing.T) {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "test:test@tcp(127.0.0.1:1)/test?parseTime=true",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+	prev := models.DB
+	models.DB = db
+	t.Cleanup(func() { models.DB = prev })
+}
+
+// postJSON gọi handler qua một app Fiber tạm và giải mã phản hồi JSON
+func postJSON(t *testing.T, handler fiber.Handler, body string) (int, map[string]any) {
+	t.Helper()
+	app := fiber.New()
+	app.Post("/", handler)
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+	var out map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return resp.StatusCode, out
+}
+
+func TestCreateSLAPolicyIsActive(t *testing.T) {
+	useDryRunDB(t)
+	tests := []struct {
+		name   string
+		active string
+		want   bool
+	}{
+		{"không gửi is_active thì mặc định bật", "", true},
+		{"bật", `,"is_active":true`, true},
+		{"tạo ở trạng thái tắt", `,"is_active":false`, false},
+	}
+	for _, tt := range tests {
+		body := `{"name":"P1","priority_id":1,"first_response_minutes":60,"resolution_minutes":480` + tt.active + `}`
+		status, out := postJSON(t, CreateSLAPolicy, body)
+		if status != 200 {
+			t.Fatalf("%s: status = %d, body = %v", tt.name, status, out)
+		}
+		item, _ := out["item"].(map[string]any)
+		if got, _ := item["is_active"].(bool); got != tt.want {
+			t.Errorf("%s: is_active = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"awesomeProject/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// ClaimTicket - Staff nhận một ticket chưa có người phụ trách trong hàng đợi team của mình
+func ClaimTicket(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	ticketID := c.Params("id")
+	var ticket models.Ticket
+	if err := models.DB.First(&ticket, ticketID).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy ticket"})
+	}
+	if user.Role == "staff" && (ticket.TeamID == nil || !isTeamMember(user.ID, *ticket.TeamID)) {
+		return c.Status(403).JSON(fiber.Map{"error": "Ticket không thuộc hàng đợi của team bạn"})
+	}
+	before := ticket
+	// Chỉ nhận được khi ticket vẫn chưa có ai phụ trách, tránh hai người cùng nhận
+	res := models.DB.Model(&models.Ticket{}).
+		Where("id = ? AND assigned_to IS NULL", ticket.ID).
+		Update("assigned_to", user.ID)
+	if res.Error != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Không thể nhận ticket"})
+	}
+	if res.RowsAffected == 0 {
+		return c.Status(409).JSON(fiber.Map{"error": "Ticket đã được phân công cho người khác"})
+	}
+	ticket.AssignedTo = &user.ID
+	recordTicketChanges(before, ticket, &user.ID)
+	return c.JSON(fiber.Map{"success": true, "assigned_to": user.ID})
+}
+
+// GetMyTeams - Danh sách team mà người dùng hiện tại là thành viên
+func GetMyTeams(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	var teams []models.Team
+	models.DB.Where("id IN (SELECT team_id FROM team_members WHERE user_id = ?)", user.ID).Order("name").Find(&teams)
+	return c.JSON(fiber.Map{"data": teams})
+}
+
+type teamRouteInput struct {
+	CategoryID    *uint `json:"category_id"`
+	ProductTypeID *uint `json:"product_type_id"`
+}
+
+type teamInput struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	MemberIDs   []uint           `json:"member_ids"`
+	Routes      []teamRouteInput `json:"routes"`
+}
+
+func (in teamInput) validate() string {
+	if in.Name == "" {
+		return "Tên không hợp lệ"
+	}
+	if len(in.MemberIDs) > 0 {
+		var count int64
+		models.AssignableStaff(models.DB).Model(&models.User{}).Where("id IN ?", in.MemberIDs).Count(&count)
+		if int(count) != len(uniqueIDs(in.MemberIDs)) {
+			return "Thành viên phải là admin hoặc staff"
+		}
+	}
+	for _, r := range in.Routes {
+		if r.CategoryID != nil && *r.CategoryID > 0 {
+			if err := models.DB.First(&models.TicketCategory{}, *r.CategoryID).Error; err != nil {
+				return "Loại ticket không tồn tại"
+			}
+		}
+		if r.ProductTypeID != nil && *r.ProductTypeID > 0 {
+			if err := models.DB.First(&models.TicketProductType{}, *r.ProductTypeID).Error; err != nil {
+				return "Loại sản phẩm không tồn tại"
+			}
+		}
+	}
+	return ""
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	out := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// saveTeam lưu team cùng danh sách thành viên và luật định tuyến (thay thế toàn bộ)
+func saveTeam(team *models.Team, in teamInput) error {
+	return models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(team).Error; err != nil {
+			return err
+		}
+		var members []models.User
+		if ids := uniqueIDs(in.MemberIDs); len(ids) > 0 {
+			if err := tx.Where("id IN ?", ids).Find(&members).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(team).Association("Members").Replace(members); err != nil {
+			return err
+		}
+		if err := tx.Where("team_id = ?", team.ID).Delete(&models.TeamRoute{}).Error; err != nil {
+			return err
+		}
+		team.Routes = nil
+		for _, r := range in.Routes {
+			route := models.TeamRoute{
+				TeamID:        team.ID,
+				CategoryID:    normalizeOptionalID(r.CategoryID),
+				ProductTypeID: normalizeOptionalID(r.ProductTypeID),
+			}
+			if err := tx.Create(&route).Error; err != nil {
+				return err
+			}
+			team.Routes = append(team.Routes, route)
+		}
+		return nil
+	})
+}
+
+func teamResponse(team models.Team) fiber.Map {
+	members := make([]fiber.Map, 0, len(team.Members))
+	for _, m := range team.Members {
+		members = append(members, fiber.Map{"id": m.ID, "name": m.Name, "email": m.Email, "role": m.Role})
+	}
+	return fiber.Map{
+		"id":          team.ID,
+		"name":        team.Name,
+		"description": team.Description,
+		"members":     members,
+		"routes":      team.Routes,
+		"created_at":  team.CreatedAt,
+		"updated_at":  team.UpdatedAt,
+	}
+}
+
+// ----------- TEAM -----------
+func GetAllTeams(c *fiber.Ctx) error {
+	var teams []models.Team
+	models.DB.Preload("Members").Preload("Routes").Order("name").Find(&teams)
+	data := make([]fiber.Map, 0, len(teams))
+	for _, t := range teams {
+		data = append(data, teamResponse(t))
+	}
+	return c.JSON(fiber.Map{"data": data})
+}
+
+func CreateTeam(c *fiber.Ctx) error {
+	var input teamInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": "Dữ liệu không hợp lệ"})
+	}
+	if msg := input.validate(); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"message": msg})
+	}
+	item := models.Team{Name: input.Name, Description: input.Description}
+	if err := saveTeam(&item, input); err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể tạo", "error": err.Error()})
+	}
+	models.DB.Preload("Members").Preload("Routes").First(&item, item.ID)
+	return c.JSON(fiber.Map{"message": "Tạo thành công", "item": teamResponse(item)})
+}
+
+func UpdateTeam(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var item models.Team
+	if err := models.DB.First(&item, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy"})
+	}
+	var input teamInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": "Dữ liệu không hợp lệ"})
+	}
+	if msg := input.validate(); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"message": msg})
+	}
+	item.Name = input.Name
+	item.Description = input.Description
+	if err := saveTeam(&item, input); err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể cập nhật", "error": err.Error()})
+	}
+	models.DB.Preload("Members").Preload("Routes").First(&item, item.ID)
+	return c.JSON(fiber.Map{"message": "Cập nhật thành công", "item": teamResponse(item)})
+}
+
+func DeleteTeam(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var item models.Team
+	if err := models.DB.First(&item, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy"})
+	}
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&item).Association("Members").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Where("team_id = ?", item.ID).Delete(&models.TeamRoute{}).Error; err != nil {
+			return err
+		}
+		// Ticket trong hàng đợi quay về trạng thái chưa thuộc team nào
+		if err := tx.Model(&models.Ticket{}).Where("team_id = ?", item.ID).Update("team_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&item).Error
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể xóa"})
+	}
+	return c.JSON(fiber.Map{"message": "Đã xóa"})
+}
@@ -1,40 +1,24 @@
 package controllers
 
 import (
+	"awesomeProject/assignment"
+	"awesomeProject/calendar"
+	"awesomeProject/chatops"
+	"awesomeProject/emailtemplate"
 	"awesomeProject/models"
-	"encoding/json"
+	"awesomeProject/realtime"
+	"awesomeProject/sla"
+	"awesomeProject/upload"
+	"awesomeProject/webhook"
+	"awesomeProject/workflow"
 	"fmt"
-	"os"
-	"path/filepath"
+	"log"
 	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"gopkg.in/gomail.v2"
 )
 
-func sendTicketEmail(to, subject, body string) error {
-	smtpHost := os.Getenv("SMTP_HOST")
-	smtpPort := os.Getenv("SMTP_PORT")
-	smtpUser := os.Getenv("SMTP_USER")
-	smtpPass := os.Getenv("SMTP_PASS")
-	from := os.Getenv("SMTP_FROM")
-	if from == "" {
-		from = smtpUser
-	}
-	port := 587
-	if smtpPort != "" {
-		fmt.Sscanf(smtpPort, "%d", &port)
-	}
-	m := gomail.NewMessage()
-	m.SetHeader("From", from)
-	m.SetHeader("To", to)
-	m.SetHeader("Subject", subject)
-	m.SetBody("text/html", body)
-	d := gomail.NewDialer(smtpHost, port, smtpUser, smtpPass)
-	return d.DialAndSend(m)
-}
-
 func CreateTicket(c *fiber.Ctx) error {
 	user := c.Locals("user").(models.User)
 
@@ -54,21 +38,17 @@ func CreateTicket(c *fiber.Ctx) error {
 	}
 
 	// Xử lý file đính kèm (nếu có)
+	files, err := uploadedFiles(c, upload.ContextTicket)
+	if err != nil {
+		return respondUploadError(c, err)
+	}
+	uploads, err := storeUploads(files, "uploads/tickets", "ticket")
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Lưu file thất bại"})
+	}
 	var attachmentPath string
-	fileHeader, err := c.FormFile("attachment")
-	if err == nil && fileHeader != nil {
-		// Tạo thư mục uploads/tickets nếu chưa có
-		uploadDir := "uploads/tickets"
-		os.MkdirAll(uploadDir, os.ModePerm)
-		// Đặt tên file duy nhất
-		timestamp := strconv.FormatInt(time.Now().UnixNano(), 10)
-		ext := filepath.Ext(fileHeader.Filename)
-		filename := "ticket_" + timestamp + ext
-		filePath := filepath.Join(uploadDir, filename)
-		if err := c.SaveFile(fileHeader, filePath); err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Lưu file thất bại"})
-		}
-		attachmentPath = "/uploads/tickets/" + filename
+	if len(uploads) > 0 {
+		attachmentPath = uploads[0].URL()
 	}
 
 	ticket := models.Ticket{
@@ -78,48 +58,109 @@ func CreateTicket(c *fiber.Ctx) error {
 		CategoryID:     uint(categoryID),
 		ProductTypeID:  uint(productTypeID),
 		PriorityID:     uint(priorityID),
-		Status:         "Mới",
+		Status:         workflow.StatusNew,
 		AttachmentPath: attachmentPath,
 	}
 
+	ticket.TeamID = assignment.RouteToTeam(models.DB, ticket.CategoryID, ticket.ProductTypeID)
+	sla.Apply(&ticket, time.Now())
+
 	if err := models.DB.Create(&ticket).Error; err != nil {
+		removeAttachmentFiles(uploads)
 		return c.Status(500).JSON(fiber.Map{"error": "Tạo ticket thất bại"})
 	}
-	// Gửi email xác nhận nếu user đã xác thực
-	if user.IsVerified && user.Email != "" {
-		subject := fmt.Sprintf("[Support] Ticket mới #%d: %s", ticket.ID, ticket.Title)
-		body := fmt.Sprintf("<p>Xin chào %s,</p><p>Bạn đã tạo ticket thành công với tiêu đề: <b>%s</b></p><p><b>Loại ticket:</b> %s<br><b>Loại sản phẩm:</b> %s<br><b>Mức độ ưu tiên:</b> %s</p><p><b>Nội dung:</b> %s</p><p>Chúng tôi sẽ phản hồi sớm nhất có thể.</p>", user.Name, ticket.Title, ticket.Category.Name, ticket.ProductType.Name, ticket.Priority.Name, ticket.Description)
-		go func() {
-			err := sendTicketEmail(user.Email, subject, body)
-			if err != nil {
-				fmt.Printf("[MAIL ERROR] To: %s | Subject: %s | Error: %v\n", user.Email, subject, err)
-			}
-		}()
-	}
-	// Gửi email cho tất cả admin đã xác thực
-	var admins []models.User
-	models.DB.Where("role = ?", "admin").Where("is_verified = ?", true).Find(&admins)
-	for _, admin := range admins {
-		if admin.Email != "" {
-			subject := fmt.Sprintf("[Support] Ticket mới #%d: %s", ticket.ID, ticket.Title)
-			body := fmt.Sprintf("<p>Admin thân mến,</p><p>Khách hàng <b>%s</b> vừa tạo ticket mới: <b>%s</b></p><p><b>Loại ticket:</b> %s<br><b>Loại sản phẩm:</b> %s<br><b>Mức độ ưu tiên:</b> %s</p><p><b>Nội dung:</b> %s</p>", user.Name, ticket.Title, ticket.Category.Name, ticket.ProductType.Name, ticket.Priority.Name, ticket.Description)
-			go func(email, subject, body string) {
-				err := sendTicketEmail(email, subject, body)
-				if err != nil {
-					fmt.Printf("[MAIL ERROR] To: %s | Subject: %s | Error: %v\n", email, subject, err)
-				}
-			}(admin.Email, subject, body)
-		}
-		// Tạo notification cho admin
-		n := models.Notification{
-			UserID:  admin.ID,
-			Type:    "ticket_new",
-			Content: fmt.Sprintf("Ticket mới #%d: %s từ %s", ticket.ID, ticket.Title, user.Name),
-			Data:    fmt.Sprintf(`{"ticket_id":%d,"user_id":%d}`, ticket.ID, user.ID),
+	attachments, err := createAttachments(uploads, models.AttachmentOwnerTicket, ticket.ID, ticket.ID, user.ID)
+	if err != nil {
+		removeAttachmentFiles(uploads)
+		models.DB.Delete(&ticket)
+		return c.Status(500).JSON(fiber.Map{"error": "Lưu file thất bại"})
+	}
+	scanAttachments(attachments, ticket)
+	onTicketCreated(&ticket, user)
+	return c.JSON(fiber.Map{"success": true, "ticket": ticket, "attachments": attachmentsResponse(attachments)})
+}
+
+// recordTicketChanges ghi lịch sử thay đổi của ticket, báo cho các client đang xem ticket
+// và gửi sự kiện đổi trạng thái/phân công qua webhook
+func recordTicketChanges(before, after models.Ticket, actorID *uint) {
+	events, err := models.RecordTicketChanges(models.DB, before, after, actorID)
+	if err != nil {
+		log.Printf("[TICKET] Không ghi được lịch sử thay đổi ticket #%d: %v", after.ID, err)
+	}
+	if len(events) == 0 {
+		return
+	}
+	realtime.Publish(realtime.TicketTopic(after.ID), "ticket_updated", fiber.Map{
+		"ticket_id": after.ID,
+		"status":    after.Status,
+		"changes":   events,
+	})
+	for _, ev := range events {
+		switch ev.Field {
+		case models.TicketFieldStatus:
+			models.QueueWebhookEvent(models.DB, webhook.EventTicketStatusChanged, fiber.Map{
+				"ticket":     models.WebhookTicket(after),
+				"old_status": before.Status,
+				"new_status": after.Status,
+				"actor_id":   actorID,
+			})
+		case models.TicketFieldAssignee:
+			models.QueueWebhookEvent(models.DB, webhook.EventTicketAssigned, fiber.Map{
+				"ticket":       models.WebhookTicket(after),
+				"old_assignee": before.AssignedTo,
+				"new_assignee": after.AssignedTo,
+				"actor_id":     actorID,
+			})
 		}
-		models.DB.Create(&n)
 	}
-	return c.JSON(fiber.Map{"success": true, "ticket": ticket})
+}
+
+// onTicketCreated ghi sự kiện tạo ticket, tự động phân công và báo cho khách hàng/admin.
+// Dùng chung cho ticket tạo qua API và qua email.
+func onTicketCreated(ticket *models.Ticket, user models.User) {
+	models.DB.Create(&models.TicketEvent{TicketID: ticket.ID, ActorID: &user.ID, Field: models.TicketFieldCreated, NewValue: ticket.Status})
+	models.QueueWebhookEvent(models.DB, webhook.EventTicketCreated, fiber.Map{"ticket": models.WebhookTicket(*ticket)})
+	// Tự động phân công theo quy tắc (nếu có cấu hình cho loại ticket/sản phẩm này)
+	before := *ticket
+	if staff, err := assignment.AutoAssign(ticket); err == nil {
+		models.DB.Model(ticket).Update("assigned_to", ticket.AssignedTo)
+		recordTicketChanges(before, *ticket, nil)
+		notifyTicketAssignee(*ticket, *staff)
+	}
+	// Nạp tên loại ticket/sản phẩm/mức ưu tiên cho nội dung email
+	models.DB.Preload("Category").Preload("ProductType").Preload("Priority").First(ticket, ticket.ID)
+	data := ticketEmailData(*ticket, user.Name)
+	data["Category"] = ticket.Category.Name
+	data["ProductType"] = ticket.ProductType.Name
+	data["Priority"] = ticket.Priority.Name
+	data["Description"] = ticket.Description
+	// Email xác nhận cho người tạo (không tạo thông báo trong ứng dụng)
+	notify(user, notice{
+		Type:     models.NotifyTicketNew,
+		Ticket:   *ticket,
+		EmailKey: emailtemplate.TicketCreated,
+		EmailData: func(to models.User) map[string]interface{} {
+			return data
+		},
+	})
+	// Báo cho tất cả admin
+	notifyUsers(adminUsers(), user.ID, notice{
+		Type:     models.NotifyTicketNew,
+		Ticket:   *ticket,
+		Content:  fmt.Sprintf("Ticket mới #%d: %s từ %s", ticket.ID, ticket.Title, user.Name),
+		Data:     models.NotificationData{TicketID: ticket.ID, UserID: user.ID},
+		EmailKey: emailtemplate.TicketCreatedAdmin,
+		EmailData: func(to models.User) map[string]interface{} {
+			adminData := map[string]interface{}{"CustomerName": user.Name}
+			for k, v := range data {
+				if k != "Name" {
+					adminData[k] = v
+				}
+			}
+			return adminData
+		},
+	})
+	notifyChat(chatops.EventTicketCreated, *ticket, "")
 }
 
 func GetMyTickets(c *fiber.Ctx) error {
@@ -149,7 +190,7 @@ func GetMyTickets(c *fiber.Ctx) error {
 
 	// Filter by user role
 	if user.Role == "staff" {
-		query = query.Where("assigned_to = ?", user.ID)
+		query = query.Scopes(staffTicketScope(user.ID))
 	} else if user.Role == "customer" {
 		query = query.Where("user_id = ?", user.ID)
 	}
@@ -198,7 +239,7 @@ func GetMyTickets(c *fiber.Ctx) error {
 	total := int64(0)
 	countQuery := models.DB.Model(&models.Ticket{})
 	if user.Role == "staff" {
-		countQuery = countQuery.Where("assigned_to = ?", user.ID)
+		countQuery = countQuery.Scopes(staffTicketScope(user.ID))
 	} else if user.Role == "customer" {
 		countQuery = countQuery.Where("user_id = ?", user.ID)
 	}
@@ -248,10 +289,12 @@ func GetMyTickets(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "Không lấy được danh sách ticket"})
 	}
 	// Lấy comment mới nhất cho từng ticket
+	now := time.Now()
+	thumbnails := ticketThumbnailURLs(tickets, user.Role)
 	var result []fiber.Map
 	for _, t := range tickets {
 		var lastComment models.TicketComment
-		models.DB.Where("ticket_id = ?", t.ID).Order("created_at DESC").First(&lastComment)
+		models.DB.Scopes(models.VisibleComments(user.Role)).Where("ticket_id = ?", t.ID).Order("created_at DESC").First(&lastComment)
 		var hasNewReply bool
 		if lastComment.ID > 0 && (t.LastViewedCommentAt == nil || lastComment.CreatedAt.After(*t.LastViewedCommentAt)) {
 			hasNewReply = true
@@ -283,7 +326,7 @@ func GetMyTickets(c *fiber.Ctx) error {
 		} else {
 			prod = fiber.Map{"id": nil, "name": "Không xác định"}
 		}
-		result = append(result, fiber.Map{
+		item := fiber.Map{
 			"id":              t.ID,
 			"title":           t.Title,
 			"description":     t.Description,
@@ -293,6 +336,7 @@ func GetMyTickets(c *fiber.Ctx) error {
 			"created_at":      t.CreatedAt,
 			"resolved_at":     t.ResolvedAt,
 			"attachment_path": t.AttachmentPath,
+			"thumbnail_url":   thumbnails[t.ID],
 			"product_type":    prod,
 			"has_new_reply":   hasNewReply,
 			"assigned_to":     t.AssignedTo,
@@ -303,7 +347,9 @@ func GetMyTickets(c *fiber.Ctx) error {
 				"email": t.User.Email,
 				"role":  t.User.Role,
 			},
-		})
+		}
+		addSLAFields(item, t, now)
+		result = append(result, item)
 	}
 	return c.JSON(fiber.Map{
 		"tickets": result,
@@ -324,7 +370,7 @@ func GetTicketDetail(c *fiber.Ctx) error {
 	if err := models.DB.Preload("User").Preload("Assigned").Preload("Category").Preload("Priority").Preload("ProductType").Where("id = ?", ticketID).First(&ticket).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy ticket"})
 	}
-	if user.Role == "staff" && (ticket.AssignedTo == nil || *ticket.AssignedTo != user.ID) {
+	if user.Role == "staff" && !canStaffViewTicket(user, ticket) {
 		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy ticket"})
 	}
 	if user.Role == "customer" && ticket.UserID != user.ID {
@@ -332,7 +378,7 @@ func GetTicketDetail(c *fiber.Ctx) error {
 	}
 	// Lấy comment mới nhất
 	var lastComment models.TicketComment
-	models.DB.Where("ticket_id = ?", ticketID).Order("created_at DESC").First(&lastComment)
+	models.DB.Scopes(models.VisibleComments(user.Role)).Where("ticket_id = ?", ticketID).Order("created_at DESC").First(&lastComment)
 	if lastComment.ID > 0 {
 		if ticket.LastViewedCommentAt == nil || lastComment.CreatedAt.After(*ticket.LastViewedCommentAt) {
 			ticket.LastViewedCommentAt = &lastComment.CreatedAt
@@ -357,27 +403,31 @@ func GetTicketDetail(c *fiber.Ctx) error {
 	} else {
 		prod = fiber.Map{"id": nil, "name": "Không xác định"}
 	}
-	return c.JSON(fiber.Map{
-		"ticket": fiber.Map{
-			"id":              ticket.ID,
-			"title":           ticket.Title,
-			"description":     ticket.Description,
-			"category":        cat,
-			"status":          ticket.Status,
-			"priority":        pri,
-			"created_at":      ticket.CreatedAt,
-			"resolved_at":     ticket.ResolvedAt,
-			"attachment_path": ticket.AttachmentPath,
-			"product_type":    prod,
-			"user": fiber.Map{
-				"id":    ticket.User.ID,
-				"name":  ticket.User.Name,
-				"email": ticket.User.Email,
-				"role":  ticket.User.Role,
-			},
-			"last_viewed_comment_at": ticket.LastViewedCommentAt,
+	resp := fiber.Map{
+		"id":              ticket.ID,
+		"title":           ticket.Title,
+		"description":     ticket.Description,
+		"category":        cat,
+		"status":          ticket.Status,
+		"priority":        pri,
+		"created_at":      ticket.CreatedAt,
+		"resolved_at":     ticket.ResolvedAt,
+		"attachment_path": ticket.AttachmentPath,
+		"product_type":    prod,
+		"user": fiber.Map{
+			"id":    ticket.User.ID,
+			"name":  ticket.User.Name,
+			"email": ticket.User.Email,
+			"role":  ticket.User.Role,
 		},
-	})
+		"last_viewed_comment_at": ticket.LastViewedCommentAt,
+	}
+	addSLAFields(resp, ticket, time.Now())
+	var attachments []models.Attachment
+	models.DB.Scopes(models.AttachmentsOf(models.AttachmentOwnerTicket, ticket.ID), models.AttachmentsVisibleTo(user.Role)).Order("id").Find(&attachments)
+	resp["attachments"] = attachmentsResponse(attachments)
+	resp["thumbnail_url"] = firstThumbnailURL(attachments)
+	return c.JSON(fiber.Map{"ticket": resp})
 }
 
 // Get all comments for a ticket (user or admin)
@@ -388,40 +438,56 @@ func GetTicketComments(c *fiber.Ctx) error {
 	if err := models.DB.First(&ticket, ticketID).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy ticket"})
 	}
-	if user.Role == "staff" && (ticket.AssignedTo == nil || *ticket.AssignedTo != user.ID) {
+	if !canViewTicket(user, ticket) {
 		return c.Status(403).JSON(fiber.Map{"error": "Bạn không có quyền xem bình luận ticket này"})
 	}
 	var comments []models.TicketComment
-	if err := models.DB.Preload("User").Where("ticket_id = ?", ticketID).Order("created_at ASC").Find(&comments).Error; err != nil {
+	if err := models.DB.Preload("User").Scopes(models.VisibleComments(user.Role)).Where("ticket_id = ?", ticketID).Order("created_at ASC").Find(&comments).Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Không lấy được danh sách bình luận"})
 	}
+	commentIDs := make([]uint, 0, len(comments))
+	for _, cm := range comments {
+		commentIDs = append(commentIDs, cm.ID)
+	}
+	attachments := commentAttachments(commentIDs, user.Role)
 	// Build response with author_name fallback (role tiếng Anh)
 	var result []fiber.Map
 	for _, c := range comments {
-		authorName := "Ẩn danh"
-		if c.User.ID != 0 {
-			if c.User.Name != "" {
-				authorName = c.User.Name
-			} else if c.User.Role == "admin" {
-				authorName = "Admin"
-			} else if c.User.Role == "staff" {
-				authorName = "Nhân viên"
-			} else if c.User.Role == "customer" {
-				authorName = "Khách hàng"
-			}
-		}
 		result = append(result, fiber.Map{
 			"id":             c.ID,
 			"content":        c.Content,
 			"created_at":     c.CreatedAt,
 			"attachment_url": c.AttachmentPath,
-			"author_name":    authorName,
+			"thumbnail_url":  firstThumbnailURL(attachments[c.ID]),
+			"author_name":    commentAuthorName(c.User),
 			"parent_id":      c.ParentID, // Thêm parent_id vào response
+			"is_internal":    c.IsInternal,
+			"type":           c.Type(),
+			"attachments":    attachmentsResponse(attachments[c.ID]),
 		})
 	}
 	return c.JSON(fiber.Map{"comments": result})
 }
 
+// commentAuthorName trả về tên người bình luận, không có tên thì dùng vai trò
+func commentAuthorName(u models.User) string {
+	if u.ID == 0 {
+		return "Ẩn danh"
+	}
+	if u.Name != "" {
+		return u.Name
+	}
+	switch u.Role {
+	case "admin":
+		return "Admin"
+	case "staff":
+		return "Nhân viên"
+	case "customer":
+		return "Khách hàng"
+	}
+	return "Ẩn danh"
+}
+
 // Post a new comment to a ticket (user or admin)
 func PostTicketComment(c *fiber.Ctx) error {
 	user := c.Locals("user").(models.User)
@@ -433,10 +499,7 @@ func PostTicketComment(c *fiber.Ctx) error {
 	if err := models.DB.First(&ticket, ticketID).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy ticket"})
 	}
-	if user.Role == "staff" && (ticket.AssignedTo == nil || *ticket.AssignedTo != user.ID) {
-		return c.Status(403).JSON(fiber.Map{"error": "Bạn không có quyền phản hồi ticket này"})
-	}
-	if user.Role == "customer" && ticket.UserID != user.ID {
+	if !canCommentTicket(user, ticket) {
 		return c.Status(403).JSON(fiber.Map{"error": "Bạn không có quyền phản hồi ticket này"})
 	}
 
@@ -444,21 +507,16 @@ func PostTicketComment(c *fiber.Ctx) error {
 	if content == "" {
 		return c.Status(400).JSON(fiber.Map{"error": "Nội dung bình luận không hợp lệ"})
 	}
+	// Ghi chú nội bộ chỉ dành cho admin/staff
+	isInternal, _ := strconv.ParseBool(c.FormValue("is_internal", "false"))
+	if isInternal && user.Role != "admin" && user.Role != "staff" {
+		return c.Status(403).JSON(fiber.Map{"error": "Bạn không có quyền tạo ghi chú nội bộ"})
+	}
 
 	// Xử lý file đính kèm (nếu có)
-	var attachmentPath string
-	fileHeader, err := c.FormFile("attachment")
-	if err == nil && fileHeader != nil {
-		uploadDir := "uploads/comments"
-		os.MkdirAll(uploadDir, os.ModePerm)
-		timestamp := strconv.FormatInt(time.Now().UnixNano(), 10)
-		ext := filepath.Ext(fileHeader.Filename)
-		filename := "comment_" + timestamp + ext
-		filePath := filepath.Join(uploadDir, filename)
-		if err := c.SaveFile(fileHeader, filePath); err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Lưu file đính kèm thất bại"})
-		}
-		attachmentPath = filePath
+	files, err := uploadedFiles(c, upload.ContextComment)
+	if err != nil {
+		return respondUploadError(c, err)
 	}
 
 	// Lấy parent_id nếu có
@@ -469,6 +527,21 @@ func PostTicketComment(c *fiber.Ctx) error {
 			parentID = &pidUint
 		}
 	}
+	if parentID != nil {
+		var parent models.TicketComment
+		if err := models.DB.Scopes(models.VisibleComments(user.Role)).Where("ticket_id = ?", ticketID).First(&parent, *parentID).Error; err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Bình luận gốc không hợp lệ"})
+		}
+	}
+
+	uploads, err := storeUploads(files, "uploads/comments", "comment")
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Lưu file đính kèm thất bại"})
+	}
+	var attachmentPath string
+	if len(uploads) > 0 {
+		attachmentPath = uploads[0].Path
+	}
 
 	comment := models.TicketComment{
 		TicketID:       uint(ticketID),
@@ -476,74 +549,142 @@ func PostTicketComment(c *fiber.Ctx) error {
 		Content:        content,
 		AttachmentPath: attachmentPath,
 		ParentID:       parentID, // Lưu parent_id nếu có
+		IsInternal:     isInternal,
 		CreatedAt:      time.Now(),
 	}
 	if err := models.DB.Create(&comment).Error; err != nil {
+		removeAttachmentFiles(uploads)
 		return c.Status(500).JSON(fiber.Map{"error": "Không thể tạo bình luận"})
 	}
+	attachments, err := createAttachments(uploads, models.AttachmentOwnerComment, comment.ID, ticket.ID, user.ID)
+	if err != nil {
+		removeAttachmentFiles(uploads)
+		models.DB.Delete(&comment)
+		return c.Status(500).JSON(fiber.Map{"error": "Lưu file đính kèm thất bại"})
+	}
+	scanAttachments(attachments, ticket)
+	onCommentCreated(&ticket, &comment, user)
+	return c.JSON(fiber.Map{"success": true, "comment": comment, "attachments": attachmentsResponse(attachments)})
+}
+
+// publishComment đẩy bình luận mới đến các client đang xem ticket; ghi chú nội bộ chỉ gửi cho admin/staff
+func publishComment(ticket models.Ticket, comment models.TicketComment) {
+	topic := realtime.TicketTopic(ticket.ID)
+	if comment.IsInternal {
+		topic = realtime.TicketStaffTopic(ticket.ID)
+	}
+	realtime.Publish(topic, "ticket_comment", fiber.Map{
+		"id":             comment.ID,
+		"ticket_id":      ticket.ID,
+		"content":        comment.Content,
+		"created_at":     comment.CreatedAt,
+		"attachment_url": comment.AttachmentPath,
+		"author_name":    commentAuthorName(comment.User),
+		"parent_id":      comment.ParentID,
+		"is_internal":    comment.IsInternal,
+		"type":           comment.Type(),
+	})
+}
+
+// onCommentCreated cập nhật workflow/SLA theo bình luận mới và báo cho các bên liên quan.
+// Dùng chung cho bình luận qua API và qua email.
+func onCommentCreated(ticket *models.Ticket, comment *models.TicketComment, user models.User) {
+	models.QueueWebhookEvent(models.DB, webhook.EventTicketCommented, fiber.Map{
+		"ticket": models.WebhookTicket(*ticket),
+		"comment": fiber.Map{
+			"id":          comment.ID,
+			"user_id":     comment.UserID,
+			"author_name": user.Name,
+			"author_role": user.Role,
+			"content":     comment.Content,
+			"parent_id":   comment.ParentID,
+			"is_internal": comment.IsInternal,
+			"created_at":  comment.CreatedAt,
+		},
+	})
+	// Cập nhật trạng thái theo workflow: nhân viên phản hồi lần đầu,
+	// khách hàng trả lời khi ticket đang chờ phản hồi thì ticket quay lại "Đang xử lý"
+	// Ghi chú nội bộ không phải phản hồi cho khách hàng nên không ảnh hưởng workflow/SLA
+	if user.Role == workflow.RoleCustomer && ticket.Status == workflow.StatusWaiting {
+		before := *ticket
+		if err := workflow.Transition(ticket, workflow.StatusInProgress, user.Role, comment.CreatedAt); err == nil {
+			models.DB.Save(ticket)
+			recordTicketChanges(before, *ticket, &user.ID)
+		}
+	} else if !comment.IsInternal && workflow.MarkFirstResponse(ticket, user.Role, comment.CreatedAt) {
+		models.DB.Model(ticket).Select("first_response_at", "sla_due_at", "sla_paused_at", "sla_paused_seconds",
+			"first_response_due_at", "resolution_due_at", "sla_breached", "response_breached", "resolution_breached").Updates(ticket)
+	}
 	// Lấy lại comment với thông tin user
-	models.DB.Preload("User").First(&comment, comment.ID)
+	models.DB.Preload("User").First(comment, comment.ID)
+	publishComment(*ticket, *comment)
 
 	// Gửi notification cho các bên liên quan
+	data := models.NotificationData{TicketID: ticket.ID, CommentID: comment.ID}
 	if user.Role == "customer" {
 		// Gửi cho staff được assigned hoặc cho tất cả admin nếu chưa assigned
+		n := notice{
+			Type:    models.NotifyTicketComment,
+			Ticket:  *ticket,
+			Content: fmt.Sprintf("Khách hàng vừa bình luận mới trên ticket #%d: %s", ticket.ID, ticket.Title),
+			Data:    data,
+		}
 		if ticket.AssignedTo != nil {
 			var staff models.User
-			if err := models.DB.First(&staff, *ticket.AssignedTo).Error; err == nil && staff.ID != user.ID {
-				n := models.Notification{
-					UserID:  staff.ID,
-					Type:    "ticket_comment",
-					Content: fmt.Sprintf("Khách hàng vừa bình luận mới trên ticket #%d: %s", ticket.ID, ticket.Title),
-					Data:    fmt.Sprintf(`{"ticket_id":%d,"comment_id":%d}`, ticket.ID, comment.ID),
-				}
-				models.DB.Create(&n)
+			if err := models.DB.First(&staff, *ticket.AssignedTo).Error; err == nil {
+				notifyUsers([]models.User{staff}, user.ID, n)
 			}
 		} else {
-			var admins []models.User
-			models.DB.Where("role = ?", "admin").Find(&admins)
-			for _, admin := range admins {
-				if admin.ID != user.ID {
-					n := models.Notification{
-						UserID:  admin.ID,
-						Type:    "ticket_comment",
-						Content: fmt.Sprintf("Khách hàng vừa bình luận mới trên ticket #%d: %s", ticket.ID, ticket.Title),
-						Data:    fmt.Sprintf(`{"ticket_id":%d,"comment_id":%d}`, ticket.ID, comment.ID),
-					}
-					models.DB.Create(&n)
-				}
-			}
+			notifyUsers(adminUsers(), user.ID, n)
 		}
+	} else if comment.IsInternal {
+		// Ghi chú nội bộ: không báo cho khách hàng, chỉ báo cho nhân viên phụ trách và admin
+		notifyInternalNote(*ticket, *comment, user)
 	} else if user.Role == "admin" || user.Role == "staff" {
 		// Gửi cho chủ ticket nếu không phải là người vừa bình luận
-		if ticket.UserID != user.ID {
-			n := models.Notification{
-				UserID:  ticket.UserID,
-				Type:    "ticket_comment",
-				Content: fmt.Sprintf("Có phản hồi mới từ %s trên ticket #%d: %s", user.Name, ticket.ID, ticket.Title),
-				Data:    fmt.Sprintf(`{"ticket_id":%d,"comment_id":%d}`, ticket.ID, comment.ID),
-			}
-			models.DB.Create(&n)
+		var owner models.User
+		if err := models.DB.First(&owner, ticket.UserID).Error; err == nil {
+			notifyUsers([]models.User{owner}, user.ID, notice{
+				Type:     models.NotifyTicketComment,
+				Ticket:   *ticket,
+				Content:  fmt.Sprintf("Có phản hồi mới từ %s trên ticket #%d: %s", user.Name, ticket.ID, ticket.Title),
+				Data:     data,
+				EmailKey: emailtemplate.TicketComment,
+				EmailData: func(to models.User) map[string]interface{} {
+					emailData := ticketEmailData(*ticket, to.Name)
+					emailData["AuthorName"] = user.Name
+					emailData["Content"] = comment.Content
+					return emailData
+				},
+			})
 		}
 
 		// Nếu staff comment, gửi notification cho admin
 		if user.Role == "staff" {
-			var admins []models.User
-			models.DB.Where("role = ?", "admin").Find(&admins)
-			for _, admin := range admins {
-				if admin.ID != user.ID {
-					n := models.Notification{
-						UserID:  admin.ID,
-						Type:    "ticket_comment",
-						Content: fmt.Sprintf("Staff %s vừa bình luận trên ticket #%d: %s", user.Name, ticket.ID, ticket.Title),
-						Data:    fmt.Sprintf(`{"ticket_id":%d,"comment_id":%d}`, ticket.ID, comment.ID),
-					}
-					models.DB.Create(&n)
-				}
-			}
+			notifyUsers(adminUsers(), user.ID, notice{
+				Type:    models.NotifyTicketComment,
+				Ticket:  *ticket,
+				Content: fmt.Sprintf("Staff %s vừa bình luận trên ticket #%d: %s", user.Name, ticket.ID, ticket.Title),
+				Data:    data,
+			})
 		}
 	}
+}
 
-	return c.JSON(fiber.Map{"success": true, "comment": comment})
+// notifyInternalNote báo ghi chú nội bộ mới cho nhân viên phụ trách và admin (trừ người viết)
+func notifyInternalNote(ticket models.Ticket, comment models.TicketComment, author models.User) {
+	var recipients []models.User
+	query := models.DB.Where("role = ?", "admin")
+	if ticket.AssignedTo != nil {
+		query = query.Or("id = ?", *ticket.AssignedTo)
+	}
+	query.Find(&recipients)
+	notifyUsers(recipients, author.ID, notice{
+		Type:    models.NotifyTicketInternalNote,
+		Ticket:  ticket,
+		Content: fmt.Sprintf("%s vừa thêm ghi chú nội bộ trên ticket #%d: %s", author.Name, ticket.ID, ticket.Title),
+		Data:    models.NotificationData{TicketID: ticket.ID, CommentID: comment.ID},
+	})
 }
 
 // Lấy danh sách user có role admin hoặc staff
@@ -553,16 +694,17 @@ func GetAssignableStaff(c *fiber.Ctx) error {
 		return c.Status(403).JSON(fiber.Map{"error": "Chỉ admin mới có quyền phân công ticket"})
 	}
 	var staff []models.User
-	if err := models.DB.Where("role IN ?", []string{"admin", "staff"}).Find(&staff).Error; err != nil {
+	if err := models.AssignableStaff(models.DB).Find(&staff).Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Không lấy được danh sách nhân viên"})
 	}
 	result := make([]fiber.Map, 0, len(staff))
 	for _, s := range staff {
 		result = append(result, fiber.Map{
-			"id":    s.ID,
-			"name":  s.Name,
-			"email": s.Email,
-			"role":  s.Role,
+			"id":           s.ID,
+			"name":         s.Name,
+			"email":        s.Email,
+			"role":         s.Role,
+			"is_available": s.IsAvailable,
 		})
 	}
 	return c.JSON(fiber.Map{"staff": result})
@@ -590,10 +732,15 @@ func AssignTicket(c *fiber.Ctx) error {
 	if err := models.DB.First(&staff, input.AssignedTo).Error; err != nil || (staff.Role != "admin" && staff.Role != "staff") {
 		return c.Status(400).JSON(fiber.Map{"error": "Người được phân công không hợp lệ"})
 	}
+	before := ticket
 	ticket.AssignedTo = &input.AssignedTo
 	if err := models.DB.Save(&ticket).Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Không thể phân công ticket"})
 	}
+	recordTicketChanges(before, ticket, &user.ID)
+	if staff.ID != user.ID {
+		notifyTicketAssignee(ticket, staff)
+	}
 	return c.JSON(fiber.Map{"success": true, "assigned_to": input.AssignedTo})
 }
 
@@ -610,11 +757,15 @@ func AdminGetTickets(c *fiber.Ctx) error {
 	productType := c.Query("product_type")
 	productTypeID := c.Query("product_type_id")
 	assignedTo := c.Query("assigned_to")
+	teamID := c.Query("team_id")
 	search := c.Query("search")
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	limit, _ := strconv.Atoi(c.Query("limit", "10"))
 	fromDate := c.Query("from_date")
 	toDate := c.Query("to_date")
+	slaFilter := c.Query("sla")
+	slaWindow, _ := strconv.Atoi(c.Query("sla_window", "60"))
+	now := time.Now()
 
 	if page < 1 {
 		page = 1
@@ -622,13 +773,16 @@ func AdminGetTickets(c *fiber.Ctx) error {
 	if limit < 1 || limit > 100 {
 		limit = 10
 	}
+	if slaWindow < 1 {
+		slaWindow = 60
+	}
 
 	var tickets []models.Ticket
 	query := models.DB.Preload("User").Preload("Assigned").Preload("Category").Preload("Priority").Preload("ProductType")
 
 	// Filter by role - staff only sees assigned tickets
 	if userRole == "staff" {
-		query = query.Where("assigned_to = ?", user.ID)
+		query = query.Scopes(staffTicketScope(user.ID))
 	}
 
 	// Apply filters
@@ -656,11 +810,18 @@ func AdminGetTickets(c *fiber.Ctx) error {
 	} else if productType != "" && productType != "Tất cả" {
 		query = query.Joins("JOIN ticket_product_types ON tickets.product_type_id = ticket_product_types.id").Where("ticket_product_types.name = ?", productType)
 	}
-	if assignedTo != "" {
+	if assignedTo == "none" {
+		query = query.Where("tickets.assigned_to IS NULL")
+	} else if assignedTo != "" {
 		if id, err := strconv.Atoi(assignedTo); err == nil {
 			query = query.Where("assigned_to = ?", id)
 		}
 	}
+	if teamID != "" {
+		if id, err := strconv.Atoi(teamID); err == nil {
+			query = query.Where("tickets.team_id = ?", id)
+		}
+	}
 	if search != "" {
 		searchTerm := "%" + search + "%"
 		query = query.Where("title LIKE ? OR description LIKE ? OR users.name LIKE ? OR users.email LIKE ?",
@@ -677,6 +838,13 @@ func AdminGetTickets(c *fiber.Ctx) error {
 			query = query.Where("created_at < ?", t.Add(24*time.Hour))
 		}
 	}
+	// Lọc theo SLA: "breached" (đã vi phạm) hoặc "breaching_soon" (sắp hết hạn trong sla_window phút)
+	switch slaFilter {
+	case "breached":
+		query = query.Scopes(sla.ScopeBreached(now))
+	case "breaching_soon":
+		query = query.Scopes(sla.ScopeBreachingSoon(now, time.Duration(slaWindow)*time.Minute))
+	}
 
 	// Count total for pagination
 	total := int64(0)
@@ -684,7 +852,7 @@ func AdminGetTickets(c *fiber.Ctx) error {
 
 	// Apply same role filter to count query
 	if userRole == "staff" {
-		countQuery = countQuery.Where("assigned_to = ?", user.ID)
+		countQuery = countQuery.Scopes(staffTicketScope(user.ID))
 	}
 
 	if status != "" && status != "Tất cả" {
@@ -711,11 +879,18 @@ func AdminGetTickets(c *fiber.Ctx) error {
 	} else if productType != "" && productType != "Tất cả" {
 		countQuery = countQuery.Joins("JOIN ticket_product_types ON tickets.product_type_id = ticket_product_types.id").Where("ticket_product_types.name = ?", productType)
 	}
-	if assignedTo != "" {
+	if assignedTo == "none" {
+		countQuery = countQuery.Where("tickets.assigned_to IS NULL")
+	} else if assignedTo != "" {
 		if id, err := strconv.Atoi(assignedTo); err == nil {
 			countQuery = countQuery.Where("assigned_to = ?", id)
 		}
 	}
+	if teamID != "" {
+		if id, err := strconv.Atoi(teamID); err == nil {
+			countQuery = countQuery.Where("tickets.team_id = ?", id)
+		}
+	}
 	if search != "" {
 		searchTerm := "%" + search + "%"
 		countQuery = countQuery.Joins("LEFT JOIN users ON tickets.user_id = users.id")
@@ -732,6 +907,12 @@ func AdminGetTickets(c *fiber.Ctx) error {
 			countQuery = countQuery.Where("created_at < ?", t.Add(24*time.Hour))
 		}
 	}
+	switch slaFilter {
+	case "breached":
+		countQuery = countQuery.Scopes(sla.ScopeBreached(now))
+	case "breaching_soon":
+		countQuery = countQuery.Scopes(sla.ScopeBreachingSoon(now, time.Duration(slaWindow)*time.Minute))
+	}
 
 	countQuery.Count(&total)
 
@@ -739,6 +920,7 @@ func AdminGetTickets(c *fiber.Ctx) error {
 	if err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&tickets).Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Không lấy được danh sách ticket"})
 	}
+	thumbnails := ticketThumbnailURLs(tickets, user.Role)
 	var result []fiber.Map
 	for _, t := range tickets {
 		assigned := fiber.Map{}
@@ -768,7 +950,7 @@ func AdminGetTickets(c *fiber.Ctx) error {
 		} else {
 			prod = fiber.Map{"id": nil, "name": "Không xác định"}
 		}
-		result = append(result, fiber.Map{
+		item := fiber.Map{
 			"id":              t.ID,
 			"title":           t.Title,
 			"description":     t.Description,
@@ -778,6 +960,7 @@ func AdminGetTickets(c *fiber.Ctx) error {
 			"created_at":      t.CreatedAt,
 			"resolved_at":     t.ResolvedAt,
 			"attachment_path": t.AttachmentPath,
+			"thumbnail_url":   thumbnails[t.ID],
 			"product_type":    prod,
 			"user": fiber.Map{
 				"id":    t.User.ID,
@@ -787,7 +970,10 @@ func AdminGetTickets(c *fiber.Ctx) error {
 			},
 			"assigned_to": t.AssignedTo,
 			"assigned":    assigned,
-		})
+			"team_id":     t.TeamID,
+		}
+		addSLAFields(item, t, now)
+		result = append(result, item)
 	}
 	return c.JSON(fiber.Map{
 		"tickets": result,
@@ -811,7 +997,7 @@ func UpdateMyTicket(c *fiber.Ctx) error {
 	if ticket.UserID != user.ID {
 		return c.Status(403).JSON(fiber.Map{"error": "Bạn không có quyền sửa ticket này"})
 	}
-	if ticket.Status != "Mới" {
+	if ticket.Status != workflow.StatusNew {
 		return c.Status(400).JSON(fiber.Map{"error": "Chỉ được sửa ticket khi trạng thái là 'Mới'"})
 	}
 	type UpdateInput struct {
@@ -825,6 +1011,7 @@ func UpdateMyTicket(c *fiber.Ctx) error {
 	if err := c.BodyParser(&input); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Dữ liệu không hợp lệ"})
 	}
+	before := ticket
 	if input.Title != "" {
 		ticket.Title = input.Title
 	}
@@ -840,22 +1027,25 @@ func UpdateMyTicket(c *fiber.Ctx) error {
 	if input.PriorityID != 0 {
 		ticket.PriorityID = input.PriorityID
 	}
+	// Đổi priority/category thì tính lại hạn SLA
+	if ticket.PriorityID != before.PriorityID || ticket.CategoryID != before.CategoryID {
+		sla.Apply(&ticket, time.Now())
+	}
+	// Đổi category/product type thì chuyển ticket sang hàng đợi team tương ứng
+	if ticket.CategoryID != before.CategoryID || ticket.ProductTypeID != before.ProductTypeID {
+		ticket.TeamID = assignment.RouteToTeam(models.DB, ticket.CategoryID, ticket.ProductTypeID)
+	}
 	if err := models.DB.Save(&ticket).Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Không thể cập nhật ticket"})
 	}
+	recordTicketChanges(before, ticket, &user.ID)
 	// Tạo notification cho admin
-	var admins []models.User
-	models.DB.Where("role = ?", "admin").Find(&admins)
-	notifData, _ := json.Marshal(fiber.Map{"ticket_id": ticket.ID, "action": "update", "user_id": user.ID})
-	for _, admin := range admins {
-		n := models.Notification{
-			UserID:  admin.ID,
-			Type:    "ticket_update",
-			Content: "Khách hàng đã sửa ticket #" + strconv.Itoa(int(ticket.ID)),
-			Data:    string(notifData),
-		}
-		models.DB.Create(&n)
-	}
+	notifyUsers(adminUsers(), user.ID, notice{
+		Type:    models.NotifyTicketUpdate,
+		Ticket:  ticket,
+		Content: "Khách hàng đã sửa ticket #" + strconv.Itoa(int(ticket.ID)),
+		Data:    models.NotificationData{TicketID: ticket.ID, Action: "update", UserID: user.ID},
+	})
 	return c.JSON(fiber.Map{"success": true, "ticket": ticket})
 }
 
@@ -870,156 +1060,97 @@ func DeleteMyTicket(c *fiber.Ctx) error {
 	if ticket.UserID != user.ID {
 		return c.Status(403).JSON(fiber.Map{"error": "Bạn không có quyền thu hồi ticket này"})
 	}
-	if ticket.Status != "Mới" {
+	if ticket.Status != workflow.StatusNew {
 		return c.Status(400).JSON(fiber.Map{"error": "Chỉ được thu hồi ticket khi trạng thái là 'Mới'"})
 	}
 	if err := models.DB.Delete(&ticket).Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Không thể thu hồi ticket"})
 	}
+	deleteTicketAttachments(ticket.ID)
 	// Tạo notification cho admin
-	var admins []models.User
-	models.DB.Where("role = ?", "admin").Find(&admins)
-	notifData, _ := json.Marshal(fiber.Map{"ticket_id": ticket.ID, "action": "delete", "user_id": user.ID})
-	for _, admin := range admins {
-		n := models.Notification{
-			UserID:  admin.ID,
-			Type:    "ticket_delete",
-			Content: "Khách hàng đã thu hồi ticket #" + strconv.Itoa(int(ticket.ID)),
-			Data:    string(notifData),
-		}
-		models.DB.Create(&n)
-	}
+	notifyUsers(adminUsers(), user.ID, notice{
+		Type:    models.NotifyTicketDelete,
+		Ticket:  ticket,
+		Content: "Khách hàng đã thu hồi ticket #" + strconv.Itoa(int(ticket.ID)),
+		Data:    models.NotificationData{TicketID: ticket.ID, Action: "delete", UserID: user.ID},
+	})
 	return c.JSON(fiber.Map{"success": true})
 }
 
-// Lấy notification cho admin
-func AdminGetNotifications(c *fiber.Ctx) error {
-	user := c.Locals("user").(models.User)
-	userRole := c.Locals("userRole").(string)
-
-	var notifs []models.Notification
-
-	if userRole == "staff" {
-		// Staff chỉ thấy notifications liên quan đến tickets được assign cho họ
-		models.DB.Raw(`
-			SELECT n.* FROM notifications n
-			JOIN tickets t ON JSON_EXTRACT(n.data, '$.ticket_id') = t.id
-			WHERE t.assigned_to = ? AND n.user_id = ?
-			ORDER BY n.created_at DESC
-			LIMIT 50
-		`, user.ID, user.ID).Scan(&notifs)
-	} else {
-		// Admin thấy tất cả notifications
-		models.DB.Where("user_id = ?", user.ID).Order("created_at DESC").Limit(50).Find(&notifs)
-	}
-
-	return c.JSON(fiber.Map{"notifications": notifs})
-}
+// lateTicketThreshold là số giờ làm việc không cập nhật trước khi nhắc (ticket chưa có SLA)
+const lateTicketThreshold = 24 * time.Hour
 
-// Đánh dấu đã đọc notification cho admin
-func AdminReadNotification(c *fiber.Ctx) error {
-	user := c.Locals("user").(models.User)
-	userRole := c.Locals("userRole").(string)
+// Hàm kiểm tra ticket chậm và gửi email nhắc nhở
+func CheckAndSendLateTicketReminders() {
+	db := models.DB
+	now := time.Now()
+	openStatuses := []string{workflow.StatusNew, workflow.StatusInProgress, workflow.StatusWaiting}
 
-	notifID := c.Params("id")
-	var notif models.Notification
-	if err := models.DB.First(&notif, notifID).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy thông báo"})
+	// Ticket có chính sách SLA: nhắc một lần cho mỗi hạn (phản hồi đầu tiên, xử lý) khi hạn đó vừa trôi qua
+	var breached []models.Ticket
+	db.Scopes(sla.ScopeNewlyBreached(now)).Where("status IN ?", openStatuses).Find(&breached)
+	for _, t := range breached {
+		sla.MarkBreached(&t)
+		db.Model(&t).Select("sla_breached", "response_breached", "resolution_breached").Updates(&t)
+		sendLateTicketReminder(t, "sla_breached")
 	}
 
-	// Kiểm tra quyền truy cập notification
-	if userRole == "staff" {
-		// Staff chỉ có thể đọc notifications liên quan đến tickets được assign cho họ
-		var ticketID uint
-		if err := json.Unmarshal([]byte(notif.Data), &fiber.Map{"ticket_id": &ticketID}); err == nil {
-			var ticket models.Ticket
-			if err := models.DB.First(&ticket, ticketID).Error; err != nil {
-				return c.Status(403).JSON(fiber.Map{"error": "Không có quyền với thông báo này"})
-			}
-			if ticket.AssignedTo == nil || *ticket.AssignedTo != user.ID {
-				return c.Status(403).JSON(fiber.Map{"error": "Không có quyền với thông báo này"})
-			}
+	// Ticket chưa có chính sách SLA: nhắc khi chưa được cập nhật quá 24 giờ làm việc, sau đó nhắc lại tối đa
+	// một lần mỗi 24 giờ làm việc. Giờ làm việc luôn ít hơn giờ thực nên lọc trước bằng 24h giờ thực rồi tính lại theo lịch.
+	cal := calendar.Default()
+	var tickets []models.Ticket
+	db.Where("sla_policy_id IS NULL AND status IN ? AND TIMESTAMPDIFF(HOUR, GREATEST(updated_at, COALESCE(last_reminded_at, updated_at)), ?) >= ?",
+		openStatuses, now, int(lateTicketThreshold.Hours())).Find(&tickets)
+	for _, t := range tickets {
+		if !noResponseReminderDue(t, cal, now) {
+			continue
 		}
-	} else if notif.UserID != user.ID {
-		return c.Status(403).JSON(fiber.Map{"error": "Không có quyền với thông báo này"})
-	}
-
-	notif.IsRead = true
-	if err := models.DB.Save(&notif).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Không thể cập nhật thông báo"})
+		// Không đổi updated_at để lần cập nhật thật của ticket vẫn được tính riêng
+		db.Model(&t).UpdateColumn("last_reminded_at", now)
+		sendLateTicketReminder(t, "no_response")
 	}
-	return c.JSON(fiber.Map{"success": true})
-}
-
-// Lấy danh sách notification cho user
-func UserGetNotifications(c *fiber.Ctx) error {
-	user := c.Locals("user").(models.User)
-	var notifs []models.Notification
-	models.DB.Where("user_id = ?", user.ID).Order("created_at DESC").Limit(50).Find(&notifs)
-	return c.JSON(fiber.Map{"notifications": notifs})
 }
 
-// Đánh dấu đã đọc notification cho user
-func UserReadNotification(c *fiber.Ctx) error {
-	user := c.Locals("user").(models.User)
-	notifID := c.Params("id")
-	var notif models.Notification
-	if err := models.DB.First(&notif, notifID).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy thông báo"})
-	}
-	if notif.UserID != user.ID {
-		return c.Status(403).JSON(fiber.Map{"error": "Không có quyền với thông báo này"})
-	}
-	notif.IsRead = true
-	if err := models.DB.Save(&notif).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Không thể cập nhật thông báo"})
+// noResponseReminderDue: đã quá 24 giờ làm việc kể từ lần cập nhật hoặc lần nhắc gần nhất
+func noResponseReminderDue(t models.Ticket, cal *calendar.Calendar, now time.Time) bool {
+	since := t.UpdatedAt
+	if t.LastRemindedAt != nil && t.LastRemindedAt.After(since) {
+		since = *t.LastRemindedAt
 	}
-	return c.JSON(fiber.Map{"success": true})
+	return cal.Between(since, now) >= lateTicketThreshold
 }
 
-// Hàm kiểm tra ticket chậm và gửi email nhắc nhở
-func CheckAndSendLateTicketReminders() {
-	db := models.DB
-	var tickets []models.Ticket
-	now := time.Now()
-	// Lấy các ticket chưa được phản hồi quá 24h (chỉ lấy ticket chưa đóng)
-	db.Where("status != ? AND TIMESTAMPDIFF(HOUR, updated_at, ?) >= 24", "Đã đóng", now).Find(&tickets)
-	var tk []models.Ticket
-	tk = append(tk, tickets[0])
-	for _, t := range tk {
-		// Gửi cho staff được assigned hoặc cho tất cả admin nếu chưa assigned
-		if t.AssignedTo != nil {
-			var staff models.User
-			db.First(&staff, *t.AssignedTo)
-			if staff.Email != "" && staff.IsVerified {
-				subject := fmt.Sprintf("[Support] Ticket #%d chưa được phản hồi", t.ID)
-				body := fmt.Sprintf("<p>Xin chào %s,</p><p>Ticket <b>%s</b> (ID: %d) được giao cho bạn chưa được phản hồi trong hơn 24h.</p>", staff.Name, t.Title, t.ID)
-				go func() {
-					err := sendTicketEmail(staff.Email, subject, body)
-					if err != nil {
-						fmt.Printf("[MAIL ERROR] To: %s | Subject: %s | Error: %v\n", staff.Email, subject, err)
-					}
-				}()
-				time.Sleep(5 * time.Second)
-			}
-		} else {
-			var admins []models.User
-			db.Where("role = ? AND is_verified = ?", "admin", true).Find(&admins)
-			for _, admin := range admins {
-				if admin.Email != "" {
-					subject := fmt.Sprintf("[Support] Ticket #%d chưa được phản hồi", t.ID)
-					body := fmt.Sprintf("<p>Admin thân mến,</p><p>Ticket <b>%s</b> (ID: %d) chưa được phản hồi trong hơn 24h.</p>", t.Title, t.ID)
-					go func() {
-						err := sendTicketEmail(admin.Email, subject, body)
-						if err != nil {
-							fmt.Printf("[MAIL ERROR] To: %s | Subject: %s | Error: %v\n", admin.Email, subject, err)
-						}
-					}()
-					time.Sleep(5 * time.Second)
-				}
-			}
+// sendLateTicketReminder nhắc staff được assigned hoặc tất cả admin nếu chưa assigned,
+// đồng thời đăng lên các kênh chat đã cấu hình.
+// reason: sla_breached (quá hạn SLA) hoặc no_response (chưa phản hồi quá 24 giờ làm việc)
+func sendLateTicketReminder(t models.Ticket, reason string) {
+	notifyChat(chatops.EventSLAReminder, t, reason)
+	reasonText := "chưa được phản hồi trong hơn 24 giờ làm việc"
+	if reason == "sla_breached" {
+		reasonText = "đã quá hạn SLA"
+	}
+	n := notice{
+		Type:     models.NotifyTicketReminder,
+		Ticket:   t,
+		Data:     models.NotificationData{TicketID: t.ID, Reason: reason},
+		EmailKey: emailtemplate.TicketReminder,
+		EmailData: func(to models.User) map[string]interface{} {
+			data := ticketEmailData(t, to.Name)
+			data["Assigned"] = t.AssignedTo != nil
+			data["Reason"] = reason
+			return data
+		},
+	}
+	if t.AssignedTo != nil {
+		var staff models.User
+		if err := models.DB.First(&staff, *t.AssignedTo).Error; err == nil {
+			n.Content = fmt.Sprintf("Ticket #%d: %s được giao cho bạn %s", t.ID, t.Title, reasonText)
+			notify(staff, n)
 		}
+		return
 	}
+	n.Content = fmt.Sprintf("Ticket #%d: %s %s", t.ID, t.Title, reasonText)
+	notifyUsers(adminUsers(), 0, n)
 }
 
 // Lấy danh sách loại ticket
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"awesomeProject/models"
+
+	"gorm.io/gorm"
+)
+
+// staffTicketScope giới hạn danh sách ticket của staff: ticket được giao cho mình
+// hoặc nằm trong hàng đợi của team mình là thành viên
+func staffTicketScope(userID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(tickets.assigned_to = ? OR tickets.team_id IN (SELECT team_id FROM team_members WHERE user_id = ?))", userID, userID)
+	}
+}
+
+// isTeamMember kiểm tra user có thuộc team hay không
+func isTeamMember(userID, teamID uint) bool {
+	var count int64
+	models.DB.Table("team_members").Where("user_id = ? AND team_id = ?", userID, teamID).Count(&count)
+	return count > 0
+}
+
+// canStaffViewTicket: staff xem được ticket được giao cho mình hoặc thuộc hàng đợi team của mình
+func canStaffViewTicket(user models.User, ticket models.Ticket) bool {
+	if ticket.AssignedTo != nil && *ticket.AssignedTo == user.ID {
+		return true
+	}
+	return ticket.TeamID != nil && isTeamMember(user.ID, *ticket.TeamID)
+}
+
+// canViewTicket áp dụng quy tắc xem ticket chung: admin xem tất cả, staff theo phân công/team, khách hàng chỉ ticket của mình
+func canViewTicket(user models.User, ticket models.Ticket) bool {
+	switch user.Role {
+	case "admin":
+		return true
+	case "staff":
+		return canStaffViewTicket(user, ticket)
+	default:
+		return ticket.UserID == user.ID
+	}
+}
+
+// canCommentTicket: admin phản hồi mọi ticket, staff chỉ ticket được giao cho mình, khách hàng chỉ ticket của mình
+func canCommentTicket(user models.User, ticket models.Ticket) bool {
+	switch user.Role {
+	case "admin":
+		return true
+	case "staff":
+		return ticket.AssignedTo != nil && *ticket.AssignedTo == user.ID
+	default:
+		return ticket.UserID == user.ID
+	}
+}
@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"awesomeProject/models"
+	"sort"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetTicketHistory trả về dòng thời gian của ticket: các thay đổi trường và bình luận theo thứ tự thời gian
+func GetTicketHistory(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	ticketID := c.Params("id")
+
+	var ticket models.Ticket
+	if err := models.DB.First(&ticket, ticketID).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy ticket"})
+	}
+	if user.Role == "staff" && !canStaffViewTicket(user, ticket) {
+		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy ticket"})
+	}
+	if user.Role == "customer" && ticket.UserID != user.ID {
+		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy ticket"})
+	}
+
+	var events []models.TicketEvent
+	if err := models.DB.Preload("Actor").Where("ticket_id = ?", ticket.ID).Order("created_at ASC, id ASC").Find(&events).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Không lấy được lịch sử ticket"})
+	}
+	var comments []models.TicketComment
+	if err := models.DB.Preload("User").Scopes(models.VisibleComments(user.Role)).Where("ticket_id = ?", ticket.ID).Order("created_at ASC").Find(&comments).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Không lấy được danh sách bình luận"})
+	}
+
+	commentIDs := make([]uint, 0, len(comments))
+	for _, cm := range comments {
+		commentIDs = append(commentIDs, cm.ID)
+	}
+	attachments := commentAttachments(commentIDs, user.Role)
+
+	labels := ticketEventLabels(events)
+	type timelineItem struct {
+		entry fiber.Map
+		order int64
+	}
+	items := make([]timelineItem, 0, len(events)+len(comments))
+	for _, e := range events {
+		entry := fiber.Map{
+			"type":       "event",
+			"id":         e.ID,
+			"field":      e.Field,
+			"old_value":  e.OldValue,
+			"new_value":  e.NewValue,
+			"old_label":  labels.label(e.Field, e.OldValue),
+			"new_label":  labels.label(e.Field, e.NewValue),
+			"created_at": e.CreatedAt,
+			"actor":      nil,
+		}
+		if e.Actor != nil {
+			entry["actor"] = fiber.Map{"id": e.Actor.ID, "name": e.Actor.Name, "role": e.Actor.Role}
+		}
+		items = append(items, timelineItem{entry: entry, order: e.CreatedAt.UnixNano()})
+	}
+	for _, cm := range comments {
+		entry := fiber.Map{
+			"type":           "comment",
+			"id":             cm.ID,
+			"content":        cm.Content,
+			"attachment_url": cm.AttachmentPath,
+			"thumbnail_url":  firstThumbnailURL(attachments[cm.ID]),
+			"attachments":    attachmentsResponse(attachments[cm.ID]),
+			"parent_id":      cm.ParentID,
+			"is_internal":    cm.IsInternal,
+			"comment_type":   cm.Type(),
+			"created_at":     cm.CreatedAt,
+			"actor":          fiber.Map{"id": cm.User.ID, "name": cm.User.Name, "role": cm.User.Role},
+		}
+		items = append(items, timelineItem{entry: entry, order: cm.CreatedAt.UnixNano()})
+	}
+	// Sắp xếp ổn định để sự kiện và bình luận cùng thời điểm giữ nguyên thứ tự
+	sort.SliceStable(items, func(i, j int) bool { return items[i].order < items[j].order })
+
+	timeline := make([]fiber.Map, 0, len(items))
+	for _, it := range items {
+		timeline = append(timeline, it.entry)
+	}
+	return c.JSON(fiber.Map{"ticket_id": ticket.ID, "timeline": timeline})
+}
+
+// eventLabels ánh xạ ID trong lịch sử sang tên hiển thị
+type eventLabels map[string]map[string]string
+
+func (l eventLabels) label(field, value string) string {
+	if value == "" {
+		return ""
+	}
+	if names, ok := l[field]; ok {
+		if name, ok := names[value]; ok {
+			return name
+		}
+	}
+	return value
+}
+
+func ticketEventLabels(events []models.TicketEvent) eventLabels {
+	ids := map[string][]string{}
+	for _, e := range events {
+		for _, v := range []string{e.OldValue, e.NewValue} {
+			if v != "" {
+				ids[e.Field] = append(ids[e.Field], v)
+			}
+		}
+	}
+	labels := eventLabels{}
+	lookup := func(field, table string) {
+		if len(ids[field]) == 0 {
+			return
+		}
+		var rows []struct {
+			ID   uint
+			Name string
+		}
+		models.DB.Table(table).Select("id, name").Where("id IN ?", ids[field]).Scan(&rows)
+		labels[field] = map[string]string{}
+		for _, r := range rows {
+			labels[field][strconv.FormatUint(uint64(r.ID), 10)] = r.Name
+		}
+	}
+	lookup(models.TicketFieldPriority, "ticket_priorities")
+	lookup(models.TicketFieldCategory, "ticket_categories")
+	lookup(models.TicketFieldProductType, "ticket_product_types")
+	lookup(models.TicketFieldAssignee, "users")
+	lookup(models.TicketFieldTeam, "teams")
+	return labels
+}
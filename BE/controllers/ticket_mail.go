@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"awesomeProject/emailtemplate"
+	"awesomeProject/inbound"
+	"awesomeProject/models"
+	"fmt"
+	"strconv"
+)
+
+// sendTicketEmail dựng email từ mẫu theo ngôn ngữ người nhận rồi xếp hàng kèm tiêu đề nối luồng
+// (Message-ID/In-Reply-To/References) để trình đọc thư gom các email của cùng ticket,
+// và địa chỉ trả lời riêng để người nhận trả lời trực tiếp bằng email
+func sendTicketEmail(ticket models.Ticket, to models.User, key string, data map[string]interface{}) error {
+	msg, err := renderEmail(key, to.Language, data)
+	if err != nil {
+		return err
+	}
+	body := msg.HTML
+	root := "<" + inbound.ThreadRootID(ticket.ID) + ">"
+	headers := map[string]string{
+		"Message-ID":  "<" + inbound.NewMessageID(ticket.ID) + ">",
+		"In-Reply-To": root,
+		"References":  root,
+	}
+	if replyTo := inbound.ReplyAddress(ticket.ID, to.ID); replyTo != "" {
+		headers["Reply-To"] = replyTo
+		body = fmt.Sprintf(`<p style="color:#999999;font-size:12px">%s</p>`, inbound.ReplyMarker) + body
+	}
+	return queueEmail(outgoingEmail{Kind: models.EmailKindTicket, To: to.Email, Subject: msg.Subject, Body: body, Headers: headers})
+}
+
+// ticketEmailData là dữ liệu chung của các mẫu email về ticket
+func ticketEmailData(ticket models.Ticket, name string) map[string]interface{} {
+	return map[string]interface{}{"Name": name, "TicketID": ticket.ID, "Title": ticket.Title}
+}
+
+// notifyTicketOwnerStatus báo cho khách hàng khi admin/staff đổi trạng thái ticket
+func notifyTicketOwnerStatus(ticket models.Ticket, oldStatus string, actor models.User) {
+	var owner models.User
+	if err := models.DB.First(&owner, ticket.UserID).Error; err != nil {
+		return
+	}
+	notifyUsers([]models.User{owner}, actor.ID, notice{
+		Type:     models.NotifyTicketStatus,
+		Ticket:   ticket,
+		Content:  "Trạng thái ticket #" + strconv.Itoa(int(ticket.ID)) + " đã thay đổi thành '" + ticket.Status + "'",
+		Data:     models.NotificationData{TicketID: ticket.ID, Action: "status", Status: ticket.Status},
+		EmailKey: emailtemplate.TicketStatus,
+		EmailData: func(to models.User) map[string]interface{} {
+			data := ticketEmailData(ticket, to.Name)
+			data["ActorName"] = actor.Name
+			data["OldStatus"] = emailtemplate.StatusLabel(oldStatus, to.Language)
+			data["NewStatus"] = emailtemplate.StatusLabel(ticket.Status, to.Language)
+			return data
+		},
+	})
+}
+
+// ticketCreatedByEmail cho biết ticket được tạo từ email gửi đến hộp thư hỗ trợ
+func ticketCreatedByEmail(ticketID uint) bool {
+	var count int64
+	models.DB.Model(&models.InboundEmail{}).Where("ticket_id = ? AND status = ?", ticketID, models.InboundTicketCreated).Count(&count)
+	return count > 0
+}
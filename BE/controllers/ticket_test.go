@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"awesomeProject/calendar"
+	"awesomeProject/models"
+	"testing"
+	"time"
+)
+
+func TestNoResponseReminderDue(t *testing.T) {
+	office := []calendar.Interval{{Start: 9 * 60, End: 17 * 60}}
+	cal := &calendar.Calendar{
+		Location: time.UTC,
+		Hours: map[time.Weekday][]calendar.Interval{
+			time.Monday: office, time.Tuesday: office, time.Wednesday: office, time.Thursday: office, time.Friday: office,
+		},
+	}
+	// 05/01/2026 là thứ Hai
+	at := func(day, hour int) time.Time { return time.Date(2026, 1, day, hour, 0, 0, 0, time.UTC) }
+	ptr := func(v time.Time) *time.Time { return &v }
+	tests := []struct {
+		name     string
+		updated  time.Time
+		reminded *time.Time
+		now      time.Time
+		want     bool
+	}{
+		{"chưa đủ 24 giờ làm việc", at(5, 9), nil, at(7, 16), false},
+		{"đủ 24 giờ làm việc", at(5, 9), nil, at(7, 17), true},
+		{"vừa nhắc thì không nhắc lại mỗi giờ", at(5, 9), ptr(at(7, 17)), at(8, 10), false},
+		{"nhắc lại sau 24 giờ làm việc kể từ lần nhắc", at(5, 9), ptr(at(7, 17)), at(12, 17), true},
+		{"cập nhật sau lần nhắc thì tính từ lần cập nhật", at(8, 9), ptr(at(7, 17)), at(9, 17), false},
+	}
+	for _, tt := range tests {
+		tk := models.Ticket{UpdatedAt: tt.updated, LastRemindedAt: tt.reminded}
+		if got := noResponseReminderDue(tk, cal, tt.now); got != tt.want {
+			t.Errorf("%s: noResponseReminderDue = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
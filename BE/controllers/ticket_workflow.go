@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/workflow"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// respondTransitionError trả về lỗi có cấu trúc khi bước chuyển trạng thái không hợp lệ
+func respondTransitionError(c *fiber.Ctx, err error) error {
+	var te *workflow.TransitionError
+	if !errors.As(err, &te) {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Không thể cập nhật trạng thái ticket",
+			"success": false,
+		})
+	}
+	status := fiber.StatusConflict
+	switch te.Code {
+	case workflow.ErrInvalidStatus:
+		status = fiber.StatusBadRequest
+	case workflow.ErrRoleNotAllowed:
+		status = fiber.StatusForbidden
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"message": te.Error(),
+		"success": false,
+		"error":   te,
+	})
+}
+
+// UpdateMyTicketStatus cho phép khách hàng đóng hoặc mở lại ticket của mình
+func UpdateMyTicketStatus(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	ticketID := c.Params("id")
+	type StatusInput struct {
+		Status string `json:"status"`
+	}
+	var input StatusInput
+	if err := c.BodyParser(&input); err != nil || input.Status == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Dữ liệu không hợp lệ"})
+	}
+	var ticket models.Ticket
+	if err := models.DB.First(&ticket, ticketID).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Không tìm thấy ticket"})
+	}
+	if ticket.UserID != user.ID {
+		return c.Status(403).JSON(fiber.Map{"error": "Bạn không có quyền cập nhật ticket này"})
+	}
+	before := ticket
+	if err := workflow.Transition(&ticket, input.Status, workflow.RoleCustomer, time.Now()); err != nil {
+		return respondTransitionError(c, err)
+	}
+	if err := models.DB.Save(&ticket).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Không thể cập nhật ticket"})
+	}
+	recordTicketChanges(before, ticket, &user.ID)
+	// Thông báo cho nhân viên phụ trách hoặc admin
+	var recipients []models.User
+	if ticket.AssignedTo != nil {
+		models.DB.Where("id = ?", *ticket.AssignedTo).Find(&recipients)
+	} else {
+		recipients = adminUsers()
+	}
+	notifyUsers(recipients, user.ID, notice{
+		Type:    models.NotifyTicketStatus,
+		Ticket:  ticket,
+		Content: "Khách hàng đã chuyển ticket #" + strconv.Itoa(int(ticket.ID)) + " sang '" + ticket.Status + "'",
+		Data:    models.NotificationData{TicketID: ticket.ID, Action: "status", Status: ticket.Status},
+	})
+	return c.JSON(fiber.Map{"success": true, "ticket": ticket})
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/upload"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Giới hạn trên cho cấu hình dung lượng, không vượt quá BodyLimit của server
+const maxUploadPolicySizeMB = 100
+
+type uploadPolicyInput struct {
+	MaxSizeMB         int      `json:"max_size_mb"`
+	MaxFiles          int      `json:"max_files"`
+	AllowedExtensions []string `json:"allowed_extensions"`
+	AllowedMimeTypes  []string `json:"allowed_mime_types"`
+}
+
+func (in uploadPolicyInput) validate() string {
+	if in.MaxSizeMB < 1 || in.MaxSizeMB > maxUploadPolicySizeMB {
+		return "Dung lượng tối đa phải từ 1 đến 100MB"
+	}
+	if in.MaxFiles < 1 || in.MaxFiles > 50 {
+		return "Số file tối đa phải từ 1 đến 50"
+	}
+	for _, mt := range in.AllowedMimeTypes {
+		if !strings.Contains(mt, "/") {
+			return "Loại MIME không hợp lệ: " + mt
+		}
+	}
+	return ""
+}
+
+// ----------- UPLOAD POLICY -----------
+func GetUploadPolicies(c *fiber.Ctx) error {
+	var items []models.UploadPolicy
+	models.DB.Order("id").Find(&items)
+	return c.JSON(fiber.Map{"data": items})
+}
+
+func UpdateUploadPolicy(c *fiber.Ctx) error {
+	context := c.Params("context")
+	if !upload.IsValidContext(context) {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy"})
+	}
+	var input uploadPolicyInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": "Dữ liệu không hợp lệ"})
+	}
+	if msg := input.validate(); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"message": msg})
+	}
+	extensions := make([]string, 0, len(input.AllowedExtensions))
+	for _, ext := range input.AllowedExtensions {
+		if ext = upload.NormalizeExtension(ext); ext != "" {
+			extensions = append(extensions, ext)
+		}
+	}
+	item := models.UploadPolicy{Context: context}
+	models.DB.Where("context = ?", context).FirstOrInit(&item)
+	item.MaxSizeMB = input.MaxSizeMB
+	item.MaxFiles = input.MaxFiles
+	item.AllowedExtensions = strings.Join(extensions, ",")
+	item.AllowedMimeTypes = strings.ToLower(strings.Join(input.AllowedMimeTypes, ","))
+	if err := models.DB.Save(&item).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể cập nhật", "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "Cập nhật thành công", "item": item})
+}
@@ -0,0 +1,303 @@
+package controllers
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/webhook"
+	"context"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Cấu hình worker gửi webhook
+const (
+	webhookRetryBase      = 30 * time.Second // thời gian chờ sau lần lỗi đầu, nhân đôi sau mỗi lần lỗi
+	webhookRetryMax       = 6 * time.Hour    // thời gian chờ tối đa giữa hai lần gửi
+	webhookSendingTimeout = 5 * time.Minute  // lần gửi kẹt ở trạng thái sending (server dừng giữa chừng) được gửi lại
+	webhookBatchSize      = 20
+)
+
+// webhookRetryDelay: 30 giây, 1 phút, 2 phút... tối đa 6 giờ
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := webhookRetryBase
+	for i := 1; i < attempts && delay < webhookRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > webhookRetryMax {
+		delay = webhookRetryMax
+	}
+	return delay
+}
+
+// ProcessWebhookDeliveries gửi các sự kiện webhook đến hạn trong hàng đợi
+func ProcessWebhookDeliveries() {
+	now := time.Now()
+	// Lần gửi bị kẹt do server dừng khi đang gửi
+	models.DB.Model(&models.WebhookDelivery{}).
+		Where("status = ? AND updated_at < ?", models.WebhookSending, now.Add(-webhookSendingTimeout)).
+		Updates(map[string]interface{}{"status": models.WebhookFailed, "next_attempt_at": now})
+
+	var items []models.WebhookDelivery
+	models.DB.Where("status IN ? AND next_attempt_at <= ?", []string{models.WebhookPending, models.WebhookFailed}, now).
+		Order("next_attempt_at").Limit(webhookBatchSize).Find(&items)
+	for _, item := range items {
+		// Nhận lần gửi bằng cập nhật có điều kiện để không gửi trùng khi chạy nhiều server
+		claim := models.DB.Model(&models.WebhookDelivery{}).Where("id = ? AND status = ?", item.ID, item.Status).
+			Update("status", models.WebhookSending)
+		if claim.Error != nil || claim.RowsAffected == 0 {
+			continue
+		}
+		var sub models.WebhookSubscription
+		if err := models.DB.First(&sub, item.SubscriptionID).Error; err != nil || !sub.IsActive {
+			models.DB.Model(&item).Updates(map[string]interface{}{"status": models.WebhookDead, "last_error": "Webhook đã bị tắt hoặc xóa"})
+			continue
+		}
+		attemptWebhookDelivery(&item, sub)
+	}
+}
+
+// attemptWebhookDelivery gửi một lần và lưu kết quả vào nhật ký gửi
+func attemptWebhookDelivery(item *models.WebhookDelivery, sub models.WebhookSubscription) error {
+	err := sendWebhookDelivery(item, sub, time.Now())
+	models.DB.Model(item).Select("status", "attempts", "response_status", "response_body", "duration_ms",
+		"last_error", "next_attempt_at", "delivered_at").Updates(item)
+	return err
+}
+
+// sendWebhookDelivery gửi một lần và cập nhật trạng thái lần gửi: lỗi thì hẹn gửi lại, hết lượt thì chuyển dead
+func sendWebhookDelivery(item *models.WebhookDelivery, sub models.WebhookSubscription, now time.Time) error {
+	item.Attempts++
+	result, err := webhook.Default().Send(context.Background(), webhook.Request{
+		URL:        sub.URL,
+		Secret:     sub.Secret,
+		Event:      item.Event,
+		DeliveryID: strconv.Itoa(int(item.ID)),
+		Body:       []byte(item.Payload),
+	})
+	item.ResponseStatus = result.StatusCode
+	item.ResponseBody = result.Body
+	item.DurationMs = result.Duration.Milliseconds()
+	if err != nil {
+		item.Status = models.WebhookFailed
+		if item.Attempts >= item.MaxAttempts {
+			item.Status = models.WebhookDead
+			log.Printf("[WEBHOOK] Bỏ gửi sự kiện %s #%d đến %s sau %d lần: %v", item.Event, item.ID, sub.URL, item.Attempts, err)
+		}
+		item.LastError = err.Error()
+		item.NextAttemptAt = now.Add(webhookRetryDelay(item.Attempts))
+	} else {
+		item.Status = models.WebhookDelivered
+		item.LastError = ""
+		item.DeliveredAt = &now
+	}
+	return err
+}
+
+// ----------- WEBHOOK (ADMIN) -----------
+
+type webhookInput struct {
+	Name     string   `json:"name"`
+	URL      string   `json:"url"`
+	Secret   string   `json:"secret"` // để trống khi tạo thì hệ thống tự sinh, khi cập nhật thì giữ khóa cũ
+	Events   []string `json:"events"`
+	IsActive *bool    `json:"is_active"`
+}
+
+func (in *webhookInput) validate() string {
+	in.Name = strings.TrimSpace(in.Name)
+	in.URL = strings.TrimSpace(in.URL)
+	in.Secret = strings.TrimSpace(in.Secret)
+	if in.Name == "" {
+		return "Tên không hợp lệ"
+	}
+	u, err := url.Parse(in.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(in.URL) > 500 {
+		return "URL phải là địa chỉ http/https hợp lệ"
+	}
+	if in.Secret != "" && len(in.Secret) < 16 {
+		return "Khóa ký phải có ít nhất 16 ký tự"
+	}
+	for _, e := range in.Events {
+		if !webhook.IsValidEvent(e) {
+			return "Sự kiện không hợp lệ: " + e
+		}
+	}
+	return ""
+}
+
+// GetWebhooks - Danh sách webhook và các sự kiện có thể đăng ký
+func GetWebhooks(c *fiber.Ctx) error {
+	var items []models.WebhookSubscription
+	models.DB.Order("id").Find(&items)
+	return c.JSON(fiber.Map{"data": items, "events": webhook.Events})
+}
+
+// CreateWebhook - Tạo webhook. Khóa ký chỉ trả về một lần khi tạo hoặc đổi khóa.
+func CreateWebhook(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	var input webhookInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": "Dữ liệu không hợp lệ"})
+	}
+	if msg := input.validate(); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"message": msg})
+	}
+	if input.Secret == "" {
+		secret, err := webhook.GenerateSecret()
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": "Không tạo được khóa ký"})
+		}
+		input.Secret = secret
+	}
+	item := models.WebhookSubscription{
+		Name:      input.Name,
+		URL:       input.URL,
+		Secret:    input.Secret,
+		Events:    input.Events,
+		IsActive:  input.IsActive == nil || *input.IsActive,
+		CreatedBy: user.ID,
+	}
+	if err := models.DB.Create(&item).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể tạo", "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "Tạo thành công", "item": item, "secret": item.Secret})
+}
+
+// UpdateWebhook - Cập nhật webhook; gửi secret mới để đổi khóa ký
+func UpdateWebhook(c *fiber.Ctx) error {
+	var item models.WebhookSubscription
+	if err := models.DB.First(&item, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy"})
+	}
+	var input webhookInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": "Dữ liệu không hợp lệ"})
+	}
+	if msg := input.validate(); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"message": msg})
+	}
+	item.Name = input.Name
+	item.URL = input.URL
+	item.Events = input.Events
+	if input.IsActive != nil {
+		item.IsActive = *input.IsActive
+	}
+	if input.Secret != "" {
+		item.Secret = input.Secret
+	}
+	if err := models.DB.Save(&item).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể cập nhật", "error": err.Error()})
+	}
+	resp := fiber.Map{"message": "Cập nhật thành công", "item": item}
+	if input.Secret != "" {
+		resp["secret"] = item.Secret
+	}
+	return c.JSON(resp)
+}
+
+// DeleteWebhook - Xóa webhook cùng nhật ký gửi
+func DeleteWebhook(c *fiber.Ctx) error {
+	var item models.WebhookSubscription
+	if err := models.DB.First(&item, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy"})
+	}
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subscription_id = ?", item.ID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&item).Error
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể xóa", "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "Đã xóa"})
+}
+
+// TestWebhook - Gửi ngay sự kiện "ping" đến webhook và trả về kết quả (không gửi lại khi lỗi)
+func TestWebhook(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	var sub models.WebhookSubscription
+	if err := models.DB.First(&sub, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy"})
+	}
+	eventID, body, err := webhook.Encode(webhook.EventPing, fiber.Map{
+		"webhook_id": sub.ID,
+		"message":    "Sự kiện thử gửi từ trang quản trị",
+		"sent_by":    user.ID,
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không tạo được sự kiện thử"})
+	}
+	item := models.WebhookDelivery{
+		SubscriptionID: sub.ID,
+		EventID:        eventID,
+		Event:          webhook.EventPing,
+		Payload:        string(body),
+		Status:         models.WebhookSending,
+		MaxAttempts:    1,
+		NextAttemptAt:  time.Now(),
+	}
+	if err := models.DB.Create(&item).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không tạo được sự kiện thử"})
+	}
+	if err := attemptWebhookDelivery(&item, sub); err != nil {
+		return c.Status(502).JSON(fiber.Map{"message": "Gửi thử thất bại: " + err.Error(), "success": false, "item": item})
+	}
+	return c.JSON(fiber.Map{"message": "Gửi thử thành công", "success": true, "item": item})
+}
+
+// GetWebhookDeliveries - Nhật ký gửi của một webhook, lọc theo status, event
+func GetWebhookDeliveries(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	query := models.DB.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", c.Params("id"))
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if event := c.Query("event"); event != "" {
+		query = query.Where("event = ?", event)
+	}
+	var total int64
+	query.Count(&total)
+	var items []models.WebhookDelivery
+	if err := query.Order("id DESC").Limit(limit).Offset((page - 1) * limit).Find(&items).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không lấy được nhật ký gửi"})
+	}
+	return c.JSON(fiber.Map{
+		"data": items,
+		"pagination": fiber.Map{
+			"total": total,
+			"pages": int((total + int64(limit) - 1) / int64(limit)),
+		},
+	})
+}
+
+// RetryWebhookDelivery - Gửi lại sự kiện lỗi hoặc đã bỏ gửi (dead), giữ nguyên nội dung và mã lần gửi
+func RetryWebhookDelivery(c *fiber.Ctx) error {
+	var item models.WebhookDelivery
+	if err := models.DB.First(&item, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"message": "Không tìm thấy"})
+	}
+	if item.Status != models.WebhookFailed && item.Status != models.WebhookDead {
+		return c.Status(400).JSON(fiber.Map{"message": "Chỉ gửi lại được sự kiện gửi lỗi"})
+	}
+	item.Status = models.WebhookPending
+	item.Attempts = 0
+	item.MaxAttempts = models.WebhookMaxAttempts
+	item.NextAttemptAt = time.Now()
+	if err := models.DB.Model(&item).Select("status", "attempts", "max_attempts", "next_attempt_at").Updates(&item).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": "Không thể gửi lại"})
+	}
+	return c.JSON(fiber.Map{"message": "Đã đưa sự kiện vào hàng đợi gửi lại", "item": item})
+}
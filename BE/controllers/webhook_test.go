@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/webhook"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWebhookRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, webhookRetryMax},
+		{50, webhookRetryMax},
+	}
+	for _, tt := range tests {
+		if got := webhookRetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("webhookRetryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// Bên nhận kiểm tra chữ ký rồi trả lần lượt các mã trong responses
+func startWebhookReceiver(t *testing.T, secret string, responses ...int) *httptest.Server {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		if !webhook.Verify(secret, r.Header.Get(webhook.HeaderSignature), ts, body) {
+			t.Errorf("chữ ký không hợp lệ: %s", r.Header.Get(webhook.HeaderSignature))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(responses[calls])
+		calls++
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSendWebhookDelivery(t *testing.T) {
+	const secret = "whsec_0123456789abcdef"
+	now := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		responses  []int
+		want       []string
+		wantNextIn []time.Duration
+	}{
+		{
+			name:       "lỗi rồi gửi lại thành công",
+			responses:  []int{500, 200},
+			want:       []string{models.WebhookFailed, models.WebhookDelivered},
+			wantNextIn: []time.Duration{30 * time.Second},
+		},
+		{
+			name:       "lỗi đến hết lượt thì dead",
+			responses:  []int{500, 502, 404},
+			want:       []string{models.WebhookFailed, models.WebhookFailed, models.WebhookDead},
+			wantNextIn: []time.Duration{30 * time.Second, time.Minute},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := startWebhookReceiver(t, secret, tt.responses...)
+			webhook.SetDefault(&webhook.Client{HTTP: srv.Client()})
+			defer webhook.SetDefault(&webhook.Client{})
+			sub := models.WebhookSubscription{URL: srv.URL, Secret: secret, IsActive: true}
+			_, body, _ := webhook.Encode(webhook.EventTicketCreated, map[string]int{"ticket_id": 1})
+			item := models.WebhookDelivery{Event: webhook.EventTicketCreated, Payload: string(body), Status: models.WebhookPending, MaxAttempts: 3}
+			for i, want := range tt.want {
+				err := sendWebhookDelivery(&item, sub, now)
+				if item.Status != want || item.Attempts != i+1 || item.ResponseStatus != tt.responses[i] {
+					t.Fatalf("lần %d: status = %s attempts = %d response = %d, want %s", i+1, item.Status, item.Attempts, item.ResponseStatus, want)
+				}
+				if want == models.WebhookDelivered {
+					if err != nil || item.DeliveredAt == nil || item.LastError != "" {
+						t.Errorf("lần %d: err = %v delivered_at = %v last_error = %q", i+1, err, item.DeliveredAt, item.LastError)
+					}
+					continue
+				}
+				if err == nil || item.LastError == "" {
+					t.Errorf("lần %d: lỗi phải được ghi lại", i+1)
+				}
+				if i < len(tt.wantNextIn) && item.NextAttemptAt.Sub(now) != tt.wantNextIn[i] {
+					t.Errorf("lần %d: gửi lại sau %v, want %v", i+1, item.NextAttemptAt.Sub(now), tt.wantNextIn[i])
+				}
+			}
+		})
+	}
+}
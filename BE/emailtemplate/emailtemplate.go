@@ -0,0 +1,234 @@
+package emailtemplate
+
+import (
+	"awesomeProject/workflow"
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Mẫu mặc định: templates/<ngôn ngữ>/<key>.tmpl, dòng đầu "Subject: ...", một dòng trống rồi đến nội dung HTML
+//
+//go:embed templates
+var files embed.FS
+
+// DefaultLanguage dùng khi người nhận chưa chọn ngôn ngữ hoặc chọn ngôn ngữ chưa hỗ trợ
+const DefaultLanguage = "vi"
+
+// Languages là các ngôn ngữ có mẫu mặc định
+var Languages = []string{"vi", "en"}
+
+// Các loại email
+const (
+	Verification       = "verification"
+	ResetPassword      = "reset_password"
+	TicketCreated      = "ticket_created"
+	TicketCreatedAdmin = "ticket_created_admin"
+	TicketStatus       = "ticket_status"
+	TicketComment      = "ticket_comment"
+	TicketReminder     = "ticket_reminder"
+	Notification       = "notification" // thông báo chung cho loại không có mẫu riêng
+	NotificationDigest = "notification_digest"
+)
+
+// Info mô tả một loại email và dữ liệu mẫu dùng để kiểm tra, xem trước
+type Info struct {
+	Key         string                 `json:"key"`
+	Description string                 `json:"description"`
+	Sample      map[string]interface{} `json:"sample"`
+}
+
+var catalog = []Info{
+	{Verification, "Mã xác thực tài khoản", map[string]interface{}{
+		"Code": "123456",
+	}},
+	{ResetPassword, "Mã đặt lại mật khẩu", map[string]interface{}{
+		"Code": "654321",
+	}},
+	{TicketCreated, "Xác nhận ticket mới cho khách hàng", map[string]interface{}{
+		"Name": "Nguyễn Văn A", "TicketID": 42, "Title": "Không đăng nhập được",
+		"Category": "Lỗi", "ProductType": "Website", "Priority": "Cao",
+		"Description": "Tôi không đăng nhập được từ sáng nay.\nVui lòng kiểm tra giúp.",
+	}},
+	{TicketCreatedAdmin, "Thông báo ticket mới cho admin", map[string]interface{}{
+		"CustomerName": "Nguyễn Văn A", "TicketID": 42, "Title": "Không đăng nhập được",
+		"Category": "Lỗi", "ProductType": "Website", "Priority": "Cao",
+		"Description": "Tôi không đăng nhập được từ sáng nay.\nVui lòng kiểm tra giúp.",
+	}},
+	{TicketStatus, "Ticket đổi trạng thái", map[string]interface{}{
+		"Name": "Nguyễn Văn A", "TicketID": 42, "Title": "Không đăng nhập được",
+		"ActorName": "Trần Thị B", "OldStatus": workflow.StatusInProgress, "NewStatus": workflow.StatusResolved,
+	}},
+	{TicketComment, "Phản hồi mới trên ticket", map[string]interface{}{
+		"Name": "Nguyễn Văn A", "TicketID": 42, "Title": "Không đăng nhập được",
+		"AuthorName": "Trần Thị B", "Content": "Chúng tôi đã khắc phục sự cố.\nBạn vui lòng thử lại.",
+	}},
+	{TicketReminder, "Nhắc ticket quá hạn SLA hoặc chưa phản hồi (Reason: sla_breached, no_response)", map[string]interface{}{
+		"Name": "Trần Thị B", "TicketID": 42, "Title": "Không đăng nhập được",
+		"Assigned": true, "Reason": "sla_breached",
+	}},
+	{Notification, "Thông báo chung (phân công, sửa/thu hồi ticket...) khi người nhận chọn nhận qua email", map[string]interface{}{
+		"Name": "Trần Thị B", "TicketID": 42, "Title": "Không đăng nhập được",
+		"Content": "Bạn được phân công ticket #42: Không đăng nhập được",
+	}},
+	{NotificationDigest, "Email tóm tắt thông báo chưa đọc theo giờ/ngày (Frequency: hourly, daily)", map[string]interface{}{
+		"Name": "Trần Thị B", "Count": 3, "Frequency": "daily",
+		"Tickets": []map[string]interface{}{
+			{"TicketID": 42, "Title": "Không đăng nhập được", "Items": []map[string]interface{}{
+				{"Time": "09:15 16/10", "Content": "Khách hàng vừa bình luận mới trên ticket #42: Không đăng nhập được"},
+				{"Time": "10:02 16/10", "Content": "Khách hàng đã chuyển ticket #42 sang 'Đang xử lý'"},
+			}},
+			{"TicketID": 43, "Title": "Lỗi thanh toán", "Items": []map[string]interface{}{
+				{"Time": "11:30 16/10", "Content": "Bạn được phân công ticket #43: Lỗi thanh toán"},
+			}},
+		},
+	}},
+}
+
+// Catalog trả về danh sách các loại email
+func Catalog() []Info {
+	return catalog
+}
+
+func find(key string) (Info, bool) {
+	for _, info := range catalog {
+		if info.Key == key {
+			return info, true
+		}
+	}
+	return Info{}, false
+}
+
+// IsValidKey kiểm tra loại email có tồn tại
+func IsValidKey(key string) bool {
+	_, ok := find(key)
+	return ok
+}
+
+// IsValidLanguage kiểm tra ngôn ngữ được hỗ trợ
+func IsValidLanguage(lang string) bool {
+	for _, l := range Languages {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeLanguage chuẩn hóa mã ngôn ngữ ("en-US" -> "en"), ngôn ngữ chưa hỗ trợ trả về DefaultLanguage
+func NormalizeLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	if IsValidLanguage(lang) {
+		return lang
+	}
+	return DefaultLanguage
+}
+
+// Sample trả về dữ liệu mẫu của một loại email
+func Sample(key string) map[string]interface{} {
+	info, _ := find(key)
+	return info.Sample
+}
+
+// Source là nội dung mẫu theo cú pháp Go template: tiêu đề dạng văn bản, nội dung dạng HTML
+type Source struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Message là email đã dựng xong
+type Message struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+}
+
+var ErrUnknownTemplate = errors.New("không có mẫu email này")
+
+// Default đọc mẫu mặc định theo ngôn ngữ, chưa có bản dịch thì dùng DefaultLanguage
+func Default(key, lang string) (Source, error) {
+	if !IsValidKey(key) {
+		return Source{}, ErrUnknownTemplate
+	}
+	raw, err := files.ReadFile("templates/" + NormalizeLanguage(lang) + "/" + key + ".tmpl")
+	if err != nil {
+		raw, err = files.ReadFile("templates/" + DefaultLanguage + "/" + key + ".tmpl")
+		if err != nil {
+			return Source{}, ErrUnknownTemplate
+		}
+	}
+	content := strings.ReplaceAll(string(raw), "\r\n", "\n")
+	header, body, _ := strings.Cut(content, "\n\n")
+	return Source{
+		Subject: strings.TrimSpace(strings.TrimPrefix(header, "Subject:")),
+		Body:    strings.TrimSpace(body),
+	}, nil
+}
+
+// nl2br escape nội dung người dùng và giữ xuống dòng
+func nl2br(s string) htmltemplate.HTML {
+	escaped := htmltemplate.HTMLEscapeString(strings.ReplaceAll(s, "\r\n", "\n"))
+	return htmltemplate.HTML(strings.ReplaceAll(escaped, "\n", "<br>"))
+}
+
+// Render dựng email từ mẫu. Nội dung dùng html/template nên dữ liệu người dùng được escape tự động;
+// dữ liệu thiếu trường mà mẫu dùng đến sẽ báo lỗi thay vì in "<no value>".
+func Render(src Source, data interface{}) (Message, error) {
+	subjectTmpl, err := texttemplate.New("subject").Option("missingkey=error").Parse(src.Subject)
+	if err != nil {
+		return Message{}, fmt.Errorf("tiêu đề: %w", err)
+	}
+	bodyTmpl, err := htmltemplate.New("body").Option("missingkey=error").
+		Funcs(htmltemplate.FuncMap{"nl2br": nl2br}).Parse(src.Body)
+	if err != nil {
+		return Message{}, fmt.Errorf("nội dung: %w", err)
+	}
+	var subject, body bytes.Buffer
+	if err := subjectTmpl.Execute(&subject, data); err != nil {
+		return Message{}, fmt.Errorf("tiêu đề: %w", err)
+	}
+	if err := bodyTmpl.Execute(&body, data); err != nil {
+		return Message{}, fmt.Errorf("nội dung: %w", err)
+	}
+	// Tiêu đề là một dòng header, bỏ ký tự xuống dòng để tránh chèn header
+	return Message{
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		HTML:    body.String(),
+	}, nil
+}
+
+// Validate kiểm tra mẫu tùy chỉnh dựng được với dữ liệu mẫu của loại email
+func Validate(key string, src Source) error {
+	if !IsValidKey(key) {
+		return ErrUnknownTemplate
+	}
+	if strings.TrimSpace(src.Subject) == "" || strings.TrimSpace(src.Body) == "" {
+		return errors.New("tiêu đề và nội dung không được để trống")
+	}
+	_, err := Render(src, Sample(key))
+	return err
+}
+
+var statusLabels = map[string]map[string]string{
+	"en": {
+		workflow.StatusNew:        "New",
+		workflow.StatusInProgress: "In progress",
+		workflow.StatusWaiting:    "Waiting for reply",
+		workflow.StatusResolved:   "Resolved",
+		workflow.StatusClosed:     "Closed",
+	},
+}
+
+// StatusLabel dịch tên trạng thái ticket sang ngôn ngữ người nhận
+func StatusLabel(status, lang string) string {
+	if label, ok := statusLabels[NormalizeLanguage(lang)][status]; ok {
+		return label
+	}
+	return status
+}
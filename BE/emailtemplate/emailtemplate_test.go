@@ -0,0 +1,122 @@
+package emailtemplate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultTemplatesRenderWithSample(t *testing.T) {
+	for _, lang := range Languages {
+		for _, info := range Catalog() {
+			src, err := Default(info.Key, lang)
+			if err != nil {
+				t.Errorf("%s/%s: Default: %v", lang, info.Key, err)
+				continue
+			}
+			if err := Validate(info.Key, src); err != nil {
+				t.Errorf("%s/%s: Validate: %v", lang, info.Key, err)
+			}
+		}
+	}
+}
+
+func TestRenderEscapesUserContent(t *testing.T) {
+	src, err := Default(TicketComment, "vi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := Render(src, map[string]interface{}{
+		"Name":       `<img src=x onerror=alert(1)>`,
+		"TicketID":   7,
+		"Title":      `<script>alert("title")</script>`,
+		"AuthorName": "B & C",
+		"Content":    "<script>alert('content')</script>\n<b>đậm</b>",
+	})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	for _, raw := range []string{"<script>", "<img", "<b>đậm</b>"} {
+		if strings.Contains(msg.HTML, raw) {
+			t.Errorf("HTML chứa nội dung chưa escape %q:\n%s", raw, msg.HTML)
+		}
+	}
+	for _, escaped := range []string{"&lt;script&gt;alert(", "&lt;img src=x", "B &amp; C", "&lt;b&gt;đậm&lt;/b&gt;", "<br>"} {
+		if !strings.Contains(msg.HTML, escaped) {
+			t.Errorf("HTML thiếu %q:\n%s", escaped, msg.HTML)
+		}
+	}
+	// Tiêu đề là văn bản thuần, không escape HTML
+	if msg.Subject != `[Support] Ticket #7: <script>alert("title")</script>` {
+		t.Errorf("Subject = %q", msg.Subject)
+	}
+}
+
+func TestNl2br(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"dòng 1\ndòng 2", "dòng 1<br>dòng 2"},
+		{"a\r\nb", "a<br>b"},
+		{"<br>\n<script>", "&lt;br&gt;<br>&lt;script&gt;"},
+		{`"x" & 'y'`, "&#34;x&#34; &amp; &#39;y&#39;"},
+	}
+	for _, tt := range tests {
+		if got := string(nl2br(tt.in)); got != tt.want {
+			t.Errorf("nl2br(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRenderSubjectHeaderInjection(t *testing.T) {
+	src := Source{Subject: "Ticket {{.Title}}", Body: "<p>{{.Title}}</p>"}
+	tests := []struct{ title, want string }{
+		{"Lỗi\r\nBcc: attacker@example.com", "Ticket Lỗi Bcc: attacker@example.com"},
+		{"a\nb\rc", "Ticket a b c"},
+		{"  nhiều   khoảng\ttrắng  ", "Ticket nhiều khoảng trắng"},
+	}
+	for _, tt := range tests {
+		msg, err := Render(src, map[string]interface{}{"Title": tt.title})
+		if err != nil {
+			t.Fatalf("Render: %v", err)
+		}
+		if msg.Subject != tt.want || strings.ContainsAny(msg.Subject, "\r\n") {
+			t.Errorf("Subject = %q, want %q", msg.Subject, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		src  Source
+		ok   bool
+	}{
+		{"hợp lệ", TicketComment, Source{Subject: "#{{.TicketID}}", Body: "<p>{{nl2br .Content}}</p>"}, true},
+		{"trường không tồn tại trong nội dung", TicketComment, Source{Subject: "#{{.TicketID}}", Body: "<p>{{.Password}}</p>"}, false},
+		{"trường không tồn tại trong tiêu đề", TicketComment, Source{Subject: "{{.Secret}}", Body: "<p>x</p>"}, false},
+		{"trường của loại email khác", Verification, Source{Subject: "Mã", Body: "<p>{{.TicketID}}</p>"}, false},
+		{"sai cú pháp", TicketComment, Source{Subject: "x", Body: "{{.Content"}, false},
+		{"hàm không tồn tại", TicketComment, Source{Subject: "x", Body: "{{exec .Content}}"}, false},
+		{"tiêu đề rỗng", TicketComment, Source{Subject: " ", Body: "<p>x</p>"}, false},
+		{"loại email không tồn tại", "unknown", Source{Subject: "x", Body: "x"}, false},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.key, tt.src); (err == nil) != tt.ok {
+			t.Errorf("%s: Validate = %v, want ok = %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"en-US", "en"},
+		{" EN_gb ", "en"},
+		{"vi", "vi"},
+		{"fr", DefaultLanguage},
+		{"", DefaultLanguage},
+	}
+	for _, tt := range tests {
+		if got := NormalizeLanguage(tt.in); got != tt.want {
+			t.Errorf("NormalizeLanguage(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package inbound
+
+import "strings"
+
+// SenderAuthenticated cho biết địa chỉ From đã được máy chủ nhận thư xác thực (SPF, DKIM hoặc DMARC pass
+// cho đúng tên miền người gửi). Chỉ tin header Authentication-Results (RFC 8601) do máy chủ có
+// authserv-id trùng authservID thêm vào; authservID rỗng thì không tin header nào.
+func (m *Message) SenderAuthenticated(authservID string) bool {
+	authservID = strings.TrimSpace(authservID)
+	at := strings.LastIndex(m.FromAddress, "@")
+	if authservID == "" || at < 0 {
+		return false
+	}
+	domain := m.FromAddress[at+1:]
+	for _, header := range m.AuthResults {
+		parts := strings.Split(header, ";")
+		if fields := strings.Fields(parts[0]); len(fields) == 0 || !strings.EqualFold(fields[0], authservID) {
+			continue
+		}
+		for _, res := range parts[1:] {
+			if authResultPasses(res, domain) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// authResultPasses kiểm tra một kết quả dạng "dkim=pass header.d=example.com ..."
+func authResultPasses(res, domain string) bool {
+	fields := strings.Fields(strings.ToLower(res))
+	if len(fields) == 0 {
+		return false
+	}
+	method, result, _ := strings.Cut(fields[0], "=")
+	if result != "pass" || (method != "dkim" && method != "spf" && method != "dmarc") {
+		return false
+	}
+	for _, prop := range fields[1:] {
+		key, value, ok := strings.Cut(prop, "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "header.d", "header.i", "header.from", "smtp.mailfrom":
+			if i := strings.LastIndex(value, "@"); i >= 0 {
+				value = value[i+1:]
+			}
+			if strings.Trim(value, `"`) == domain {
+				return true
+			}
+		}
+	}
+	return false
+}
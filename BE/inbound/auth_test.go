@@ -0,0 +1,33 @@
+package inbound
+
+import "testing"
+
+func TestSenderAuthenticated(t *testing.T) {
+	tests := []struct {
+		name       string
+		from       string
+		authservID string
+		results    []string
+		want       bool
+	}{
+		{"DKIM pass đúng tên miền", "an@example.com", "mx.support.vn", []string{"mx.support.vn; dkim=pass header.d=example.com header.s=s1"}, true},
+		{"SPF pass theo smtp.mailfrom", "an@example.com", "mx.support.vn", []string{"mx.support.vn; spf=pass smtp.mailfrom=bounce@example.com"}, true},
+		{"DMARC pass, authserv-id khác hoa thường", "an@example.com", "MX.Support.vn", []string{"mx.support.vn 1; dmarc=pass header.from=example.com"}, true},
+		{"kết quả pass nằm sau kết quả fail", "an@example.com", "mx.support.vn", []string{"mx.support.vn; spf=fail smtp.mailfrom=example.com; dkim=pass header.d=example.com"}, true},
+		{"header của máy chủ lạ", "an@example.com", "mx.support.vn", []string{"evil.example.net; dkim=pass header.d=example.com"}, false},
+		{"authserv-id chỉ trùng tiền tố", "an@example.com", "mx.support.vn", []string{"mx.support.vn.evil.net; dkim=pass header.d=example.com"}, false},
+		{"header.d sai tên miền", "an@example.com", "mx.support.vn", []string{"mx.support.vn; dkim=pass header.d=attacker.com"}, false},
+		{"header.d là tên miền con giả", "an@example.com", "mx.support.vn", []string{"mx.support.vn; dkim=pass header.d=example.com.attacker.com"}, false},
+		{"kết quả fail", "an@example.com", "mx.support.vn", []string{"mx.support.vn; dkim=fail header.d=example.com; spf=softfail smtp.mailfrom=example.com"}, false},
+		{"phương thức khác không được tin", "an@example.com", "mx.support.vn", []string{"mx.support.vn; arc=pass header.d=example.com"}, false},
+		{"chưa cấu hình authserv-id", "an@example.com", "", []string{"mx.support.vn; dkim=pass header.d=example.com"}, false},
+		{"không có header", "an@example.com", "mx.support.vn", nil, false},
+		{"From không có tên miền", "an", "mx.support.vn", []string{"mx.support.vn; dkim=pass header.d=example.com"}, false},
+	}
+	for _, tt := range tests {
+		m := &Message{FromAddress: tt.from, AuthResults: tt.results}
+		if got := m.SenderAuthenticated(tt.authservID); got != tt.want {
+			t.Errorf("%s: SenderAuthenticated = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package inbound
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IMAPSource đọc email chưa đọc (UNSEEN) trong một hộp thư IMAP.
+// Email đã xử lý được đánh dấu \Seen, email lỗi vĩnh viễn được đánh dấu thêm \Flagged,
+// email lỗi tạm thời được giữ UNSEEN để đọc lại lần sau.
+type IMAPSource struct {
+	Address  string // host:port, vd imap.gmail.com:993
+	Username string
+	Password string
+	Mailbox  string // mặc định INBOX
+	TLS      bool   // kết nối TLS trực tiếp (cổng 993)
+}
+
+const imapTimeout = 60 * time.Second
+
+func (s *IMAPSource) Poll(handle Handler) error {
+	c, err := dialIMAP(s.Address, s.TLS)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	if _, err := c.cmd("LOGIN %s %s", quoteIMAP(s.Username), quoteIMAP(s.Password)); err != nil {
+		return err
+	}
+	mailbox := s.Mailbox
+	if mailbox == "" {
+		mailbox = "INBOX"
+	}
+	if _, err := c.cmd("SELECT %s", quoteIMAP(mailbox)); err != nil {
+		return err
+	}
+	untagged, err := c.cmd("UID SEARCH UNSEEN")
+	if err != nil {
+		return err
+	}
+	var uids []string
+	for _, r := range untagged {
+		if strings.HasPrefix(r.text, "* SEARCH") {
+			uids = append(uids, strings.Fields(strings.TrimPrefix(r.text, "* SEARCH"))...)
+		}
+	}
+	for _, uid := range uids {
+		if _, err := strconv.ParseUint(uid, 10, 32); err != nil {
+			continue
+		}
+		flags := `(\Seen)`
+		raw, err := c.fetch(uid)
+		if err == nil {
+			err = handle(raw)
+		}
+		if err != nil {
+			if isConnError(err) {
+				return err
+			}
+			if !IsPermanent(err) {
+				log.Printf("[INBOUND] Xử lý email IMAP UID %s lỗi, sẽ thử lại: %v", uid, err)
+				continue
+			}
+			log.Printf("[INBOUND] Xử lý email IMAP UID %s thất bại: %v", uid, err)
+			flags = `(\Seen \Flagged)`
+		}
+		if _, err := c.cmd("UID STORE %s +FLAGS.SILENT %s", uid, flags); err != nil {
+			return err
+		}
+	}
+	c.cmd("LOGOUT")
+	return nil
+}
+
+// imapConn là client IMAP4rev1 tối giản, chỉ đủ các lệnh cần cho việc đọc thư
+type imapConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	seq  int
+}
+
+// imapResponse là một dòng phản hồi; nội dung literal {n} được tách vào literals
+type imapResponse struct {
+	text     string
+	literals [][]byte
+}
+
+// imapConnError bọc lỗi mạng/giao thức khiến kết nối không dùng tiếp được
+type imapConnError struct{ err error }
+
+func (e *imapConnError) Error() string { return "imap: " + e.err.Error() }
+func (e *imapConnError) Unwrap() error { return e.err }
+
+func isConnError(err error) bool {
+	var ce *imapConnError
+	return errors.As(err, &ce)
+}
+
+func dialIMAP(addr string, useTLS bool) (*imapConn, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	var conn net.Conn
+	var err error
+	if useTLS {
+		host, _, _ := net.SplitHostPort(addr)
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &imapConn{conn: conn, r: bufio.NewReader(conn)}
+	greeting, err := c.readResponse()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(greeting.text, "* OK") && !strings.HasPrefix(greeting.text, "* PREAUTH") {
+		conn.Close()
+		return nil, fmt.Errorf("imap: máy chủ từ chối kết nối: %s", greeting.text)
+	}
+	return c, nil
+}
+
+func (c *imapConn) close() error {
+	return c.conn.Close()
+}
+
+// cmd gửi một lệnh và đọc đến phản hồi có tag, trả về các phản hồi untagged
+func (c *imapConn) cmd(format string, args ...interface{}) ([]imapResponse, error) {
+	c.seq++
+	tag := fmt.Sprintf("A%03d", c.seq)
+	c.conn.SetDeadline(time.Now().Add(imapTimeout))
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, fmt.Sprintf(format, args...)); err != nil {
+		return nil, &imapConnError{err}
+	}
+	var untagged []imapResponse
+	for {
+		resp, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(resp.text, tag+" ") {
+			untagged = append(untagged, resp)
+			continue
+		}
+		status := strings.TrimPrefix(resp.text, tag+" ")
+		if !strings.HasPrefix(strings.ToUpper(status), "OK") {
+			verb := strings.Fields(format)[0]
+			return untagged, fmt.Errorf("imap: lệnh %s thất bại: %s", verb, status)
+		}
+		return untagged, nil
+	}
+}
+
+// fetch lấy toàn bộ nội dung email theo UID mà không đánh dấu đã đọc
+func (c *imapConn) fetch(uid string) ([]byte, error) {
+	untagged, err := c.cmd("UID FETCH %s (BODY.PEEK[])", uid)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range untagged {
+		if strings.Contains(strings.ToUpper(r.text), "FETCH") && len(r.literals) > 0 {
+			if r.literals[0] == nil {
+				return nil, ErrTooLarge
+			}
+			return r.literals[0], nil
+		}
+	}
+	return nil, fmt.Errorf("imap: không lấy được nội dung email UID %s", uid)
+}
+
+// readResponse đọc một phản hồi, gồm cả các literal {n} nằm giữa dòng
+func (c *imapConn) readResponse() (imapResponse, error) {
+	var resp imapResponse
+	var text strings.Builder
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return resp, &imapConnError{err}
+		}
+		line = strings.TrimRight(line, "\r\n")
+		n, ok := literalSize(line)
+		if !ok {
+			text.WriteString(line)
+			resp.text = text.String()
+			return resp, nil
+		}
+		text.WriteString(line[:strings.LastIndex(line, "{")])
+		if n > MaxMessageSize {
+			// Bỏ qua email quá lớn nhưng vẫn đọc hết để giữ đồng bộ luồng dữ liệu
+			if _, err := io.CopyN(io.Discard, c.r, n); err != nil {
+				return resp, &imapConnError{err}
+			}
+			resp.literals = append(resp.literals, nil)
+			continue
+		}
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return resp, &imapConnError{err}
+		}
+		resp.literals = append(resp.literals, buf)
+	}
+}
+
+// literalSize nhận diện dòng kết thúc bằng {n} (hoặc {n+}) báo hiệu n byte dữ liệu theo sau
+func literalSize(line string) (int64, bool) {
+	if !strings.HasSuffix(line, "}") {
+		return 0, false
+	}
+	i := strings.LastIndex(line, "{")
+	if i < 0 {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(strings.TrimSuffix(line[i+1:len(line)-1], "+"), 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// quoteIMAP đặt chuỗi trong dấu nháy kép theo cú pháp IMAP
+func quoteIMAP(s string) string {
+	s = strings.NewReplacer("\r", "", "\n", "").Replace(s)
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,163 @@
+package inbound
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestLiteralSize(t *testing.T) {
+	tests := []struct {
+		line string
+		n    int64
+		ok   bool
+	}{
+		{"* 1 FETCH (UID 5 BODY[] {342}", 342, true},
+		{"* 1 FETCH (BODY[] {0}", 0, true},
+		{"A001 LOGIN {5+}", 5, true},
+		{"* OK ready", 0, false},
+		{"* OK {abc}", 0, false},
+		{"* OK {-1}", 0, false},
+		{"* OK }", 0, false},
+		{"* OK {12} trailing", 0, false},
+	}
+	for _, tt := range tests {
+		n, ok := literalSize(tt.line)
+		if n != tt.n || ok != tt.ok {
+			t.Errorf("literalSize(%q) = %d, %v, want %d, %v", tt.line, n, ok, tt.n, tt.ok)
+		}
+	}
+}
+
+// pipeIMAP trả về client nối với máy chủ giả: serve nhận phía máy chủ của kết nối
+func pipeIMAP(t *testing.T, serve func(conn net.Conn, r *bufio.Reader)) *imapConn {
+	t.Helper()
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+		serve(server, bufio.NewReader(server))
+	}()
+	t.Cleanup(func() { client.Close() })
+	return &imapConn{conn: client, r: bufio.NewReader(client)}
+}
+
+func TestReadResponseLiterals(t *testing.T) {
+	c := pipeIMAP(t, func(conn net.Conn, _ *bufio.Reader) {
+		io.WriteString(conn, "* 1 FETCH (UID 7 BODY[HEADER] {5}\r\nhello BODY[TEXT] {4}\r\n\r\n\r\n)\r\n* OK tiếp\r\n")
+	})
+	resp, err := c.readResponse()
+	if err != nil {
+		t.Fatalf("readResponse: %v", err)
+	}
+	if resp.text != "* 1 FETCH (UID 7 BODY[HEADER]  BODY[TEXT] )" {
+		t.Errorf("text = %q", resp.text)
+	}
+	if len(resp.literals) != 2 || string(resp.literals[0]) != "hello" || string(resp.literals[1]) != "\r\n\r\n" {
+		t.Errorf("literals = %q", resp.literals)
+	}
+	next, err := c.readResponse()
+	if err != nil || next.text != "* OK tiếp" {
+		t.Errorf("phản hồi kế tiếp = %q, %v", next.text, err)
+	}
+}
+
+func TestReadResponseOversizedLiteral(t *testing.T) {
+	c := pipeIMAP(t, func(conn net.Conn, _ *bufio.Reader) {
+		fmt.Fprintf(conn, "* 1 FETCH (BODY[] {%d}\r\n", MaxMessageSize+1)
+		chunk := strings.Repeat("x", 1<<20)
+		for left := MaxMessageSize + 1; left > 0; left -= len(chunk) {
+			if left < len(chunk) {
+				chunk = chunk[:left]
+			}
+			io.WriteString(conn, chunk)
+		}
+		io.WriteString(conn, ")\r\n* OK còn đồng bộ\r\n")
+	})
+	resp, err := c.readResponse()
+	if err != nil {
+		t.Fatalf("readResponse: %v", err)
+	}
+	if len(resp.literals) != 1 || resp.literals[0] != nil {
+		t.Errorf("literal quá lớn phải được bỏ qua (nil), got %d literal", len(resp.literals))
+	}
+	next, err := c.readResponse()
+	if err != nil || next.text != "* OK còn đồng bộ" {
+		t.Errorf("luồng dữ liệu lệch sau literal quá lớn: %q, %v", next.text, err)
+	}
+}
+
+func TestReadResponseTruncated(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"literal bị cắt", "* 1 FETCH (BODY[] {100}\r\nchỉ có vài byte"},
+		{"dòng không kết thúc", "* OK chưa xong"},
+		{"đóng kết nối ngay", ""},
+	}
+	for _, tt := range tests {
+		c := pipeIMAP(t, func(conn net.Conn, _ *bufio.Reader) { io.WriteString(conn, tt.data) })
+		if _, err := c.readResponse(); err == nil || !isConnError(err) {
+			t.Errorf("%s: err = %v, want lỗi kết nối", tt.name, err)
+		}
+	}
+}
+
+func TestCmdTaggedResponses(t *testing.T) {
+	c := pipeIMAP(t, func(conn net.Conn, r *bufio.Reader) {
+		replies := []string{
+			"* SEARCH 3 9\r\n* 2 EXISTS\r\nA001 OK SEARCH completed\r\n",
+			"* BYE sắp đóng\r\nA002 NO [AUTHENTICATIONFAILED] sai mật khẩu\r\n",
+			"* 1 FETCH (UID 3 BODY[] {3}\r\nabc)\r\nA003 OK done\r\n",
+			fmt.Sprintf("* 1 FETCH (UID 9 BODY[] {%d}\r\n%s)\r\nA004 OK done\r\n", MaxMessageSize+1, strings.Repeat("y", MaxMessageSize+1)),
+		}
+		for _, reply := range replies {
+			if _, err := r.ReadString('\n'); err != nil {
+				return
+			}
+			io.WriteString(conn, reply)
+		}
+	})
+
+	untagged, err := c.cmd("UID SEARCH UNSEEN")
+	if err != nil {
+		t.Fatalf("SEARCH: %v", err)
+	}
+	if len(untagged) != 2 || untagged[0].text != "* SEARCH 3 9" {
+		t.Errorf("untagged = %+v", untagged)
+	}
+
+	untagged, err = c.cmd("LOGIN %s %s", quoteIMAP("u"), quoteIMAP("p"))
+	if err == nil || isConnError(err) || !strings.Contains(err.Error(), "LOGIN") {
+		t.Errorf("LOGIN NO: err = %v, muốn lỗi lệnh (không phải lỗi kết nối)", err)
+	}
+	if len(untagged) != 1 {
+		t.Errorf("untagged khi lỗi = %+v", untagged)
+	}
+
+	raw, err := c.fetch("3")
+	if err != nil || string(raw) != "abc" {
+		t.Errorf("fetch = %q, %v", raw, err)
+	}
+
+	if _, err := c.fetch("9"); !errors.Is(err, ErrTooLarge) || !IsPermanent(err) {
+		t.Errorf("fetch email quá lớn: err = %v, want ErrTooLarge", err)
+	}
+}
+
+func TestQuoteIMAP(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"user@example.com", `"user@example.com"`},
+		{`pa"ss\word`, `"pa\"ss\\word"`},
+		{"a\r\nA002 LOGOUT", `"aA002 LOGOUT"`},
+	}
+	for _, tt := range tests {
+		if got := quoteIMAP(tt.in); got != tt.want {
+			t.Errorf("quoteIMAP(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
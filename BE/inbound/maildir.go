@@ -0,0 +1,125 @@
+package inbound
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DirSource đọc email từ thư mục trên đĩa:
+//   - Maildir (có thư mục con new/ và cur/): email trong new/ được chuyển sang cur/ sau khi xử lý,
+//     email lỗi vĩnh viễn được gắn cờ F (flagged)
+//   - Thư mục chứa file .eml: file được chuyển sang processed/ hoặc failed/ (lỗi vĩnh viễn)
+//
+// Email lỗi tạm thời được giữ nguyên chỗ để đọc lại ở lần quét sau.
+type DirSource struct {
+	Root string
+}
+
+// File .eml vừa sửa gần đây có thể chưa ghi xong nên để lần quét sau
+const dropSettleTime = 5 * time.Second
+
+func (d *DirSource) Poll(handle Handler) error {
+	if info, err := os.Stat(filepath.Join(d.Root, "new")); err == nil && info.IsDir() {
+		return d.pollMaildir(handle)
+	}
+	return d.pollDrop(handle)
+}
+
+func (d *DirSource) pollMaildir(handle Handler) error {
+	newDir := filepath.Join(d.Root, "new")
+	curDir := filepath.Join(d.Root, "cur")
+	if err := os.MkdirAll(curDir, 0755); err != nil {
+		return err
+	}
+	names, err := listFiles(newDir, "")
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		flags := "S"
+		if err := handleFile(filepath.Join(newDir, name), handle); err != nil {
+			if !IsPermanent(err) {
+				log.Printf("[INBOUND] Xử lý email %s lỗi, sẽ thử lại: %v", name, err)
+				continue
+			}
+			log.Printf("[INBOUND] Xử lý email %s thất bại: %v", name, err)
+			flags = "FS"
+		}
+		// Tên file Maildir: <tên duy nhất>:2,<cờ>
+		base := strings.SplitN(name, ":", 2)[0]
+		if err := os.Rename(filepath.Join(newDir, name), filepath.Join(curDir, base+":2,"+flags)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DirSource) pollDrop(handle Handler) error {
+	names, err := listFiles(d.Root, ".eml")
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		path := filepath.Join(d.Root, name)
+		if info, err := os.Stat(path); err != nil || time.Since(info.ModTime()) < dropSettleTime {
+			continue
+		}
+		target := "processed"
+		if err := handleFile(path, handle); err != nil {
+			if !IsPermanent(err) {
+				log.Printf("[INBOUND] Xử lý email %s lỗi, sẽ thử lại: %v", name, err)
+				continue
+			}
+			log.Printf("[INBOUND] Xử lý email %s thất bại: %v", name, err)
+			target = "failed"
+		}
+		if err := os.MkdirAll(filepath.Join(d.Root, target), 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(path, filepath.Join(d.Root, target, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listFiles liệt kê file (không gồm thư mục) theo thứ tự tên, lọc theo đuôi nếu có
+func listFiles(dir, ext string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if ext != "" && !strings.EqualFold(filepath.Ext(e.Name()), ext) {
+			continue
+		}
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func handleFile(path string, handle Handler) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	raw, err := io.ReadAll(io.LimitReader(f, MaxMessageSize+1))
+	if err != nil {
+		return err
+	}
+	if len(raw) > MaxMessageSize {
+		return ErrTooLarge
+	}
+	return handle(raw)
+}
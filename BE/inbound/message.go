@@ -0,0 +1,320 @@
+// Package inbound đọc email gửi đến hộp thư hỗ trợ (IMAP hoặc thư mục Maildir/.eml)
+// và phân tích thành Message để tạo ticket hoặc bình luận.
+package inbound
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxMessageSize là dung lượng tối đa của một email được xử lý
+const MaxMessageSize = 50 << 20
+
+// Độ sâu lồng multipart tối đa, tránh email dị dạng đệ quy vô hạn
+const maxMultipartDepth = 10
+
+var ErrTooLarge = errors.New("inbound: email vượt quá dung lượng cho phép")
+
+// Attachment là một file đính kèm trong email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Message là email đã phân tích
+type Message struct {
+	MessageID   string   // không kèm dấu <>
+	InReplyTo   []string // Message-ID mà email này trả lời
+	References  []string
+	FromName    string
+	FromAddress string // đã chuyển chữ thường
+	To          []string
+	Subject     string
+	Date        time.Time
+	Text        string // nội dung text/plain
+	HTML        string // nội dung text/html
+	Attachments []Attachment
+	AutoReply   bool     // email tự động (trả lời vắng mặt, thông báo lỗi gửi...)
+	AuthResults []string // các header Authentication-Results (kết quả SPF/DKIM của máy chủ nhận thư)
+}
+
+// Body trả về nội dung dạng văn bản: ưu tiên text/plain, nếu không có thì chuyển từ HTML
+func (m *Message) Body() string {
+	if strings.TrimSpace(m.Text) != "" {
+		return strings.TrimSpace(m.Text)
+	}
+	return HTMLToText(m.HTML)
+}
+
+// ThreadIDs trả về các Message-ID liên quan của luồng thư, mới nhất trước
+func (m *Message) ThreadIDs() []string {
+	all := append([]string{}, m.InReplyTo...)
+	for i := len(m.References) - 1; i >= 0; i-- {
+		all = append(all, m.References[i])
+	}
+	seen := make(map[string]bool, len(all))
+	ids := make([]string, 0, len(all))
+	for _, id := range all {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+var wordDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// Parse phân tích email thô theo RFC 5322/MIME
+func Parse(raw []byte) (*Message, error) {
+	if len(raw) > MaxMessageSize {
+		return nil, ErrTooLarge
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	h := msg.Header
+	m := &Message{
+		MessageID:  firstID(h.Get("Message-Id")),
+		InReplyTo:  parseIDs(h.Get("In-Reply-To")),
+		References: parseIDs(h.Get("References")),
+		Subject:    strings.TrimSpace(decodeHeader(h.Get("Subject"))),
+	}
+	if m.MessageID == "" {
+		// Email không có Message-ID: dùng mã băm nội dung để chống xử lý trùng
+		sum := sha256.Sum256(raw)
+		m.MessageID = hex.EncodeToString(sum[:16]) + "@inbound.local"
+	}
+	parser := &mail.AddressParser{WordDecoder: wordDecoder}
+	if addr, err := parser.Parse(h.Get("From")); err == nil {
+		m.FromName = strings.TrimSpace(addr.Name)
+		m.FromAddress = strings.ToLower(addr.Address)
+	}
+	for _, field := range []string{"To", "Cc", "Delivered-To", "X-Original-To"} {
+		if list, err := parser.ParseList(h.Get(field)); err == nil {
+			for _, a := range list {
+				m.To = append(m.To, strings.ToLower(a.Address))
+			}
+		}
+	}
+	if d, err := h.Date(); err == nil {
+		m.Date = d
+	} else {
+		m.Date = time.Now()
+	}
+	m.AutoReply = isAutoReply(h)
+	m.AuthResults = h["Authentication-Results"]
+
+	if err := m.walk(textproto.MIMEHeader(h), msg.Body, 0); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// walk duyệt cây MIME, lấy phần nội dung đầu tiên và các file đính kèm
+func (m *Message) walk(h textproto.MIMEHeader, body io.Reader, depth int) error {
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth >= maxMultipartDepth || params["boundary"] == "" {
+			return nil
+		}
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				// Phần cuối bị cắt cụt: giữ những gì đã đọc được
+				return nil
+			}
+			if err := m.walk(part.Header, part, depth+1); err != nil {
+				// Nội dung phần bị cắt cụt hoặc mã hóa hỏng: bỏ phần đó, giữ những gì đã đọc được
+				return nil
+			}
+		}
+	}
+
+	data, err := io.ReadAll(decodeTransfer(h.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return err
+	}
+	disposition, dparams, _ := mime.ParseMediaType(h.Get("Content-Disposition"))
+	filename := dparams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	filename = decodeHeader(filename)
+
+	isText := mediaType == "text/plain" || mediaType == "text/html"
+	if isText && disposition != "attachment" && filename == "" {
+		text := toUTF8(data, params["charset"])
+		if mediaType == "text/plain" && m.Text == "" {
+			m.Text = text
+			return nil
+		}
+		if mediaType == "text/html" && m.HTML == "" {
+			m.HTML = text
+			return nil
+		}
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if filename == "" {
+		switch {
+		case mediaType == "message/rfc822":
+			filename = "email.eml"
+		case isText:
+			// Phần nội dung thay thế thứ hai (vd text/plain lặp lại), không phải file đính kèm
+			return nil
+		default:
+			filename = "attachment" + extensionFor(mediaType)
+		}
+	}
+	m.Attachments = append(m.Attachments, Attachment{Filename: filename, ContentType: mediaType, Data: data})
+	return nil
+}
+
+func decodeTransfer(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &base64Cleaner{r: r})
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	}
+	return r
+}
+
+// base64Cleaner bỏ ký tự xuống dòng/khoảng trắng trong nội dung base64
+type base64Cleaner struct{ r io.Reader }
+
+func (b *base64Cleaner) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	j := 0
+	for i := 0; i < n; i++ {
+		switch p[i] {
+		case '\r', '\n', ' ', '\t':
+		default:
+			p[j] = p[i]
+			j++
+		}
+	}
+	return j, err
+}
+
+// charsetReader hỗ trợ các bảng mã phổ biến ngoài UTF-8 trong tiêu đề email
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	return strings.NewReader(toUTF8(data, charset)), nil
+}
+
+// toUTF8 chuyển nội dung sang UTF-8; hỗ trợ latin1/windows-1252, bảng mã khác giữ nguyên byte hợp lệ
+func toUTF8(data []byte, charset string) string {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "iso-8859-1", "latin1", "windows-1252", "cp1252":
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	}
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	return strings.ToValidUTF8(string(data), "�")
+}
+
+func decodeHeader(v string) string {
+	if decoded, err := wordDecoder.DecodeHeader(v); err == nil {
+		return decoded
+	}
+	return v
+}
+
+var idPattern = regexp.MustCompile(`<([^<>\s]+)>`)
+
+// parseIDs lấy danh sách Message-ID trong tiêu đề In-Reply-To/References
+func parseIDs(v string) []string {
+	var ids []string
+	for _, m := range idPattern.FindAllStringSubmatch(v, -1) {
+		ids = append(ids, m[1])
+	}
+	if len(ids) == 0 {
+		for _, f := range strings.Fields(v) {
+			ids = append(ids, strings.Trim(f, "<>"))
+		}
+	}
+	return ids
+}
+
+func firstID(v string) string {
+	if ids := parseIDs(v); len(ids) > 0 {
+		return ids[0]
+	}
+	return ""
+}
+
+// isAutoReply nhận diện email tự động để không tạo ticket/bình luận lặp vô hạn
+func isAutoReply(h mail.Header) bool {
+	if v := strings.ToLower(h.Get("Auto-Submitted")); v != "" && v != "no" {
+		return true
+	}
+	if h.Get("X-Autoreply") != "" || h.Get("X-Autorespond") != "" {
+		return true
+	}
+	switch strings.ToLower(h.Get("Precedence")) {
+	case "bulk", "junk", "auto_reply":
+		return true
+	}
+	return false
+}
+
+func extensionFor(mediaType string) string {
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
+
+var (
+	htmlDropBlocks = regexp.MustCompile(`(?is)<(script|style|head)[^>]*>.*?</(script|style|head)>`)
+	htmlBreaks     = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|tr|h[1-6]|blockquote)>`)
+	htmlTags       = regexp.MustCompile(`(?s)<[^>]*>`)
+	blankLines     = regexp.MustCompile(`\n{3,}`)
+)
+
+// HTMLToText chuyển nội dung HTML đơn giản thành văn bản thuần
+func HTMLToText(s string) string {
+	s = htmlDropBlocks.ReplaceAllString(s, "")
+	s = htmlBreaks.ReplaceAllString(s, "\n")
+	s = htmlTags.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(strings.ReplaceAll(l, " ", " "))
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
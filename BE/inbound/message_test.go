@@ -0,0 +1,159 @@
+package inbound
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func crlf(s string) []byte {
+	return []byte(strings.ReplaceAll(s, "\n", "\r\n"))
+}
+
+func TestParseMultipartWithAttachment(t *testing.T) {
+	raw := crlf(`From: =?UTF-8?B?Tmd1eeG7hW4gVsSDbiBB?= <An.Nguyen@Example.com>
+To: Support <support@example.com>
+Cc: other@example.com
+Subject: =?UTF-8?Q?M=C3=A1y_in_h=E1=BB=8Fng?=
+Message-ID: <abc@mail.example.com>
+In-Reply-To: <ticket-12@example.com>
+References: <ticket-12@example.com> <ticket-12-1@example.com>
+Date: Mon, 05 Jan 2026 09:00:00 +0700
+Authentication-Results: mx.example.com; dkim=pass header.d=example.com
+Content-Type: multipart/mixed; boundary="outer"
+
+--outer
+Content-Type: multipart/alternative; boundary="inner"
+
+--inner
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: quoted-printable
+
+M=C3=A1y in kh=C3=B4ng ch=E1=BA=A1y
+--inner
+Content-Type: text/html; charset=utf-8
+
+<p>Máy in không chạy</p>
+--inner--
+--outer
+Content-Type: application/pdf; name="bao-gia.pdf"
+Content-Disposition: attachment; filename="bao-gia.pdf"
+Content-Transfer-Encoding: base64
+
+JVBERi0x
+LjQK
+--outer
+Content-Type: text/plain; name="=?UTF-8?Q?ghi_ch=C3=BA.txt?="
+Content-Disposition: attachment
+
+ghi chú
+--outer--
+`)
+	m, err := Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	checks := []struct {
+		name      string
+		got, want string
+	}{
+		{"MessageID", m.MessageID, "abc@mail.example.com"},
+		{"FromName", m.FromName, "Nguyễn Văn A"},
+		{"FromAddress", m.FromAddress, "an.nguyen@example.com"},
+		{"Subject", m.Subject, "Máy in hỏng"},
+		{"Text", m.Text, "Máy in không chạy"},
+		{"HTML", strings.TrimSpace(m.HTML), "<p>Máy in không chạy</p>"},
+		{"To", strings.Join(m.To, ","), "support@example.com,other@example.com"},
+		{"ThreadIDs", strings.Join(m.ThreadIDs(), ","), "ticket-12@example.com,ticket-12-1@example.com"},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %q, want %q", c.name, c.got, c.want)
+		}
+	}
+	if len(m.AuthResults) != 1 {
+		t.Errorf("AuthResults = %v", m.AuthResults)
+	}
+	if len(m.Attachments) != 2 {
+		t.Fatalf("Attachments = %d, want 2", len(m.Attachments))
+	}
+	if a := m.Attachments[0]; a.Filename != "bao-gia.pdf" || a.ContentType != "application/pdf" || string(a.Data) != "%PDF-1.4\n" {
+		t.Errorf("attachment 0 = %q %q %q", a.Filename, a.ContentType, a.Data)
+	}
+	if a := m.Attachments[1]; a.Filename != "ghi chú.txt" || strings.TrimSpace(string(a.Data)) != "ghi chú" {
+		t.Errorf("attachment 1 = %q %q", a.Filename, a.Data)
+	}
+}
+
+func TestParseSinglePart(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       string
+		body      string
+		autoReply bool
+	}{
+		{
+			"latin1 quoted-printable",
+			"From: a@example.com\nSubject: Hi\nContent-Type: text/plain; charset=iso-8859-1\nContent-Transfer-Encoding: quoted-printable\n\nCaf=E9 cr=E8me\n",
+			"Café crème", false,
+		},
+		{
+			"chỉ có HTML",
+			"From: a@example.com\nContent-Type: text/html\n\n<html><head><style>p{}</style></head><body><p>Dòng 1</p><script>x()</script>Dòng&nbsp;2<br>3 &amp; 4</body></html>\n",
+			"Dòng 1\nDòng 2\n3 & 4", false,
+		},
+		{
+			"trả lời tự động",
+			"From: a@example.com\nAuto-Submitted: auto-replied\n\nTôi đang nghỉ phép\n",
+			"Tôi đang nghỉ phép", true,
+		},
+		{
+			"Content-Type dị dạng coi như text/plain",
+			"From: a@example.com\nPrecedence: bulk\nContent-Type: ;;;\n\nnội dung\n",
+			"nội dung", true,
+		},
+	}
+	for _, tt := range tests {
+		m, err := Parse(crlf(tt.raw))
+		if err != nil {
+			t.Fatalf("%s: Parse: %v", tt.name, err)
+		}
+		if got := m.Body(); got != tt.body {
+			t.Errorf("%s: Body = %q, want %q", tt.name, got, tt.body)
+		}
+		if m.AutoReply != tt.autoReply {
+			t.Errorf("%s: AutoReply = %v, want %v", tt.name, m.AutoReply, tt.autoReply)
+		}
+	}
+}
+
+func TestParseMessageIDFallback(t *testing.T) {
+	raw := crlf("From: a@example.com\n\nbody\n")
+	a, err := Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	b, _ := Parse(raw)
+	if a.MessageID == "" || a.MessageID != b.MessageID || !strings.HasSuffix(a.MessageID, "@inbound.local") {
+		t.Errorf("MessageID = %q / %q, muốn mã băm ổn định", a.MessageID, b.MessageID)
+	}
+}
+
+func TestParseTruncatedMultipart(t *testing.T) {
+	raw := crlf("From: a@example.com\nContent-Type: multipart/mixed; boundary=b\n\n--b\nContent-Type: text/plain\n\nphần đầu\n--b\nContent-Type: application/octet-stream\nContent-Transfer-Encoding: base64\n\nAAAA")
+	m, err := Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if m.Text != "phần đầu" {
+		t.Errorf("Text = %q", m.Text)
+	}
+}
+
+func TestParseTooLarge(t *testing.T) {
+	raw := bytes.Repeat([]byte("a"), MaxMessageSize+1)
+	if _, err := Parse(raw); !errors.Is(err, ErrTooLarge) || !IsPermanent(err) {
+		t.Errorf("Parse = %v, want ErrTooLarge", err)
+	}
+}
@@ -0,0 +1,157 @@
+package inbound
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReplyMarker được chèn đầu email gửi đi; khi khách hàng trả lời, phần từ dòng này trở xuống là lịch sử trích dẫn
+const ReplyMarker = "##- Vui lòng nhập nội dung trả lời phía trên dòng này -##"
+
+// Độ dài chữ ký (ký tự hex) trong địa chỉ trả lời
+const replySignatureLen = 16
+
+// replyDomain là tên miền dùng cho Message-ID: lấy từ địa chỉ trả lời hoặc địa chỉ gửi
+func replyDomain() string {
+	for _, addr := range []string{os.Getenv("INBOUND_REPLY_ADDRESS"), os.Getenv("SMTP_FROM"), os.Getenv("SMTP_USER")} {
+		if i := strings.LastIndex(addr, "@"); i >= 0 {
+			return strings.Trim(addr[i+1:], "<> ")
+		}
+	}
+	return "support.local"
+}
+
+// ThreadRootID là Message-ID gốc cố định của luồng email một ticket, mọi email của ticket đều tham chiếu đến nó
+func ThreadRootID(ticketID uint) string {
+	return fmt.Sprintf("ticket-%d@%s", ticketID, replyDomain())
+}
+
+// NewMessageID tạo Message-ID mới cho một email của ticket
+func NewMessageID(ticketID uint) string {
+	return fmt.Sprintf("ticket-%d-%d@%s", ticketID, time.Now().UnixNano(), replyDomain())
+}
+
+var messageIDPattern = regexp.MustCompile(`^ticket-(\d+)(?:-\d+)?@(.+)$`)
+
+// TicketFromMessageID lấy ID ticket từ Message-ID do hệ thống tạo, 0 nếu không phải
+func TicketFromMessageID(id string) uint {
+	m := messageIDPattern.FindStringSubmatch(id)
+	if m == nil || !strings.EqualFold(m[2], replyDomain()) {
+		return 0
+	}
+	n, _ := strconv.ParseUint(m[1], 10, 64)
+	return uint(n)
+}
+
+func replySecret() []byte {
+	if s := os.Getenv("INBOUND_REPLY_SECRET"); s != "" {
+		return []byte(s)
+	}
+	return []byte(os.Getenv("JWT_SECRET"))
+}
+
+func replySignature(ticketID, userID uint) string {
+	mac := hmac.New(sha256.New, replySecret())
+	fmt.Fprintf(mac, "reply:%d:%d", ticketID, userID)
+	return hex.EncodeToString(mac.Sum(nil))[:replySignatureLen]
+}
+
+// ReplyAddress tạo địa chỉ trả lời riêng cho người nhận của một ticket bằng plus-addressing,
+// vd support+12.34.<chữ ký>@example.com. Chưa cấu hình INBOUND_REPLY_ADDRESS thì trả về rỗng,
+// chưa có khóa ký thì trả về địa chỉ chung (email trả lời chỉ được nối theo Message-ID khi người gửi đã được xác thực SPF/DKIM).
+func ReplyAddress(ticketID, userID uint) string {
+	base := os.Getenv("INBOUND_REPLY_ADDRESS")
+	at := strings.LastIndex(base, "@")
+	if at <= 0 || len(replySecret()) == 0 {
+		return base
+	}
+	return fmt.Sprintf("%s+%d.%d.%s%s", base[:at], ticketID, userID, replySignature(ticketID, userID), base[at:])
+}
+
+var replyTokenPattern = regexp.MustCompile(`^[^+@]+\+(\d+)\.(\d+)\.([0-9a-f]+)@`)
+
+// ParseReplyAddress tìm địa chỉ trả lời hợp lệ trong danh sách người nhận, trả về ticket và người nhận ban đầu
+func ParseReplyAddress(addrs []string) (ticketID, userID uint, ok bool) {
+	if len(replySecret()) == 0 {
+		return 0, 0, false
+	}
+	for _, addr := range addrs {
+		m := replyTokenPattern.FindStringSubmatch(strings.ToLower(addr))
+		if m == nil {
+			continue
+		}
+		t, _ := strconv.ParseUint(m[1], 10, 64)
+		u, _ := strconv.ParseUint(m[2], 10, 64)
+		if hmac.Equal([]byte(m[3]), []byte(replySignature(uint(t), uint(u)))) {
+			return uint(t), uint(u), true
+		}
+	}
+	return 0, 0, false
+}
+
+// Các dòng mở đầu phần trích dẫn của các trình gửi thư phổ biến
+var quoteHeaderPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)^on\b.{0,200}\bwrote:\s*$`),
+	regexp.MustCompile(`(?i)^vào\s.{0,200}\sđã viết:\s*$`),
+	regexp.MustCompile(`(?i)^le\b.{0,200}\ba écrit\s*:\s*$`),
+	regexp.MustCompile(`(?i)^am\b.{0,200}\bschrieb\b.{0,100}:\s*$`),
+	regexp.MustCompile(`(?i)^-{2,}\s*original message\s*-{2,}$`),
+	regexp.MustCompile(`(?i)^-{2,}\s*thư gốc\s*-{2,}$`),
+	regexp.MustCompile(`(?i)^_{10,}$`),
+}
+
+// Khối tiêu đề kiểu Outlook: "From: ..." ngay sau là "Sent:"/"Date:"/"To:"
+var (
+	outlookFromPattern = regexp.MustCompile(`(?i)^\*?(from|từ)\*?:\s`)
+	outlookNextPattern = regexp.MustCompile(`(?i)^\*?(sent|date|to|gửi|đã gửi|ngày|đến)\*?:\s`)
+)
+
+// StripQuoted bỏ phần lịch sử trích dẫn khỏi nội dung email trả lời, chỉ giữ nội dung mới
+func StripQuoted(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	marker := strings.Trim(ReplyMarker, "#- ")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		next := ""
+		if i+1 < len(lines) {
+			next = strings.TrimSpace(lines[i+1])
+		}
+		cut := strings.Contains(trimmed, marker) || isQuoteHeader(trimmed) ||
+			// Dòng "On ... wrote:" dài có thể bị trình gửi thư ngắt thành hai dòng
+			(trimmed != "" && next != "" && isQuoteHeader(trimmed+" "+next)) ||
+			(outlookFromPattern.MatchString(trimmed) && outlookNextPattern.MatchString(next))
+		if cut {
+			lines = lines[:i]
+			break
+		}
+	}
+	kept := lines[:0]
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), ">") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	result := strings.TrimSpace(strings.Join(kept, "\n"))
+	if result == "" {
+		// Không nhận diện được nội dung mới thì giữ nguyên để không mất dữ liệu
+		return strings.TrimSpace(text)
+	}
+	return result
+}
+
+func isQuoteHeader(line string) bool {
+	for _, p := range quoteHeaderPatterns {
+		if p.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
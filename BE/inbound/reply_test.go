@@ -0,0 +1,154 @@
+package inbound
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseReplyAddress(t *testing.T) {
+	t.Setenv("INBOUND_REPLY_ADDRESS", "support@example.com")
+	t.Setenv("INBOUND_REPLY_SECRET", "bí-mật")
+	valid := ReplyAddress(12, 34)
+	if !strings.HasPrefix(valid, "support+12.34.") || !strings.HasSuffix(valid, "@example.com") {
+		t.Fatalf("ReplyAddress = %q", valid)
+	}
+	sig := strings.TrimSuffix(strings.TrimPrefix(valid, "support+12.34."), "@example.com")
+	flip := func(s string) string {
+		if s[0] == '0' {
+			return "1" + s[1:]
+		}
+		return "0" + s[1:]
+	}
+
+	tests := []struct {
+		name  string
+		addrs []string
+		ok    bool
+	}{
+		{"hợp lệ", []string{valid}, true},
+		{"chữ hoa (máy chủ đổi hoa thường)", []string{strings.ToUpper(valid)}, true},
+		{"chữ ký viết hoa một phần", []string{"Support+12.34." + strings.ToUpper(sig[:8]) + sig[8:] + "@Example.com"}, true},
+		{"nằm sau địa chỉ khác", []string{"other@example.com", valid}, true},
+		{"sửa ID ticket", []string{"support+13.34." + sig + "@example.com"}, false},
+		{"sửa ID người nhận", []string{"support+12.35." + sig + "@example.com"}, false},
+		{"sửa chữ ký", []string{"support+12.34." + flip(sig) + "@example.com"}, false},
+		{"chữ ký bị cắt ngắn", []string{"support+12.34." + sig[:8] + "@example.com"}, false},
+		{"chữ ký không phải hex", []string{"support+12.34.zzzzzzzzzzzzzzzz@example.com"}, false},
+		{"không có plus-addressing", []string{"support@example.com"}, false},
+		{"rỗng", nil, false},
+	}
+	for _, tt := range tests {
+		ticketID, userID, ok := ParseReplyAddress(tt.addrs)
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %v, want %v", tt.name, ok, tt.ok)
+			continue
+		}
+		if ok && (ticketID != 12 || userID != 34) {
+			t.Errorf("%s: = %d, %d, want 12, 34", tt.name, ticketID, userID)
+		}
+	}
+
+	// Đổi khóa ký thì địa chỉ cũ không còn hợp lệ
+	t.Setenv("INBOUND_REPLY_SECRET", "khóa-khác")
+	if _, _, ok := ParseReplyAddress([]string{valid}); ok {
+		t.Error("địa chỉ ký bằng khóa cũ vẫn được chấp nhận")
+	}
+}
+
+func TestReplyAddressWithoutSecret(t *testing.T) {
+	t.Setenv("INBOUND_REPLY_ADDRESS", "support@example.com")
+	t.Setenv("INBOUND_REPLY_SECRET", "")
+	t.Setenv("JWT_SECRET", "")
+	if got := ReplyAddress(1, 2); got != "support@example.com" {
+		t.Errorf("ReplyAddress khi chưa có khóa = %q, want địa chỉ chung", got)
+	}
+	// Không có khóa thì không tin chữ ký nào, kể cả chữ ký tính bằng khóa rỗng
+	forged := "support+1.2." + replySignature(1, 2) + "@example.com"
+	if _, _, ok := ParseReplyAddress([]string{forged}); ok {
+		t.Error("ParseReplyAddress chấp nhận chữ ký khi chưa cấu hình khóa")
+	}
+}
+
+func TestTicketFromMessageID(t *testing.T) {
+	t.Setenv("INBOUND_REPLY_ADDRESS", "support@example.com")
+	tests := []struct {
+		id   string
+		want uint
+	}{
+		{ThreadRootID(42), 42},
+		{NewMessageID(42), 42},
+		{"ticket-42@EXAMPLE.com", 42},
+		{"ticket-42@attacker.com", 0},
+		{"ticket-x@example.com", 0},
+		{"abc@example.com", 0},
+	}
+	for _, tt := range tests {
+		if got := TicketFromMessageID(tt.id); got != tt.want {
+			t.Errorf("TicketFromMessageID(%q) = %d, want %d", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestStripQuoted(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			"Gmail",
+			"Đã thử lại, vẫn lỗi.\n\nOn Mon, Jan 5, 2026 at 9:00 AM Hỗ trợ <support@example.com> wrote:\n> Bạn đã thử khởi động lại chưa?\n",
+			"Đã thử lại, vẫn lỗi.",
+		},
+		{
+			"Gmail ngắt dòng On ... wrote:",
+			"Cảm ơn.\n\nOn Mon, Jan 5, 2026 at 9:00 AM Bộ phận hỗ trợ khách hàng\n<support@example.com> wrote:\n\n> Chào bạn\n",
+			"Cảm ơn.",
+		},
+		{
+			"Gmail tiếng Việt",
+			"Vâng ạ.\n\nVào Th 2, 5 thg 1, 2026 lúc 09:00 Hỗ trợ <support@example.com> đã viết:\n> Chào bạn\n",
+			"Vâng ạ.",
+		},
+		{
+			"Outlook",
+			"Ok, đã nhận.\r\n\r\n________________________________\r\nFrom: Hỗ trợ <support@example.com>\r\nSent: Monday, January 5, 2026 9:00 AM\r\n",
+			"Ok, đã nhận.",
+		},
+		{
+			"Outlook không có đường kẻ",
+			"Ok.\n\nFrom: Hỗ trợ <support@example.com>\nSent: Monday, January 5, 2026 9:00 AM\nTo: An\nSubject: Re: Máy in\n\nNội dung cũ\n",
+			"Ok.",
+		},
+		{
+			"Outlook tiếng Việt",
+			"Dạ.\n\nTừ: Hỗ trợ <support@example.com>\nĐã gửi: Thứ Hai, 5 tháng 1, 2026 9:00\n",
+			"Dạ.",
+		},
+		{
+			"dòng đánh dấu của hệ thống",
+			"Trả lời mới\n\n" + ReplyMarker + "\nNội dung email gửi đi\n",
+			"Trả lời mới",
+		},
+		{
+			"From: trong nội dung không phải khối tiêu đề",
+			"From: phòng kế toán\nCần xuất hóa đơn.",
+			"From: phòng kế toán\nCần xuất hóa đơn.",
+		},
+		{
+			"trả lời xen kẽ trích dẫn",
+			"> câu hỏi 1\nTrả lời 1\n> câu hỏi 2\nTrả lời 2",
+			"Trả lời 1\nTrả lời 2",
+		},
+		{
+			"chỉ có trích dẫn thì giữ nguyên",
+			"> toàn bộ là trích dẫn",
+			"> toàn bộ là trích dẫn",
+		},
+	}
+	for _, tt := range tests {
+		if got := StripQuoted(tt.in); got != tt.want {
+			t.Errorf("%s: StripQuoted = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
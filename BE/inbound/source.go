@@ -0,0 +1,66 @@
+package inbound
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// Handler xử lý một email thô. Lỗi vĩnh viễn (bọc bằng Permanent: email dị dạng, quá lớn...) thì email
+// được đánh dấu xử lý thất bại để admin kiểm tra; lỗi khác (vd DB tạm thời lỗi) thì email được giữ nguyên
+// để đọc lại ở lần quét sau.
+type Handler func(raw []byte) error
+
+// permanentError đánh dấu lỗi xử lý lại cũng không thành công
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent đánh dấu lỗi không nên xử lý lại email
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err}
+}
+
+// IsPermanent cho biết lỗi là vĩnh viễn; email quá lớn luôn là lỗi vĩnh viễn
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe) || errors.Is(err, ErrTooLarge)
+}
+
+// Source là nơi nhận email gửi đến
+type Source interface {
+	// Poll đọc các email mới và gọi handle cho từng email
+	Poll(handle Handler) error
+}
+
+// FromEnv tạo nguồn email theo INBOUND_MAIL_DRIVER ("imap" hoặc "maildir"); để trống thì tắt (trả về nil)
+func FromEnv() (Source, error) {
+	switch strings.ToLower(os.Getenv("INBOUND_MAIL_DRIVER")) {
+	case "":
+		return nil, nil
+	case "maildir", "dir":
+		dir := os.Getenv("INBOUND_MAILDIR")
+		if dir == "" {
+			return nil, errors.New("inbound: thiếu INBOUND_MAILDIR")
+		}
+		return &DirSource{Root: dir}, nil
+	case "imap":
+		src := &IMAPSource{
+			Address:  os.Getenv("IMAP_ADDRESS"),
+			Username: os.Getenv("IMAP_USERNAME"),
+			Password: os.Getenv("IMAP_PASSWORD"),
+			Mailbox:  os.Getenv("IMAP_MAILBOX"),
+			TLS:      os.Getenv("IMAP_TLS") != "false",
+		}
+		if src.Address == "" || src.Username == "" {
+			return nil, errors.New("inbound: thiếu IMAP_ADDRESS hoặc IMAP_USERNAME")
+		}
+		return src, nil
+	default:
+		return nil, errors.New("inbound: driver không hỗ trợ " + os.Getenv("INBOUND_MAIL_DRIVER"))
+	}
+}
@@ -0,0 +1,124 @@
+// Package mailer gửi email của hệ thống. Driver được chọn qua biến môi trường MAIL_DRIVER:
+// "smtp" (mặc định), "file" (ghi file .eml vào MAIL_SINK_DIR) hoặc "log" (chỉ ghi log) cho môi trường phát triển/kiểm thử.
+package mailer
+
+import (
+	"errors"
+	"log"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/gomail.v2"
+)
+
+var ErrNotConfigured = errors.New("mailer: thiếu cấu hình SMTP (SMTP_HOST, SMTP_FROM hoặc SMTP_USER)")
+
+// Message là một email HTML gửi cho một người nhận
+type Message struct {
+	To      string
+	Subject string
+	HTML    string
+	Headers map[string]string // tiêu đề bổ sung (Message-ID, Reply-To...)
+}
+
+// Mailer gửi email
+type Mailer interface {
+	Send(msg Message) error
+	// Check kiểm tra cấu hình và kết nối đến máy chủ gửi thư
+	Check() error
+}
+
+var (
+	defaultMailer Mailer
+	defaultErr    error
+	defaultOnce   sync.Once
+)
+
+// Default trả về mailer cấu hình theo biến môi trường, khởi tạo một lần.
+// Cấu hình lỗi thì trả về mailer luôn báo lỗi cấu hình khi gửi để email nằm chờ trong hàng đợi.
+func Default() Mailer {
+	defaultOnce.Do(func() {
+		m, err := FromEnv()
+		if err != nil {
+			log.Printf("[MAIL] Cấu hình gửi email không hợp lệ: %v", err)
+			m = unavailable{err}
+		}
+		defaultMailer, defaultErr = m, err
+	})
+	return defaultMailer
+}
+
+// Configured trả về lỗi cấu hình của mailer mặc định, nil nếu đã cấu hình
+func Configured() error {
+	Default()
+	return defaultErr
+}
+
+// FromEnv tạo mailer theo MAIL_DRIVER
+func FromEnv() (Mailer, error) {
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = os.Getenv("SMTP_USER")
+	}
+	switch strings.ToLower(os.Getenv("MAIL_DRIVER")) {
+	case "", "smtp":
+		port, _ := strconv.Atoi(os.Getenv("SMTP_PORT"))
+		return NewSMTP(SMTPConfig{
+			Host:       os.Getenv("SMTP_HOST"),
+			Port:       port,
+			Username:   os.Getenv("SMTP_USER"),
+			Password:   os.Getenv("SMTP_PASS"),
+			From:       from,
+			Encryption: strings.ToLower(os.Getenv("SMTP_ENCRYPTION")),
+		})
+	case "file":
+		dir := os.Getenv("MAIL_SINK_DIR")
+		if dir == "" {
+			dir = "./mail/outbox"
+		}
+		return &FileSink{Dir: dir, From: from}, nil
+	case "log":
+		return &LogSink{From: from}, nil
+	default:
+		return nil, errors.New("mailer: unknown driver " + os.Getenv("MAIL_DRIVER"))
+	}
+}
+
+// From trả về địa chỉ gửi đã cấu hình (chỉ phần địa chỉ email), rỗng nếu chưa cấu hình
+func From() string {
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = os.Getenv("SMTP_USER")
+	}
+	return address(from)
+}
+
+// address lấy phần địa chỉ email từ dạng "Tên <email>"
+func address(s string) string {
+	if a, err := mail.ParseAddress(s); err == nil {
+		return a.Address
+	}
+	return strings.TrimSpace(s)
+}
+
+// build dựng email MIME, tiêu đề tiếng Việt được mã hóa theo RFC 2047
+func build(from string, msg Message) *gomail.Message {
+	m := gomail.NewMessage()
+	m.SetHeader("From", from)
+	m.SetHeader("To", msg.To)
+	m.SetHeader("Subject", msg.Subject)
+	for k, v := range msg.Headers {
+		m.SetHeader(k, v)
+	}
+	m.SetBody("text/html", msg.HTML)
+	return m
+}
+
+// unavailable là mailer khi cấu hình lỗi
+type unavailable struct{ err error }
+
+func (u unavailable) Send(Message) error { return u.err }
+func (u unavailable) Check() error       { return u.err }
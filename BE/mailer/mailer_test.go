@@ -0,0 +1,68 @@
+package mailer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(Mailer) bool
+		wantErr error
+	}{
+		{"file", map[string]string{"MAIL_DRIVER": "file", "MAIL_SINK_DIR": "/tmp/mail", "SMTP_FROM": "a@example.com"},
+			func(m Mailer) bool {
+				f, ok := m.(*FileSink)
+				return ok && f.Dir == "/tmp/mail" && f.From == "a@example.com"
+			}, nil},
+		{"file mặc định", map[string]string{"MAIL_DRIVER": "FILE", "SMTP_USER": "u@example.com"},
+			func(m Mailer) bool {
+				f, ok := m.(*FileSink)
+				return ok && f.Dir == "./mail/outbox" && f.From == "u@example.com"
+			}, nil},
+		{"log", map[string]string{"MAIL_DRIVER": "log"},
+			func(m Mailer) bool { _, ok := m.(*LogSink); return ok }, nil},
+		{"smtp", map[string]string{"SMTP_HOST": "smtp.example.com", "SMTP_FROM": "a@example.com"},
+			func(m Mailer) bool {
+				s, ok := m.(*SMTP)
+				return ok && s.cfg.Port == 587 && s.cfg.Encryption == EncryptionSTARTTLS
+			}, nil},
+		{"smtp thiếu cấu hình", map[string]string{"MAIL_DRIVER": "smtp"}, nil, ErrNotConfigured},
+		{"driver không hỗ trợ", map[string]string{"MAIL_DRIVER": "sendmail"}, nil, nil},
+	}
+	keys := []string{"MAIL_DRIVER", "MAIL_SINK_DIR", "SMTP_FROM", "SMTP_USER", "SMTP_HOST", "SMTP_PORT", "SMTP_PASS", "SMTP_ENCRYPTION"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range keys {
+				t.Setenv(k, tt.env[k])
+			}
+			m, err := FromEnv()
+			if tt.check == nil {
+				if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !tt.check(m) {
+				t.Errorf("FromEnv = %#v, %v", m, err)
+			}
+		})
+	}
+}
+
+func TestAddress(t *testing.T) {
+	tests := map[string]string{
+		"Helpdesk <support@example.com>":                      "support@example.com",
+		"support@example.com":                                 "support@example.com",
+		"  support@example.com ":                              "support@example.com",
+		"=?UTF-8?q?H=E1=BB=97_tr=E1=BB=A3?= <ht@example.com>": "ht@example.com",
+		"": "",
+	}
+	for in, want := range tests {
+		if got := address(in); got != want {
+			t.Errorf("address(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package mailer
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// FileSink ghi mỗi email thành một file .eml trong thư mục thay vì gửi đi, dùng khi phát triển/kiểm thử
+type FileSink struct {
+	Dir  string
+	From string
+}
+
+func (f *FileSink) Send(msg Message) error {
+	if err := os.MkdirAll(f.Dir, 0755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(f.Dir, time.Now().Format("20060102-150405")+"-*.eml")
+	if err != nil {
+		return err
+	}
+	if _, err := build(f.From, msg).WriteTo(file); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	return file.Close()
+}
+
+// Check kiểm tra ghi được vào thư mục
+func (f *FileSink) Check() error {
+	if err := os.MkdirAll(f.Dir, 0755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(f.Dir, ".check-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// LogSink chỉ ghi log người nhận và tiêu đề email
+type LogSink struct {
+	From string
+}
+
+func (l *LogSink) Send(msg Message) error {
+	log.Printf("[MAIL] (log) From: %s | To: %s | Subject: %s", l.From, msg.To, msg.Subject)
+	return nil
+}
+
+func (l *LogSink) Check() error {
+	return nil
+}
@@ -0,0 +1,80 @@
+package mailer
+
+import (
+	"io"
+	"mime"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSink(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	sink := &FileSink{Dir: dir, From: "Helpdesk <support@example.com>"}
+	if err := sink.Check(); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	msg := Message{
+		To:      "khach@example.com",
+		Subject: "Ticket #12: Không đăng nhập được",
+		HTML:    "<p>Xin chào, ticket của bạn đã được tiếp nhận.</p>",
+		Headers: map[string]string{"Message-ID": "<ticket-12@example.com>", "Reply-To": "reply+12@example.com"},
+	}
+	for i := 0; i < 2; i++ {
+		if err := sink.Send(msg); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 2 {
+		t.Fatalf("có %d file .eml, want 2 (mỗi email một file, file kiểm tra đã xóa)", len(files))
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("thư mục còn %d file", len(entries))
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	parsed, err := mail.ReadMessage(f)
+	if err != nil {
+		t.Fatalf("file .eml không hợp lệ: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("Subject = %q (%v), want %q", subject, err, msg.Subject)
+	}
+	headers := map[string]string{
+		"From":       "Helpdesk <support@example.com>",
+		"To":         msg.To,
+		"Message-ID": "<ticket-12@example.com>",
+		"Reply-To":   "reply+12@example.com",
+	}
+	for k, want := range headers {
+		if got := parsed.Header.Get(k); got != want {
+			t.Errorf("%s = %q, want %q", k, got, want)
+		}
+	}
+	if ct := parsed.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if body, _ := io.ReadAll(parsed.Body); len(body) == 0 {
+		t.Error("email không có nội dung")
+	}
+}
+
+func TestFileSinkUnwritableDir(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "not-a-dir")
+	os.WriteFile(file, []byte("x"), 0644)
+	sink := &FileSink{Dir: filepath.Join(file, "outbox")}
+	if err := sink.Check(); err == nil {
+		t.Error("Check phải lỗi khi không tạo được thư mục")
+	}
+	if err := sink.Send(Message{To: "a@example.com"}); err == nil {
+		t.Error("Send phải lỗi khi không tạo được thư mục")
+	}
+}
@@ -0,0 +1,141 @@
+package mailer
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// Kiểu mã hóa kết nối SMTP
+const (
+	EncryptionSTARTTLS = "starttls" // kết nối thường rồi nâng cấp bằng STARTTLS (cổng 587), bắt buộc máy chủ hỗ trợ
+	EncryptionTLS      = "tls"      // TLS ngay từ đầu (implicit TLS, cổng 465)
+	EncryptionNone     = "none"     // không mã hóa, chỉ dùng cho máy chủ nội bộ
+)
+
+const smtpTimeout = 30 * time.Second
+
+// SMTPConfig là cấu hình máy chủ SMTP
+type SMTPConfig struct {
+	Host       string
+	Port       int // mặc định 465 với tls, 587 với starttls/none
+	Username   string
+	Password   string
+	From       string
+	Encryption string // mặc định tls nếu cổng 465, ngược lại starttls
+}
+
+// SMTP gửi email qua máy chủ SMTP, mỗi email một kết nối
+type SMTP struct {
+	cfg SMTPConfig
+}
+
+func NewSMTP(cfg SMTPConfig) (*SMTP, error) {
+	if cfg.Host == "" || cfg.From == "" {
+		return nil, ErrNotConfigured
+	}
+	if cfg.Encryption == "" {
+		cfg.Encryption = EncryptionSTARTTLS
+		if cfg.Port == 465 {
+			cfg.Encryption = EncryptionTLS
+		}
+	}
+	switch cfg.Encryption {
+	case EncryptionSTARTTLS, EncryptionNone:
+		if cfg.Port == 0 {
+			cfg.Port = 587
+		}
+	case EncryptionTLS:
+		if cfg.Port == 0 {
+			cfg.Port = 465
+		}
+	default:
+		return nil, fmt.Errorf("mailer: SMTP_ENCRYPTION không hợp lệ: %s", cfg.Encryption)
+	}
+	return &SMTP{cfg: cfg}, nil
+}
+
+// dial kết nối, mã hóa và đăng nhập máy chủ SMTP
+func (s *SMTP) dial() (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	tlsConfig := &tls.Config{ServerName: s.cfg.Host}
+	var conn net.Conn
+	var err error
+	if s.cfg.Encryption == EncryptionTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(smtpTimeout))
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if s.cfg.Encryption == EncryptionSTARTTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			c.Close()
+			return nil, errors.New("mailer: máy chủ SMTP không hỗ trợ STARTTLS")
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	if s.cfg.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			c.Close()
+			return nil, errors.New("mailer: máy chủ SMTP không hỗ trợ đăng nhập (AUTH)")
+		}
+		// PlainAuth từ chối gửi mật khẩu qua kết nối không mã hóa (trừ localhost)
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (s *SMTP) Send(msg Message) error {
+	c, err := s.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if err := c.Mail(address(s.cfg.From)); err != nil {
+		return err
+	}
+	if err := c.Rcpt(address(msg.To)); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := build(s.cfg.From, msg).WriteTo(w); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// Check kết nối, mã hóa và đăng nhập máy chủ SMTP mà không gửi email
+func (s *SMTP) Check() error {
+	c, err := s.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return c.Quit()
+}
@@ -2,8 +2,11 @@ package main
 
 import (
 	"awesomeProject/background"
+	"awesomeProject/mailer"
 	"awesomeProject/models"
+	"awesomeProject/scanner"
 	"awesomeProject/server"
+	"awesomeProject/storage"
 	"log"
 
 	"github.com/joho/godotenv"
@@ -14,6 +17,17 @@ func main() {
 		log.Println("[WARN] Không tìm thấy file .env hoặc không thể load: ", err)
 	}
 	models.ConnectDatabase()
+	// Cấu hình lưu trữ hoặc quét virus file đính kèm sai thì dừng ngay khi khởi động
+	storage.Default()
+	scanner.Default()
+	// Kiểm tra kết nối máy chủ gửi email; lỗi chỉ ghi log, email vẫn nằm chờ trong hàng đợi
+	go func() {
+		if err := mailer.Default().Check(); err != nil {
+			log.Printf("[MAIL] Kiểm tra máy chủ gửi email thất bại: %v", err)
+			return
+		}
+		log.Println("[MAIL] Kết nối máy chủ gửi email thành công")
+	}()
 	app := server.NewServer()
 	background.StartBackgroundJobs()
 	log.Fatal(app.Listen(":8080"))
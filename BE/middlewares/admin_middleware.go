@@ -9,6 +9,27 @@ import (
 
 // AdminMiddleware kiểm tra quyền admin/staff
 func AdminMiddleware(c *fiber.Ctx) error {
+	// Token API của script/tích hợp: "Authorization: Token <token>", quyền theo role của user sở hữu token
+	if raw, ok := apiTokenFromHeader(c); ok {
+		user, status, message := authenticateAPIToken(c, raw)
+		if status != 0 {
+			return c.Status(status).JSON(fiber.Map{
+				"message": message,
+				"success": false,
+			})
+		}
+		if user.Role != "admin" && user.Role != "staff" {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"message": "Không có quyền truy cập trang quản trị",
+				"success": false,
+			})
+		}
+		c.Locals("user", user)
+		c.Locals("userID", user.ID)
+		c.Locals("userRole", user.Role)
+		return c.Next()
+	}
+
 	// Check Authorization header first
 	authHeader := c.Get("Authorization")
 	var token string
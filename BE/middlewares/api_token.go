@@ -0,0 +1,45 @@
+package middlewares
+
+import (
+	"awesomeProject/auth"
+	"awesomeProject/models"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// apiTokenFromHeader lấy token API từ header "Authorization: Token <token>"
+func apiTokenFromHeader(c *fiber.Ctx) (string, bool) {
+	authHeader := c.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Token ") {
+		return "", false
+	}
+	return strings.TrimSpace(authHeader[6:]), true
+}
+
+// authenticateAPIToken kiểm tra token API và quyền của token với API đang gọi.
+// Thành công thì trả về user sở hữu token, ngược lại trả về mã lỗi HTTP và thông báo.
+func authenticateAPIToken(c *fiber.Ctx, raw string) (models.User, int, string) {
+	var token models.APIToken
+	if raw == "" || models.DB.Preload("User").Where("token_hash = ?", auth.HashAPIToken(raw)).First(&token).Error != nil {
+		return models.User{}, fiber.StatusUnauthorized, "Token API không hợp lệ"
+	}
+	if !token.Active(time.Now()) {
+		return models.User{}, fiber.StatusUnauthorized, "Token API đã bị thu hồi hoặc hết hạn"
+	}
+	// User đã bị xóa thì Preload không nạp được
+	if token.User.ID == 0 {
+		return models.User{}, fiber.StatusUnauthorized, "Không tìm thấy người dùng"
+	}
+	scope := auth.RequiredScope(c.Method(), c.Path())
+	if scope == "" {
+		return models.User{}, fiber.StatusForbidden, "Token API không được dùng cho API này"
+	}
+	if !auth.ScopeAllows(token.Scopes, scope) {
+		return models.User{}, fiber.StatusForbidden, "Token API thiếu quyền " + scope
+	}
+	models.TouchAPIToken(token.ID, c.IP())
+	c.Locals("apiToken", token)
+	return token.User, 0, ""
+}
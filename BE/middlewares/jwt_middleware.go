@@ -8,6 +8,20 @@ import (
 )
 
 func JWTMiddleware(c *fiber.Ctx) error {
+	// Token API của script/tích hợp: "Authorization: Token <token>"
+	if raw, ok := apiTokenFromHeader(c); ok {
+		user, status, message := authenticateAPIToken(c, raw)
+		if status != 0 {
+			return c.Status(status).JSON(fiber.Map{
+				"message": message,
+				"success": false,
+			})
+		}
+		c.Locals("user_id", user.ID)
+		c.Locals("user", user)
+		return c.Next()
+	}
+
 	// Check Authorization header first
 	authHeader := c.Get("Authorization")
 	var token string
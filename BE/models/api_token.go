@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// APIToken là token truy cập API dài hạn của người dùng hoặc tài khoản dịch vụ.
+// Chỉ lưu SHA-256 của token; token bị thu hồi hoặc hết hạn không dùng được nữa.
+type APIToken struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	User       User       `gorm:"foreignKey:UserID" json:"-"`
+	Name       string     `gorm:"type:varchar(100);not null" json:"name"`
+	Prefix     string     `gorm:"type:varchar(20)" json:"prefix"` // phần đầu của token để nhận biết
+	TokenHash  string     `gorm:"type:char(64);uniqueIndex;not null" json:"-"`
+	Scopes     []string   `gorm:"type:text;serializer:json" json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"` // nil = không hết hạn
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `gorm:"type:varchar(45)" json:"last_used_ip"`
+	RevokedAt  *time.Time `gorm:"index" json:"revoked_at"`
+	CreatedBy  uint       `json:"created_by"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Active cho biết token còn dùng được
+func (t APIToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || now.Before(*t.ExpiresAt))
+}
+
+// TouchAPIToken ghi nhận lần dùng token; mỗi phút cập nhật tối đa một lần để giảm ghi DB
+func TouchAPIToken(id uint, ip string) {
+	now := time.Now()
+	DB.Model(&APIToken{}).Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, now.Add(-time.Minute)).
+		Updates(map[string]interface{}{"last_used_at": now, "last_used_ip": ip})
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AssignmentRule cấu hình tự động phân công ticket mới, có thể giới hạn theo loại ticket/loại sản phẩm
+type AssignmentRule struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	Name           string    `gorm:"type:varchar(100);not null" json:"name"`
+	CategoryID     *uint     `gorm:"index" json:"category_id"`
+	ProductTypeID  *uint     `gorm:"index" json:"product_type_id"`
+	Strategy       string    `gorm:"type:varchar(20);not null" json:"strategy"` // round_robin | least_loaded
+	IsActive       bool      `json:"is_active"`
+	LastAssignedTo *uint     `json:"last_assigned_to"` // dùng cho round-robin
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// AssignableStaff giới hạn query vào các user có thể được phân công ticket (admin và staff, không tính tài khoản dịch vụ)
+func AssignableStaff(db *gorm.DB) *gorm.DB {
+	return db.Where("role IN ? AND is_service_account = ?", []string{"admin", "staff"}, false)
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"awesomeProject/storage"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"mime"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Loại đối tượng sở hữu file đính kèm
+const (
+	AttachmentOwnerTicket  = "ticket"
+	AttachmentOwnerComment = "comment"
+)
+
+// Trạng thái quét virus của file đính kèm
+const (
+	ScanPending     = "pending"     // chưa quét hoặc quét lỗi, chờ quét lại
+	ScanClean       = "clean"       // sạch
+	ScanQuarantined = "quarantined" // nhiễm virus, bị cách ly
+	ScanSkipped     = "skipped"     // không bật quét virus
+)
+
+// Attachment là một file đính kèm của ticket hoặc bình luận
+type Attachment struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	OwnerType     string     `gorm:"type:varchar(20);not null;index:idx_attachments_owner" json:"owner_type"`
+	OwnerID       uint       `gorm:"not null;index:idx_attachments_owner" json:"owner_id"`
+	TicketID      uint       `gorm:"not null;index" json:"ticket_id"` // ticket chứa file, dùng cho kiểm tra quyền
+	OriginalName  string     `gorm:"type:varchar(255);not null" json:"original_name"`
+	StoredName    string     `gorm:"type:varchar(255);not null" json:"stored_name"`
+	Path          string     `gorm:"type:varchar(255);not null" json:"path"` // đường dẫn tương đối, vd uploads/tickets/ticket_123.pdf
+	Size          int64      `json:"size"`
+	MimeType      string     `gorm:"type:varchar(100)" json:"mime_type"`
+	Checksum      string     `gorm:"type:varchar(64);index" json:"checksum"` // sha256 hex
+	UploadedBy    *uint      `gorm:"index" json:"uploaded_by"`
+	Uploader      *User      `gorm:"foreignKey:UploadedBy" json:"-"`
+	ScanStatus    string     `gorm:"type:varchar(20);default:pending;index" json:"scan_status"`
+	ScanSignature string     `gorm:"type:varchar(255)" json:"scan_signature,omitempty"`
+	ScannedAt     *time.Time `json:"scanned_at"`
+	ThumbnailPath string     `gorm:"type:varchar(255)" json:"thumbnail_path"` // ảnh thu nhỏ JPEG, rỗng nếu không tạo được
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// IsAvailable cho biết file đã qua kiểm tra virus và được phép tải
+func (a Attachment) IsAvailable() bool {
+	return a.ScanStatus == ScanClean || a.ScanStatus == ScanSkipped
+}
+
+// AttachmentsVisibleTo giới hạn file đính kèm theo vai trò: khách hàng chỉ thấy file đã qua kiểm tra virus,
+// admin/staff thấy cả file đang chờ quét và bị cách ly (kèm trạng thái)
+func AttachmentsVisibleTo(role string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if role == "admin" || role == "staff" {
+			return db
+		}
+		return db.Where("scan_status IN ?", []string{ScanClean, ScanSkipped})
+	}
+}
+
+// URL trả về đường dẫn công khai của file
+func (a Attachment) URL() string {
+	return "/" + a.Path
+}
+
+// AttachmentsOf giới hạn query vào file đính kèm của một đối tượng
+func AttachmentsOf(ownerType string, ownerID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("owner_type = ? AND owner_id = ?", ownerType, ownerID)
+	}
+}
+
+// migrateLegacyAttachments chuyển giá trị attachment_path cũ (một file) của ticket/bình luận sang bảng attachments.
+// File được đọc qua kho lưu trữ đã cấu hình (STORAGE_DRIVER, STORAGE_LOCAL_ROOT) thay vì thư mục đang chạy.
+// Chạy nhiều lần không tạo bản ghi trùng.
+func migrateLegacyAttachments(db *gorm.DB) {
+	var tickets []Ticket
+	db.Where("attachment_path IS NOT NULL AND attachment_path <> ''").
+		Where("NOT EXISTS (SELECT 1 FROM attachments a WHERE a.owner_type = ? AND a.owner_id = tickets.id)", AttachmentOwnerTicket).
+		Find(&tickets)
+	for _, t := range tickets {
+		uploader := t.UserID
+		createLegacyAttachment(db, AttachmentOwnerTicket, t.ID, t.ID, &uploader, t.AttachmentPath, t.CreatedAt)
+	}
+
+	var comments []TicketComment
+	db.Where("attachment_path IS NOT NULL AND attachment_path <> ''").
+		Where("NOT EXISTS (SELECT 1 FROM attachments a WHERE a.owner_type = ? AND a.owner_id = ticket_comments.id)", AttachmentOwnerComment).
+		Find(&comments)
+	for _, cm := range comments {
+		uploader := cm.UserID
+		createLegacyAttachment(db, AttachmentOwnerComment, cm.ID, cm.TicketID, &uploader, cm.AttachmentPath, cm.CreatedAt)
+	}
+
+	if len(tickets)+len(comments) > 0 {
+		log.Printf("Migrated %d legacy attachments", len(tickets)+len(comments))
+	}
+}
+
+func createLegacyAttachment(db *gorm.DB, ownerType string, ownerID, ticketID uint, uploader *uint, legacyPath string, createdAt time.Time) {
+	// Dữ liệu cũ lưu cả "/uploads/tickets/x" lẫn "uploads/comments/x"
+	path := filepath.ToSlash(strings.TrimPrefix(legacyPath, "/"))
+	name := filepath.Base(path)
+	a := Attachment{
+		OwnerType:    ownerType,
+		OwnerID:      ownerID,
+		TicketID:     ticketID,
+		OriginalName: name,
+		StoredName:   name,
+		Path:         path,
+		MimeType:     mime.TypeByExtension(filepath.Ext(name)),
+		UploadedBy:   uploader,
+		CreatedAt:    createdAt,
+	}
+	// File cũ được tải lên trước khi có quét virus: ghi rõ là bỏ qua quét thay vì để pending,
+	// file không còn trong kho lưu trữ cũng không có gì để quét
+	now := time.Now()
+	a.ScanStatus = ScanSkipped
+	a.ScannedAt = &now
+	if rc, info, err := storage.Default().Get(path); err == nil {
+		h := sha256.New()
+		if n, err := io.Copy(h, rc); err == nil {
+			a.Size = n
+			a.Checksum = hex.EncodeToString(h.Sum(nil))
+		}
+		if info != nil && info.ContentType != "" && a.MimeType == "" {
+			a.MimeType = info.ContentType
+		}
+		rc.Close()
+	} else {
+		log.Printf("Không đọc được file đính kèm cũ %s: %v", legacyPath, err)
+	}
+	if err := db.Create(&a).Error; err != nil {
+		log.Printf("Không thể chuyển file đính kèm %s: %v", legacyPath, err)
+	}
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BusinessCalendar là lịch làm việc dùng để tính SLA, nhắc nhở và thống kê theo giờ hành chính
+type BusinessCalendar struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	Name      string         `gorm:"type:varchar(100);not null" json:"name"`
+	Timezone  string         `gorm:"type:varchar(64);not null" json:"timezone"`
+	Hours     []BusinessHour `gorm:"foreignKey:CalendarID" json:"hours"`
+	Holidays  []Holiday      `gorm:"foreignKey:CalendarID" json:"holidays"`
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BusinessHour là một khung giờ làm việc trong tuần, ví dụ thứ hai 08:00-12:00
+type BusinessHour struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	CalendarID uint   `gorm:"not null;index" json:"calendar_id"`
+	Weekday    int    `gorm:"not null" json:"weekday"`                    // 0 = Chủ nhật, 1 = Thứ hai, ..., 6 = Thứ bảy
+	StartTime  string `gorm:"type:varchar(5);not null" json:"start_time"` // HH:MM
+	EndTime    string `gorm:"type:varchar(5);not null" json:"end_time"`   // HH:MM, cho phép 24:00
+}
+
+// Holiday là ngày nghỉ của lịch làm việc
+type Holiday struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	CalendarID uint      `gorm:"not null;uniqueIndex:idx_holiday_calendar_date" json:"calendar_id"`
+	Date       string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_holiday_calendar_date" json:"date"` // YYYY-MM-DD
+	Name       string    `gorm:"type:varchar(255)" json:"name"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// DefaultBusinessCalendarID là lịch làm việc đang được áp dụng
+const DefaultBusinessCalendarID = 1
+
+// seedDefaultBusinessCalendar tạo lịch giờ hành chính Việt Nam nếu chưa có
+func seedDefaultBusinessCalendar(db *gorm.DB) {
+	var count int64
+	db.Model(&BusinessCalendar{}).Count(&count)
+	if count > 0 {
+		return
+	}
+	cal := BusinessCalendar{ID: DefaultBusinessCalendarID, Name: "Giờ hành chính", Timezone: "Asia/Ho_Chi_Minh"}
+	for weekday := 1; weekday <= 5; weekday++ {
+		cal.Hours = append(cal.Hours,
+			BusinessHour{Weekday: weekday, StartTime: "08:00", EndTime: "12:00"},
+			BusinessHour{Weekday: weekday, StartTime: "13:00", EndTime: "17:00"},
+		)
+	}
+	db.Create(&cal)
+}
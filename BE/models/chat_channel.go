@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// ChatChannel là một kênh Slack/Mattermost nhận thông báo ticket qua incoming webhook
+type ChatChannel struct {
+	ID         uint        `gorm:"primaryKey" json:"id"`
+	Name       string      `gorm:"type:varchar(100);not null" json:"name"`
+	Provider   string      `gorm:"type:varchar(20);not null" json:"provider"` // slack, mattermost
+	WebhookURL string      `gorm:"type:varchar(500);not null" json:"webhook_url"`
+	IsActive   bool        `json:"is_active"`
+	Routes     []ChatRoute `gorm:"foreignKey:ChannelID" json:"routes"`
+	CreatedAt  time.Time   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// ChatRoute chọn sự kiện được đăng lên kênh theo loại ticket và/hoặc mức ưu tiên;
+// để trống loại hoặc mức ưu tiên là áp dụng cho mọi giá trị
+type ChatRoute struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	ChannelID  uint   `gorm:"not null;index" json:"channel_id"`
+	Event      string `gorm:"type:varchar(30);not null" json:"event"` // ticket_created, sla_reminder
+	CategoryID *uint  `gorm:"index" json:"category_id"`
+	PriorityID *uint  `gorm:"index" json:"priority_id"`
+}
+
+// Matches cho biết sự kiện trên ticket khớp luật này
+func (r ChatRoute) Matches(event string, t Ticket) bool {
+	return r.Event == event &&
+		(r.CategoryID == nil || *r.CategoryID == t.CategoryID) &&
+		(r.PriorityID == nil || *r.PriorityID == t.PriorityID)
+}
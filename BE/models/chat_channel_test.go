@@ -0,0 +1,28 @@
+package models
+
+import "testing"
+
+func TestChatRouteMatches(t *testing.T) {
+	id := func(v uint) *uint { return &v }
+	ticket := Ticket{CategoryID: 2, PriorityID: 3}
+	tests := []struct {
+		name  string
+		route ChatRoute
+		event string
+		want  bool
+	}{
+		{"mọi ticket", ChatRoute{Event: "ticket_created"}, "ticket_created", true},
+		{"khác sự kiện", ChatRoute{Event: "sla_reminder"}, "ticket_created", false},
+		{"đúng loại", ChatRoute{Event: "ticket_created", CategoryID: id(2)}, "ticket_created", true},
+		{"sai loại", ChatRoute{Event: "ticket_created", CategoryID: id(5)}, "ticket_created", false},
+		{"đúng mức ưu tiên", ChatRoute{Event: "sla_reminder", PriorityID: id(3)}, "sla_reminder", true},
+		{"sai mức ưu tiên", ChatRoute{Event: "sla_reminder", PriorityID: id(1)}, "sla_reminder", false},
+		{"đúng cả loại và mức ưu tiên", ChatRoute{Event: "ticket_created", CategoryID: id(2), PriorityID: id(3)}, "ticket_created", true},
+		{"đúng loại nhưng sai mức ưu tiên", ChatRoute{Event: "ticket_created", CategoryID: id(2), PriorityID: id(1)}, "ticket_created", false},
+	}
+	for _, tt := range tests {
+		if got := tt.route.Matches(tt.event, ticket); got != tt.want {
+			t.Errorf("%s: Matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -24,13 +24,38 @@ func ConnectDatabase() {
 	}
 
 	database.AutoMigrate(&User{})
+	migrateTicketSLA(database)
 	database.AutoMigrate(&Ticket{})
 	database.AutoMigrate(&TicketComment{})
+	database.AutoMigrate(&TicketEvent{})
+	migrateNotificationData(database)
 	database.AutoMigrate(&Notification{})
 	database.AutoMigrate(&KnowledgeBase{})
 	database.AutoMigrate(&TicketCategory{})
 	database.AutoMigrate(&TicketProductType{})
 	database.AutoMigrate(&TicketPriority{})
+	database.AutoMigrate(&SLAPolicy{})
+	database.AutoMigrate(&BusinessCalendar{})
+	database.AutoMigrate(&BusinessHour{})
+	database.AutoMigrate(&Holiday{})
+	database.AutoMigrate(&AssignmentRule{})
+	database.AutoMigrate(&Team{})
+	database.AutoMigrate(&TeamRoute{})
+	database.AutoMigrate(&Attachment{})
+	database.AutoMigrate(&UploadPolicy{})
+	database.AutoMigrate(&InboundEmail{})
+	database.AutoMigrate(&EmailOutbox{})
+	database.AutoMigrate(&EmailTemplate{})
+	database.AutoMigrate(&NotificationPreference{})
+	database.AutoMigrate(&DigestSetting{})
+	database.AutoMigrate(&WebhookSubscription{})
+	database.AutoMigrate(&WebhookDelivery{})
+	database.AutoMigrate(&ChatChannel{})
+	database.AutoMigrate(&ChatRoute{})
+	database.AutoMigrate(&APIToken{})
+	seedDefaultBusinessCalendar(database)
+	seedUploadPolicies(database)
+	migrateLegacyAttachments(database)
 
 	DB = database
 
@@ -60,5 +85,8 @@ func createOptimizationIndexes() {
 	// Composite index cho query thống kê theo product_type_id và assigned_to
 	DB.Exec("CREATE INDEX IF NOT EXISTS idx_tickets_product_assigned ON tickets(product_type_id, assigned_to)")
 
+	// Composite index cho hàng đợi của team
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_tickets_team_status ON tickets(team_id, status)")
+
 	log.Println("Database indexes created successfully")
 }
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Tần suất gửi email tóm tắt thông báo
+const (
+	DigestOff    = "off"    // gửi email ngay cho từng thông báo
+	DigestHourly = "hourly" // tóm tắt mỗi giờ
+	DigestDaily  = "daily"  // tóm tắt mỗi ngày vào Hour giờ theo Timezone
+)
+
+// DefaultDigestTimezone là múi giờ mặc định khi người dùng chưa chọn
+const DefaultDigestTimezone = "Asia/Ho_Chi_Minh"
+
+// DigestSetting là cài đặt email tóm tắt thông báo của một người dùng. Khi bật, thông báo muốn nhận qua email
+// được lưu trong ứng dụng và gộp vào một email theo lịch thay vì gửi từng email.
+type DigestSetting struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;uniqueIndex" json:"user_id"`
+	Frequency  string     `gorm:"type:varchar(10);default:off;index" json:"frequency"`
+	Hour       int        `json:"hour"` // giờ gửi bản tóm tắt hằng ngày (0-23)
+	Timezone   string     `gorm:"type:varchar(64);default:Asia/Ho_Chi_Minh" json:"timezone"`
+	LastSentAt *time.Time `json:"last_sent_at"` // mốc cuối đã tóm tắt, thông báo sau mốc này vào bản tóm tắt kế tiếp
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Trạng thái email trong hàng đợi gửi
+const (
+	EmailPending = "pending" // chờ gửi lần đầu
+	EmailSending = "sending" // worker đang gửi
+	EmailSent    = "sent"    // đã gửi thành công
+	EmailFailed  = "failed"  // gửi lỗi, chờ gửi lại theo NextAttemptAt
+	EmailDead    = "dead"    // hết số lần thử, chỉ gửi lại khi admin yêu cầu
+)
+
+// Loại email
+const (
+	EmailKindTicket        = "ticket"
+	EmailKindVerification  = "verification"
+	EmailKindResetPassword = "reset_password"
+	EmailKindDigest        = "digest"
+)
+
+// SensitiveEmailKind cho biết email chứa mã bí mật (xác thực, đặt lại mật khẩu):
+// nội dung bị xóa ngay khi gửi xong
+func SensitiveEmailKind(kind string) bool {
+	return kind == EmailKindVerification || kind == EmailKindResetPassword
+}
+
+// EmailOutbox là một email chờ gửi/đã gửi. Mọi email của hệ thống được ghi vào bảng này trước,
+// worker gửi qua SMTP và gửi lại với thời gian chờ tăng dần khi lỗi.
+type EmailOutbox struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Kind          string     `gorm:"type:varchar(50);index" json:"kind"`
+	ToAddress     string     `gorm:"type:varchar(255);not null;index" json:"to_address"`
+	Subject       string     `gorm:"type:varchar(255)" json:"subject"`
+	Body          string     `gorm:"type:mediumtext" json:"-"` // không trả về qua API: email xác thực/đặt lại mật khẩu chứa mã bí mật
+	Headers       string     `gorm:"type:text" json:"-"`       // JSON object các tiêu đề bổ sung (Message-ID, Reply-To...)
+	Status        string     `gorm:"type:varchar(20);default:pending;index:idx_email_outbox_due" json:"status"`
+	Attempts      int        `gorm:"default:0" json:"attempts"`
+	MaxAttempts   int        `gorm:"default:8" json:"max_attempts"`
+	NextAttemptAt time.Time  `gorm:"index:idx_email_outbox_due" json:"next_attempt_at"`
+	LastError     string     `gorm:"type:text" json:"last_error"`
+	SentAt        *time.Time `json:"sent_at"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// HeaderMap đọc các tiêu đề bổ sung
+func (e EmailOutbox) HeaderMap() map[string]string {
+	headers := map[string]string{}
+	if e.Headers != "" {
+		json.Unmarshal([]byte(e.Headers), &headers)
+	}
+	return headers
+}
+
+// SetHeaders lưu các tiêu đề bổ sung dạng JSON
+func (e *EmailOutbox) SetHeaders(headers map[string]string) {
+	if len(headers) == 0 {
+		e.Headers = ""
+		return
+	}
+	data, _ := json.Marshal(headers)
+	e.Headers = string(data)
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// EmailTemplate là mẫu email do admin tùy chỉnh, thay cho mẫu mặc định trong package emailtemplate
+type EmailTemplate struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Key       string    `gorm:"column:template_key;type:varchar(50);not null;uniqueIndex:idx_email_template_key_lang" json:"key"`
+	Language  string    `gorm:"type:varchar(5);not null;uniqueIndex:idx_email_template_key_lang" json:"language"`
+	Subject   string    `gorm:"type:varchar(255);not null" json:"subject"`
+	Body      string    `gorm:"type:mediumtext;not null" json:"body"`
+	UpdatedBy uint      `json:"updated_by"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Kết quả xử lý email gửi đến
+const (
+	InboundTicketCreated = "ticket_created" // tạo ticket mới
+	InboundCommentAdded  = "comment_added"  // thêm bình luận vào ticket có sẵn
+	InboundIgnored       = "ignored"        // bỏ qua (email tự động, email do hệ thống gửi...)
+)
+
+// InboundEmail ghi lại email đã nhận từ hộp thư hỗ trợ, dùng để chống xử lý trùng
+// và nối email trả lời vào đúng ticket theo Message-ID
+type InboundEmail struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	MessageID   string    `gorm:"type:varchar(255);uniqueIndex;not null" json:"message_id"`
+	FromAddress string    `gorm:"type:varchar(255);index" json:"from_address"`
+	Subject     string    `gorm:"type:varchar(255)" json:"subject"`
+	UserID      *uint     `gorm:"index" json:"user_id"`
+	TicketID    *uint     `gorm:"index" json:"ticket_id"`
+	CommentID   *uint     `json:"comment_id"`
+	Status      string    `gorm:"type:varchar(20);not null" json:"status"`
+	Note        string    `gorm:"type:varchar(500)" json:"note"`
+	ReceivedAt  time.Time `json:"received_at"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// Loại thông báo
+const (
+	NotifyTicketNew             = "ticket_new"
+	NotifyTicketComment         = "ticket_comment"
+	NotifyTicketInternalNote    = "ticket_internal_note"
+	NotifyTicketStatus          = "ticket_status"
+	NotifyTicketUpdate          = "ticket_update"
+	NotifyTicketDelete          = "ticket_delete"
+	NotifyTicketAssign          = "ticket_assign"
+	NotifyTicketReminder        = "ticket_reminder"
+	NotifyAttachmentQuarantined = "attachment_quarantined"
+)
+
+// NotificationTypeInfo mô tả một loại thông báo và vai trò nhận được loại này
+type NotificationTypeInfo struct {
+	Type        string
+	Description string
+	Roles       []string
+}
+
+var staffRoles = []string{"admin", "staff"}
+
+// NotificationTypes là các loại thông báo người dùng có thể tùy chỉnh
+var NotificationTypes = []NotificationTypeInfo{
+	{NotifyTicketNew, "Ticket mới (khách hàng: email xác nhận ticket vừa tạo)", []string{"admin", "customer"}},
+	{NotifyTicketComment, "Bình luận mới trên ticket", []string{"admin", "staff", "customer"}},
+	{NotifyTicketInternalNote, "Ghi chú nội bộ mới", staffRoles},
+	{NotifyTicketStatus, "Ticket đổi trạng thái", []string{"admin", "staff", "customer"}},
+	{NotifyTicketUpdate, "Khách hàng sửa ticket", []string{"admin"}},
+	{NotifyTicketDelete, "Khách hàng thu hồi ticket", []string{"admin"}},
+	{NotifyTicketAssign, "Được phân công ticket", staffRoles},
+	{NotifyTicketReminder, "Nhắc ticket quá hạn SLA hoặc chưa phản hồi", staffRoles},
+	{NotifyAttachmentQuarantined, "File đính kèm bị cách ly do có virus", []string{"admin"}},
+}
+
+// NotificationPreference là lựa chọn kênh nhận một loại thông báo của người dùng.
+// Không có bản ghi thì dùng DefaultNotificationPreference.
+type NotificationPreference struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_notification_pref_user_type" json:"user_id"`
+	Type      string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_notification_pref_user_type" json:"type"`
+	InApp     bool      `json:"in_app"` // thông báo trong ứng dụng
+	Email     bool      `json:"email"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// DefaultNotificationPreference là kênh nhận mặc định: khách hàng nhận email về ticket của mình,
+// nhân viên nhận email ticket mới và nhắc quá hạn, còn lại chỉ thông báo trong ứng dụng
+func DefaultNotificationPreference(notifType, role string) (inApp, email bool) {
+	switch notifType {
+	case NotifyTicketReminder:
+		return false, true
+	case NotifyTicketNew:
+		return true, true
+	case NotifyTicketComment, NotifyTicketStatus:
+		return true, role == "customer"
+	}
+	return true, false
+}
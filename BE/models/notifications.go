@@ -1,13 +1,68 @@
 package models
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Notification struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	UserID    uint      `gorm:"not null;index" json:"user_id"`
-	Type      string    `gorm:"type:varchar(50);not null" json:"type"`
-	Content   string    `gorm:"type:text;not null" json:"content"`
-	Data      string    `gorm:"type:text" json:"data"` // JSON string, optional
-	IsRead    bool      `gorm:"default:false" json:"is_read"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ID         uint             `gorm:"primaryKey" json:"id"`
+	UserID     uint             `gorm:"not null;index" json:"user_id"`
+	Type       string           `gorm:"type:varchar(50);not null" json:"type"`
+	Content    string           `gorm:"type:text;not null" json:"content"`
+	Data       NotificationData `gorm:"type:json" json:"data"`
+	IsRead     bool             `gorm:"default:false" json:"is_read"`
+	ArchivedAt *time.Time       `gorm:"index" json:"archived_at"` // đã lưu trữ: ẩn khỏi hộp thông báo nhưng chưa xóa
+	DigestOnly bool             `gorm:"default:false" json:"-"`   // chỉ để gộp vào email tóm tắt, người nhận đã tắt thông báo trong ứng dụng cho loại này
+	CreatedAt  time.Time        `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// NotificationData là dữ liệu kèm thông báo để FE mở đúng ticket/bình luận, lưu dạng JSON
+type NotificationData struct {
+	TicketID     uint   `json:"ticket_id,omitempty"`
+	CommentID    uint   `json:"comment_id,omitempty"`
+	UserID       uint   `json:"user_id,omitempty"`       // người thực hiện (khách hàng sửa/thu hồi ticket)
+	AssignedTo   uint   `json:"assigned_to,omitempty"`   // nhân viên được phân công
+	AttachmentID uint   `json:"attachment_id,omitempty"` // file bị cách ly
+	Action       string `json:"action,omitempty"`        // status, update, delete
+	Status       string `json:"status,omitempty"`        // trạng thái mới của ticket
+	Reason       string `json:"reason,omitempty"`        // lý do nhắc: sla_breached, no_response
+}
+
+func (d NotificationData) Value() (driver.Value, error) {
+	b, err := json.Marshal(d)
+	return string(b), err
+}
+
+func (d *NotificationData) Scan(value interface{}) error {
+	var b []byte
+	switch v := value.(type) {
+	case nil:
+		*d = NotificationData{}
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("notification data: kiểu dữ liệu không hỗ trợ %T", value)
+	}
+	*d = NotificationData{}
+	if len(b) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, d)
+}
+
+// migrateNotificationData chuẩn bị đổi cột data từ text sang JSON: giá trị rỗng/không phải JSON hợp lệ
+// (dữ liệu cũ ghép chuỗi bằng tay) được thay bằng {} để ALTER TABLE không lỗi
+func migrateNotificationData(db *gorm.DB) {
+	if !db.Migrator().HasTable(&Notification{}) {
+		return
+	}
+	db.Exec("UPDATE notifications SET data = '{}' WHERE data IS NULL OR data = '' OR JSON_VALID(data) = 0")
 }
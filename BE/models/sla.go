@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// SLAPolicy quy định hạn phản hồi đầu tiên và hạn xử lý (phút làm việc) theo mức độ ưu tiên (và loại ticket nếu có)
+type SLAPolicy struct {
+	ID                   uint            `gorm:"primaryKey" json:"id"`
+	Name                 string          `gorm:"type:varchar(100);not null" json:"name"`
+	PriorityID           uint            `gorm:"not null;index" json:"priority_id"`
+	Priority             TicketPriority  `gorm:"foreignKey:PriorityID" json:"priority"`
+	CategoryID           *uint           `gorm:"index" json:"category_id"`
+	Category             *TicketCategory `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+	FirstResponseMinutes int             `gorm:"not null" json:"first_response_minutes"`
+	ResolutionMinutes    int             `gorm:"not null" json:"resolution_minutes"`
+	IsActive             bool            `json:"is_active"`
+	CreatedAt            time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt            time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (SLAPolicy) TableName() string {
+	return "sla_policies"
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Team là một nhóm hỗ trợ có hàng đợi ticket riêng
+type Team struct {
+	ID          uint        `gorm:"primaryKey" json:"id"`
+	Name        string      `gorm:"type:varchar(100);unique;not null" json:"name"`
+	Description string      `gorm:"type:text" json:"description"`
+	Members     []User      `gorm:"many2many:team_members;" json:"-"`
+	Routes      []TeamRoute `gorm:"foreignKey:TeamID" json:"routes"`
+	CreatedAt   time.Time   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TeamRoute đưa ticket vào hàng đợi của team theo loại ticket và/hoặc loại sản phẩm
+type TeamRoute struct {
+	ID            uint  `gorm:"primaryKey" json:"id"`
+	TeamID        uint  `gorm:"not null;index" json:"team_id"`
+	CategoryID    *uint `gorm:"index" json:"category_id"`
+	ProductTypeID *uint `gorm:"index" json:"product_type_id"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TicketEvent lưu lại một thay đổi trên ticket (ai đổi, trường nào, giá trị cũ/mới)
+type TicketEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TicketID  uint      `gorm:"not null;index" json:"ticket_id"`
+	ActorID   *uint     `gorm:"index" json:"actor_id"`
+	Actor     *User     `gorm:"foreignKey:ActorID" json:"-"`
+	Field     string    `gorm:"type:varchar(50);not null" json:"field"`
+	OldValue  string    `gorm:"type:text" json:"old_value"`
+	NewValue  string    `gorm:"type:text" json:"new_value"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// Các trường được ghi lịch sử
+const (
+	TicketFieldCreated     = "created"
+	TicketFieldStatus      = "status"
+	TicketFieldPriority    = "priority_id"
+	TicketFieldAssignee    = "assigned_to"
+	TicketFieldTeam        = "team_id"
+	TicketFieldCategory    = "category_id"
+	TicketFieldProductType = "product_type_id"
+	TicketFieldTitle       = "title"
+	TicketFieldDescription = "description"
+)
+
+// DiffTicket so sánh hai phiên bản ticket và trả về các sự kiện cho những trường đã thay đổi
+func DiffTicket(before, after Ticket, actorID *uint) []TicketEvent {
+	var events []TicketEvent
+	add := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			events = append(events, TicketEvent{
+				TicketID: after.ID,
+				ActorID:  actorID,
+				Field:    field,
+				OldValue: oldValue,
+				NewValue: newValue,
+			})
+		}
+	}
+	add(TicketFieldStatus, before.Status, after.Status)
+	add(TicketFieldPriority, idString(before.PriorityID), idString(after.PriorityID))
+	add(TicketFieldAssignee, optionalIDString(before.AssignedTo), optionalIDString(after.AssignedTo))
+	add(TicketFieldTeam, optionalIDString(before.TeamID), optionalIDString(after.TeamID))
+	add(TicketFieldCategory, idString(before.CategoryID), idString(after.CategoryID))
+	add(TicketFieldProductType, idString(before.ProductTypeID), idString(after.ProductTypeID))
+	add(TicketFieldTitle, before.Title, after.Title)
+	add(TicketFieldDescription, before.Description, after.Description)
+	return events
+}
+
+// RecordTicketChanges ghi các thay đổi giữa before và after vào bảng ticket_events, trả về các sự kiện đã ghi
+func RecordTicketChanges(db *gorm.DB, before, after Ticket, actorID *uint) ([]TicketEvent, error) {
+	events := DiffTicket(before, after, actorID)
+	if len(events) == 0 {
+		return nil, nil
+	}
+	if err := db.Create(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func idString(id uint) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+func optionalIDString(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return idString(*id)
+}
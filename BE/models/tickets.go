@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Ticket struct {
@@ -17,11 +19,26 @@ type Ticket struct {
 	Priority            TicketPriority    `gorm:"foreignKey:PriorityID" json:"priority"`
 	ProductTypeID       uint              `gorm:"index" json:"product_type_id"`
 	ProductType         TicketProductType `gorm:"foreignKey:ProductTypeID" json:"product_type"`
+	TeamID              *uint             `gorm:"index" json:"team_id"`
 	AssignedTo          *uint             `gorm:"index" json:"assigned_to"`
 	Assigned            *User             `gorm:"foreignKey:AssignedTo" json:"-"`
 	CreatedAt           time.Time         `gorm:"autoCreateTime;index" json:"created_at"`
 	UpdatedAt           time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
 	ResolvedAt          *time.Time        `gorm:"default:null;index" json:"resolved_at"`
+	FirstResponseAt     *time.Time        `gorm:"default:null" json:"first_response_at"`
+	ClosedAt            *time.Time        `gorm:"default:null" json:"closed_at"`
+	ReopenedAt          *time.Time        `gorm:"default:null" json:"reopened_at"`
+	ReopenCount         int               `gorm:"default:0" json:"reopen_count"`
+	LastRemindedAt      *time.Time        `gorm:"default:null" json:"last_reminded_at"` // lần nhắc "chưa phản hồi" gần nhất
+	SLAPolicyID         *uint             `gorm:"column:sla_policy_id;index" json:"sla_policy_id"`
+	FirstResponseDueAt  *time.Time        `gorm:"default:null" json:"first_response_due_at"`
+	ResolutionDueAt     *time.Time        `gorm:"default:null" json:"resolution_due_at"`
+	SLADueAt            *time.Time        `gorm:"column:sla_due_at;default:null;index" json:"sla_due_at"`
+	SLAPausedAt         *time.Time        `gorm:"column:sla_paused_at;default:null" json:"sla_paused_at"`
+	SLAPausedSeconds    int64             `gorm:"column:sla_paused_seconds;default:0" json:"sla_paused_seconds"`
+	SLABreached         bool              `gorm:"column:sla_breached;default:false;index" json:"sla_breached"`
+	ResponseBreached    bool              `gorm:"default:false" json:"response_breached"`   // đã vi phạm hạn phản hồi đầu tiên
+	ResolutionBreached  bool              `gorm:"default:false" json:"resolution_breached"` // đã vi phạm hạn xử lý
 	AttachmentPath      string            `gorm:"type:varchar(255);default:null" json:"attachment_path"`
 	LastViewedCommentAt *time.Time        `gorm:"default:null" json:"last_viewed_comment_at"`
 }
@@ -35,9 +52,34 @@ type TicketComment struct {
 	Content        string    `gorm:"column:message;type:text;not null" json:"content"`
 	AttachmentPath string    `gorm:"type:varchar(255);default:null" json:"attachment_url"`
 	ParentID       *uint     `gorm:"index;default:null" json:"parent_id"`
+	IsInternal     bool      `gorm:"default:false;index" json:"is_internal"` // ghi chú nội bộ, chỉ admin/staff thấy
 	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
 }
 
+// Loại bình luận trả về cho FE
+const (
+	CommentTypeReply        = "reply"
+	CommentTypeInternalNote = "internal_note"
+)
+
+// Type trả về loại bình luận để FE hiển thị khác nhau
+func (c TicketComment) Type() string {
+	if c.IsInternal {
+		return CommentTypeInternalNote
+	}
+	return CommentTypeReply
+}
+
+// VisibleComments giới hạn bình luận theo vai trò người xem: khách hàng không thấy ghi chú nội bộ
+func VisibleComments(role string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if role == "admin" || role == "staff" {
+			return db
+		}
+		return db.Where("is_internal = ?", false)
+	}
+}
+
 type TicketCategory struct {
 	ID   uint   `gorm:"primaryKey" json:"id"`
 	Name string `gorm:"unique;not null" json:"name"`
@@ -52,3 +94,18 @@ type TicketPriority struct {
 	ID   uint   `gorm:"primaryKey" json:"id"`
 	Name string `gorm:"unique;not null" json:"name"`
 }
+
+// migrateTicketSLA chuyển dữ liệu SLA cũ (chạy một lần khi thêm cột vi phạm theo từng hạn):
+// vi phạm cũ được gán cho hạn đang áp dụng lúc đó, ticket đã xử lý/đã đóng được coi là đang dừng đồng hồ
+// để khi mở lại hạn được lùi thêm thay vì vi phạm ngay
+func migrateTicketSLA(db *gorm.DB) {
+	if !db.Migrator().HasTable(&Ticket{}) || db.Migrator().HasColumn(&Ticket{}, "ResponseBreached") {
+		return
+	}
+	if err := db.AutoMigrate(&Ticket{}); err != nil {
+		return
+	}
+	db.Exec("UPDATE tickets SET response_breached = TRUE WHERE sla_breached = TRUE AND (first_response_at IS NULL OR first_response_at > first_response_due_at)")
+	db.Exec("UPDATE tickets SET resolution_breached = TRUE WHERE sla_breached = TRUE AND response_breached = FALSE")
+	db.Exec("UPDATE tickets SET sla_paused_at = COALESCE(resolved_at, closed_at) WHERE sla_policy_id IS NOT NULL AND sla_paused_at IS NULL AND COALESCE(resolved_at, closed_at) IS NOT NULL")
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UploadPolicy là chính sách upload file của một ngữ cảnh (ticket, comment, knowledge) do admin cấu hình
+type UploadPolicy struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	Context           string    `gorm:"type:varchar(20);unique;not null" json:"context"`
+	MaxSizeMB         int       `gorm:"not null" json:"max_size_mb"`
+	MaxFiles          int       `gorm:"not null;default:1" json:"max_files"`
+	AllowedExtensions string    `gorm:"type:text" json:"allowed_extensions"` // phân tách bằng dấu phẩy, vd ".pdf,.png"
+	AllowedMimeTypes  string    `gorm:"type:text" json:"allowed_mime_types"` // phân tách bằng dấu phẩy, hỗ trợ "image/*"; rỗng = mọi loại
+	UpdatedAt         time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// DefaultUploadPolicies là chính sách mặc định khi chưa cấu hình
+var DefaultUploadPolicies = []UploadPolicy{
+	{
+		Context:           "ticket",
+		MaxSizeMB:         10,
+		MaxFiles:          10,
+		AllowedExtensions: ".jpg,.jpeg,.png,.gif,.webp,.pdf,.txt,.log,.csv,.doc,.docx,.xls,.xlsx,.zip",
+	},
+	{
+		Context:           "comment",
+		MaxSizeMB:         10,
+		MaxFiles:          10,
+		AllowedExtensions: ".jpg,.jpeg,.png,.gif,.webp,.pdf,.txt,.log,.csv,.doc,.docx,.xls,.xlsx,.zip",
+	},
+	{
+		Context:           "knowledge",
+		MaxSizeMB:         50,
+		MaxFiles:          1,
+		AllowedExtensions: ".pdf,.doc,.docx,.xls,.xlsx,.ppt,.pptx,.txt,.md,.png,.jpg,.jpeg",
+	},
+}
+
+// seedUploadPolicies tạo chính sách mặc định cho các ngữ cảnh chưa có
+func seedUploadPolicies(db *gorm.DB) {
+	for _, p := range DefaultUploadPolicies {
+		policy := p
+		db.Where("context = ?", p.Context).FirstOrCreate(&policy)
+	}
+}
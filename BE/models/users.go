@@ -13,4 +13,7 @@ type User struct {
 	VerifyToken      string `gorm:"size:255"`
 	TwoFactorEnabled bool   `gorm:"default:false"`
 	TwoFactorSecret  string `gorm:"size:255"`
+	IsAvailable      bool   `gorm:"default:true"`               // Nhân viên đang nhận ticket tự động phân công
+	Language         string `gorm:"type:varchar(5);default:vi"` // Ngôn ngữ email gửi cho người dùng
+	IsServiceAccount bool   `gorm:"default:false;index"`        // Tài khoản dịch vụ cho tích hợp: chỉ đăng nhập bằng token API
 }
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"awesomeProject/webhook"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Trạng thái một lần gửi webhook
+const (
+	WebhookPending   = "pending"   // chờ gửi lần đầu
+	WebhookSending   = "sending"   // worker đang gửi
+	WebhookDelivered = "delivered" // bên nhận trả về 2xx
+	WebhookFailed    = "failed"    // gửi lỗi, chờ gửi lại theo NextAttemptAt
+	WebhookDead      = "dead"      // hết số lần thử hoặc webhook đã tắt/xóa, chỉ gửi lại khi admin yêu cầu
+)
+
+// WebhookSubscription là một địa chỉ nhận sự kiện ticket do admin cấu hình
+type WebhookSubscription struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	URL       string    `gorm:"type:varchar(500);not null" json:"url"`
+	Secret    string    `gorm:"type:varchar(100);not null" json:"-"`
+	Events    []string  `gorm:"type:text;serializer:json" json:"events"` // rỗng = nhận tất cả sự kiện
+	IsActive  bool      `json:"is_active"`
+	CreatedBy uint      `json:"created_by"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Subscribes cho biết webhook có nhận sự kiện này không
+func (s WebhookSubscription) Subscribes(event string) bool {
+	if len(s.Events) == 0 || event == webhook.EventPing {
+		return true
+	}
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery là một lần gửi sự kiện đến một webhook, đồng thời là nhật ký gửi.
+// Worker gửi lại với thời gian chờ tăng dần khi bên nhận lỗi.
+type WebhookDelivery struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	SubscriptionID uint       `gorm:"not null;index" json:"subscription_id"`
+	EventID        string     `gorm:"type:varchar(64);index" json:"event_id"`
+	Event          string     `gorm:"type:varchar(50);index" json:"event"`
+	Payload        string     `gorm:"type:mediumtext" json:"payload"`
+	Status         string     `gorm:"type:varchar(20);default:pending;index:idx_webhook_delivery_due" json:"status"`
+	Attempts       int        `gorm:"default:0" json:"attempts"`
+	MaxAttempts    int        `gorm:"default:8" json:"max_attempts"`
+	NextAttemptAt  time.Time  `gorm:"index:idx_webhook_delivery_due" json:"next_attempt_at"`
+	ResponseStatus int        `json:"response_status"`
+	ResponseBody   string     `gorm:"type:text" json:"response_body"`
+	DurationMs     int64      `json:"duration_ms"`
+	LastError      string     `gorm:"type:text" json:"last_error"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Số lần gửi tối đa của một sự kiện
+const WebhookMaxAttempts = 8
+
+// QueueWebhookEvent ghi sự kiện vào hàng đợi gửi của các webhook đang bật có đăng ký sự kiện này.
+// Lỗi chỉ ghi log để không ảnh hưởng thao tác trên ticket.
+func QueueWebhookEvent(db *gorm.DB, event string, data interface{}) {
+	var subs []WebhookSubscription
+	if err := db.Where("is_active = ?", true).Find(&subs).Error; err != nil {
+		log.Printf("[WEBHOOK] Không đọc được danh sách webhook: %v", err)
+		return
+	}
+	var deliveries []WebhookDelivery
+	var eventID string
+	var body []byte
+	for _, s := range subs {
+		if !s.Subscribes(event) {
+			continue
+		}
+		if body == nil {
+			var err error
+			if eventID, body, err = webhook.Encode(event, data); err != nil {
+				log.Printf("[WEBHOOK] Không tạo được nội dung sự kiện %s: %v", event, err)
+				return
+			}
+		}
+		deliveries = append(deliveries, WebhookDelivery{
+			SubscriptionID: s.ID,
+			EventID:        eventID,
+			Event:          event,
+			Payload:        string(body),
+			Status:         WebhookPending,
+			MaxAttempts:    WebhookMaxAttempts,
+			NextAttemptAt:  time.Now(),
+		})
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+	if err := db.Create(&deliveries).Error; err != nil {
+		log.Printf("[WEBHOOK] Không ghi được sự kiện %s vào hàng đợi: %v", event, err)
+	}
+}
+
+// WebhookTicket là thông tin ticket gửi kèm các sự kiện webhook
+func WebhookTicket(t Ticket) map[string]interface{} {
+	return map[string]interface{}{
+		"id":              t.ID,
+		"title":           t.Title,
+		"description":     t.Description,
+		"status":          t.Status,
+		"user_id":         t.UserID,
+		"category_id":     t.CategoryID,
+		"product_type_id": t.ProductTypeID,
+		"priority_id":     t.PriorityID,
+		"team_id":         t.TeamID,
+		"assigned_to":     t.AssignedTo,
+		"sla_due_at":      t.SLADueAt,
+		"created_at":      t.CreatedAt,
+		"updated_at":      t.UpdatedAt,
+	}
+}
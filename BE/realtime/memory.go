@@ -0,0 +1,67 @@
+package realtime
+
+import "sync"
+
+// Số sự kiện tối đa chờ gửi cho một client; client đọc chậm bị bỏ sự kiện thay vì chặn người phát
+const subscriptionBuffer = 64
+
+// Memory là broker trong tiến trình
+type Memory struct {
+	mu   sync.RWMutex
+	subs map[string]map[*memorySub]struct{}
+}
+
+func NewMemory() *Memory {
+	return &Memory{subs: map[string]map[*memorySub]struct{}{}}
+}
+
+type memorySub struct {
+	broker *Memory
+	topics []string
+	ch     chan Event
+	once   sync.Once
+}
+
+func (m *Memory) Publish(topic string, ev Event) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for s := range m.subs[topic] {
+		select {
+		case s.ch <- ev:
+		default:
+		}
+	}
+}
+
+func (m *Memory) Subscribe(topics ...string) Subscription {
+	s := &memorySub{broker: m, topics: topics, ch: make(chan Event, subscriptionBuffer)}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, t := range topics {
+		if m.subs[t] == nil {
+			m.subs[t] = map[*memorySub]struct{}{}
+		}
+		m.subs[t][s] = struct{}{}
+	}
+	return s
+}
+
+func (s *memorySub) Events() <-chan Event {
+	return s.ch
+}
+
+// Close hủy đăng ký và đóng kênh sự kiện
+func (s *memorySub) Close() {
+	s.once.Do(func() {
+		m := s.broker
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for _, t := range s.topics {
+			delete(m.subs[t], s)
+			if len(m.subs[t]) == 0 {
+				delete(m.subs, t)
+			}
+		}
+		close(s.ch)
+	})
+}
@@ -0,0 +1,65 @@
+// Package realtime phát sự kiện (thông báo mới, bình luận, đổi trạng thái ticket) đến client đang kết nối.
+// Broker mặc định chạy trong tiến trình; khi chạy nhiều server có thể thay bằng broker dùng Redis qua SetDefault.
+package realtime
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Event là một sự kiện gửi cho client
+type Event struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// Subscription nhận sự kiện của các topic đã đăng ký
+type Subscription interface {
+	Events() <-chan Event
+	Close()
+}
+
+// Broker phân phối sự kiện theo topic
+type Broker interface {
+	Publish(topic string, ev Event)
+	Subscribe(topics ...string) Subscription
+}
+
+// Các topic
+func UserTopic(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// TicketTopic nhận sự kiện mọi người xem được ticket đều thấy
+func TicketTopic(ticketID uint) string {
+	return fmt.Sprintf("ticket:%d", ticketID)
+}
+
+// TicketStaffTopic nhận sự kiện chỉ admin/staff thấy (ghi chú nội bộ)
+func TicketStaffTopic(ticketID uint) string {
+	return fmt.Sprintf("ticket:%d:staff", ticketID)
+}
+
+var (
+	defaultBroker Broker = NewMemory()
+	defaultMu     sync.RWMutex
+)
+
+// Default trả về broker đang dùng
+func Default() Broker {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultBroker
+}
+
+// SetDefault thay broker mặc định, gọi khi khởi động trước khi có client kết nối
+func SetDefault(b Broker) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultBroker = b
+}
+
+// Publish gửi sự kiện qua broker mặc định
+func Publish(topic string, eventType string, data interface{}) {
+	Default().Publish(topic, Event{Type: eventType, Data: data})
+}
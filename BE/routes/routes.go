@@ -32,17 +32,44 @@ func RegisterAPIRoutes(app *fiber.App) {
 	authRequired.Post("/profile/2fa/setup", controllers.Setup2FA)
 	authRequired.Post("/profile/2fa/enable", controllers.Enable2FA)
 	authRequired.Post("/profile/2fa/disable", controllers.Disable2FA)
+	authRequired.Get("/profile/notifications", controllers.GetMyNotificationPreferences)
+	authRequired.Put("/profile/notifications", controllers.UpdateMyNotificationPreferences)
+	authRequired.Get("/profile/notifications/digest", controllers.GetMyDigestSetting)
+	authRequired.Put("/profile/notifications/digest", controllers.UpdateMyDigestSetting)
+	authRequired.Get("/profile/api-tokens", controllers.GetMyAPITokens)
+	authRequired.Post("/profile/api-tokens", controllers.CreateMyAPIToken)
+	authRequired.Delete("/profile/api-tokens/:id", controllers.RevokeMyAPIToken)
 	authRequired.Post("/tickets", controllers.CreateTicket)
 	authRequired.Get("/tickets", controllers.GetMyTickets)
 	authRequired.Get("/tickets/:id", controllers.GetTicketDetail)
 	authRequired.Get("/tickets/:id/comments", controllers.GetTicketComments)
+	authRequired.Get("/tickets/:id/history", controllers.GetTicketHistory)
 	authRequired.Post("/tickets/:id/comments", controllers.PostTicketComment)
+	authRequired.Get("/tickets/:id/attachments", controllers.GetTicketAttachments)
+	authRequired.Delete("/attachments/:id", controllers.DeleteAttachment)
+	authRequired.Get("/attachments/:id/download", controllers.DownloadAttachment)
+	authRequired.Get("/attachments/:id/signed-url", controllers.GetAttachmentSignedURL)
+	authRequired.Get("/attachments/:id/thumbnail", controllers.GetAttachmentThumbnail)
 	authRequired.Put("/tickets/:id", controllers.UpdateMyTicket)
+	authRequired.Put("/tickets/:id/status", controllers.UpdateMyTicketStatus)
 	authRequired.Delete("/tickets/:id", controllers.DeleteMyTicket)
-	authRequired.Get("/notifications", controllers.UserGetNotifications)
-	authRequired.Post("/notifications/:id/read", controllers.UserReadNotification)
+	authRequired.Get("/notifications", controllers.GetNotifications)
+	authRequired.Get("/notifications/unread-count", controllers.GetUnreadNotificationCount)
+	authRequired.Post("/notifications/read", controllers.ReadNotifications)
+	authRequired.Post("/notifications/archive", controllers.ArchiveNotifications)
+	authRequired.Post("/notifications/unarchive", controllers.UnarchiveNotifications)
+	authRequired.Post("/notifications/delete", controllers.DeleteNotifications)
+	authRequired.Post("/notifications/:id/read", controllers.ReadNotification)
+	authRequired.Delete("/notifications/:id", controllers.DeleteNotification)
+	authRequired.Get("/events", controllers.StreamEvents) // Server-Sent Events: thông báo, bình luận, trạng thái ticket
 	authRequired.Get("/dashboard/stats", controllers.UserDashboardStats)
 
+	// File đã upload: bắt buộc đăng nhập và kiểm tra quyền, hoặc dùng URL đã ký
+	uploads := app.Group("/uploads")
+	uploads.Use(middlewares.JWTMiddleware)
+	uploads.Get("/*", controllers.DownloadUploadedFile)
+	app.Get("/files/attachments/:id", controllers.DownloadSignedAttachment)
+
 	// Public API cho FE lấy danh sách thuộc tính ticket
 	app.Get("/ticket-categories", controllers.GetTicketCategories)
 	app.Get("/ticket-product-types", controllers.GetTicketProductTypes)
@@ -56,11 +83,26 @@ func RegisterAPIRoutes(app *fiber.App) {
 	adminRequired.Get("/tickets/:id", controllers.AdminGetTicketDetail)
 	adminRequired.Put("/tickets/:id/status", controllers.AdminUpdateTicketStatus)
 	adminRequired.Get("/tickets/:id/comments", controllers.GetTicketComments)
+	adminRequired.Get("/tickets/:id/history", controllers.GetTicketHistory)
 	adminRequired.Post("/tickets/:id/comments", controllers.PostTicketComment)
+	adminRequired.Get("/tickets/:id/attachments", controllers.GetTicketAttachments)
+	adminRequired.Delete("/attachments/:id", controllers.DeleteAttachment)
+	adminRequired.Get("/attachments/:id/download", controllers.DownloadAttachment)
+	adminRequired.Get("/attachments/:id/signed-url", controllers.GetAttachmentSignedURL)
+	adminRequired.Get("/attachments/:id/thumbnail", controllers.GetAttachmentThumbnail)
 	adminRequired.Get("/staff", controllers.GetAssignableStaff)
 	adminRequired.Put("/tickets/:id/assign", controllers.AssignTicket)
-	adminRequired.Get("/notifications", controllers.AdminGetNotifications)
-	adminRequired.Post("/notifications/:id/read", controllers.AdminReadNotification)
+	adminRequired.Put("/staff/:id/availability", controllers.UpdateStaffAvailability)
+	adminRequired.Post("/tickets/:id/claim", controllers.ClaimTicket)
+	adminRequired.Get("/my-teams", controllers.GetMyTeams)
+	adminRequired.Get("/notifications", controllers.GetNotifications)
+	adminRequired.Get("/notifications/unread-count", controllers.GetUnreadNotificationCount)
+	adminRequired.Post("/notifications/read", controllers.ReadNotifications)
+	adminRequired.Post("/notifications/archive", controllers.ArchiveNotifications)
+	adminRequired.Post("/notifications/unarchive", controllers.UnarchiveNotifications)
+	adminRequired.Post("/notifications/delete", controllers.DeleteNotifications)
+	adminRequired.Post("/notifications/:id/read", controllers.ReadNotification)
+	adminRequired.Delete("/notifications/:id", controllers.DeleteNotification)
 	adminRequired.Get("/knowledge-base", controllers.AdminGetKnowledgeBaseList)
 	adminRequired.Post("/knowledge-base", controllers.AdminCreateKnowledgeBase)
 	adminRequired.Put("/knowledge-base/:id", controllers.AdminUpdateKnowledgeBase)
@@ -92,4 +134,83 @@ func RegisterAPIRoutes(app *fiber.App) {
 	ticketAttributes.Post("/ticket-product-types", controllers.CreateTicketProductType)
 	ticketAttributes.Put("/ticket-product-types/:id", controllers.UpdateTicketProductType)
 	ticketAttributes.Delete("/ticket-product-types/:id", controllers.DeleteTicketProductType)
+	ticketAttributes.Get("/sla-policies", controllers.GetAllSLAPolicies)
+	ticketAttributes.Post("/sla-policies", controllers.CreateSLAPolicy)
+	ticketAttributes.Put("/sla-policies/:id", controllers.UpdateSLAPolicy)
+	ticketAttributes.Delete("/sla-policies/:id", controllers.DeleteSLAPolicy)
+	ticketAttributes.Get("/assignment-rules", controllers.GetAllAssignmentRules)
+	ticketAttributes.Post("/assignment-rules", controllers.CreateAssignmentRule)
+	ticketAttributes.Put("/assignment-rules/:id", controllers.UpdateAssignmentRule)
+	ticketAttributes.Delete("/assignment-rules/:id", controllers.DeleteAssignmentRule)
+	ticketAttributes.Get("/upload-policies", controllers.GetUploadPolicies)
+	ticketAttributes.Put("/upload-policies/:context", controllers.UpdateUploadPolicy)
+
+	// Business calendar routes - chỉ admin mới truy cập được
+	businessCalendar := app.Group("/admin")
+	businessCalendar.Use(middlewares.AdminMiddleware)
+	businessCalendar.Use(middlewares.StaffRestrictedMiddleware)
+	businessCalendar.Get("/business-calendar", controllers.GetBusinessCalendar)
+	businessCalendar.Put("/business-calendar", controllers.UpdateBusinessCalendar)
+	businessCalendar.Get("/holidays", controllers.GetHolidays)
+	businessCalendar.Post("/holidays", controllers.CreateHoliday)
+	businessCalendar.Delete("/holidays/:id", controllers.DeleteHoliday)
+
+	// Team routes - chỉ admin mới truy cập được
+	teams := app.Group("/admin")
+	teams.Use(middlewares.AdminMiddleware)
+	teams.Use(middlewares.StaffRestrictedMiddleware)
+	teams.Get("/teams", controllers.GetAllTeams)
+	teams.Post("/teams", controllers.CreateTeam)
+	teams.Put("/teams/:id", controllers.UpdateTeam)
+	teams.Delete("/teams/:id", controllers.DeleteTeam)
+
+	// Hàng đợi email - chỉ admin mới truy cập được
+	emailOutbox := app.Group("/admin")
+	emailOutbox.Use(middlewares.AdminMiddleware)
+	emailOutbox.Use(middlewares.StaffRestrictedMiddleware)
+	emailOutbox.Get("/email-outbox", controllers.GetEmailOutbox)
+	emailOutbox.Get("/email-outbox/health", controllers.GetMailerHealth)
+	emailOutbox.Post("/email-outbox/:id/retry", controllers.RetryEmailOutbox)
+
+	// Mẫu email - chỉ admin mới truy cập được
+	emailTemplates := app.Group("/admin")
+	emailTemplates.Use(middlewares.AdminMiddleware)
+	emailTemplates.Use(middlewares.StaffRestrictedMiddleware)
+	emailTemplates.Get("/email-templates", controllers.GetEmailTemplates)
+	emailTemplates.Put("/email-templates/:key/:lang", controllers.UpdateEmailTemplate)
+	emailTemplates.Delete("/email-templates/:key/:lang", controllers.ResetEmailTemplate)
+	emailTemplates.Post("/email-templates/:key/:lang/preview", controllers.PreviewEmailTemplate)
+
+	// Webhook gửi sự kiện ticket ra hệ thống bên ngoài - chỉ admin mới truy cập được
+	webhooks := app.Group("/admin")
+	webhooks.Use(middlewares.AdminMiddleware)
+	webhooks.Use(middlewares.StaffRestrictedMiddleware)
+	webhooks.Get("/webhooks", controllers.GetWebhooks)
+	webhooks.Post("/webhooks", controllers.CreateWebhook)
+	webhooks.Put("/webhooks/:id", controllers.UpdateWebhook)
+	webhooks.Delete("/webhooks/:id", controllers.DeleteWebhook)
+	webhooks.Post("/webhooks/:id/test", controllers.TestWebhook)
+	webhooks.Get("/webhooks/:id/deliveries", controllers.GetWebhookDeliveries)
+	webhooks.Post("/webhook-deliveries/:id/retry", controllers.RetryWebhookDelivery)
+
+	// Kênh chat (Slack/Mattermost) nhận thông báo ticket - chỉ admin mới truy cập được
+	chatChannels := app.Group("/admin")
+	chatChannels.Use(middlewares.AdminMiddleware)
+	chatChannels.Use(middlewares.StaffRestrictedMiddleware)
+	chatChannels.Get("/chat-channels", controllers.GetChatChannels)
+	chatChannels.Post("/chat-channels", controllers.CreateChatChannel)
+	chatChannels.Put("/chat-channels/:id", controllers.UpdateChatChannel)
+	chatChannels.Delete("/chat-channels/:id", controllers.DeleteChatChannel)
+	chatChannels.Post("/chat-channels/:id/test", controllers.TestChatChannel)
+
+	// Tài khoản dịch vụ và token API - chỉ admin mới truy cập được
+	serviceAccounts := app.Group("/admin")
+	serviceAccounts.Use(middlewares.AdminMiddleware)
+	serviceAccounts.Use(middlewares.StaffRestrictedMiddleware)
+	serviceAccounts.Get("/service-accounts", controllers.GetServiceAccounts)
+	serviceAccounts.Post("/service-accounts", controllers.CreateServiceAccount)
+	serviceAccounts.Delete("/service-accounts/:id", controllers.DeleteServiceAccount)
+	serviceAccounts.Get("/service-accounts/:id/tokens", controllers.GetServiceAccountTokens)
+	serviceAccounts.Post("/service-accounts/:id/tokens", controllers.CreateServiceAccountToken)
+	serviceAccounts.Delete("/api-tokens/:id", controllers.RevokeAPIToken)
 }
@@ -0,0 +1,114 @@
+package scanner
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Kích thước mỗi chunk gửi qua INSTREAM, nhỏ hơn StreamMaxLength mặc định của clamd
+const clamdChunkSize = 64 * 1024
+
+// Clamd gửi file tới clamd qua lệnh INSTREAM (TCP hoặc unix socket)
+type Clamd struct {
+	Network string // "tcp" hoặc "unix"
+	Address string
+	Timeout time.Duration
+}
+
+// NewClamd tạo client từ địa chỉ dạng tcp://host:port, unix:///path/to.sock hoặc host:port
+func NewClamd(addr string) (*Clamd, error) {
+	c := &Clamd{Network: "tcp", Address: addr, Timeout: 2 * time.Minute}
+	if strings.Contains(addr, "://") {
+		u, err := url.Parse(addr)
+		if err != nil {
+			return nil, err
+		}
+		switch u.Scheme {
+		case "tcp":
+			c.Address = u.Host
+		case "unix":
+			c.Network = "unix"
+			c.Address = u.Path
+		default:
+			return nil, fmt.Errorf("scanner: unsupported clamd scheme %q", u.Scheme)
+		}
+	}
+	if c.Address == "" {
+		return nil, errors.New("scanner: empty clamd address")
+	}
+	return c, nil
+}
+
+// Scan gửi nội dung theo giao thức INSTREAM: "zINSTREAM\0", các chunk [độ dài 4 byte big-endian][dữ liệu], kết thúc bằng chunk rỗng
+func (c *Clamd) Scan(r io.Reader) (Result, error) {
+	conn, err := net.DialTimeout(c.Network, c.Address, 10*time.Second)
+	if err != nil {
+		return Result{}, err
+	}
+	defer conn.Close()
+	if c.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(c.Timeout))
+	}
+
+	w := bufio.NewWriterSize(conn, clamdChunkSize+4)
+	if _, err := w.WriteString("zINSTREAM\x00"); err != nil {
+		return Result{}, err
+	}
+	buf := make([]byte, clamdChunkSize)
+	var size [4]byte
+	for {
+		n, rerr := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size[:], uint32(n))
+			if _, err := w.Write(size[:]); err != nil {
+				return Result{}, err
+			}
+			if _, err := w.Write(buf[:n]); err != nil {
+				return Result{}, err
+			}
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return Result{}, rerr
+		}
+	}
+	binary.BigEndian.PutUint32(size[:], 0)
+	if _, err := w.Write(size[:]); err != nil {
+		return Result{}, err
+	}
+	if err := w.Flush(); err != nil {
+		return Result{}, err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return Result{}, err
+	}
+	return parseClamdReply(reply)
+}
+
+// parseClamdReply đọc phản hồi "stream: OK", "stream: <tên virus> FOUND" hoặc "... ERROR"
+func parseClamdReply(reply string) (Result, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	msg := reply
+	if i := strings.Index(reply, ": "); i >= 0 {
+		msg = reply[i+2:]
+	}
+	switch {
+	case msg == "OK":
+		return Result{}, nil
+	case strings.HasSuffix(msg, " FOUND"):
+		return Result{Infected: true, Signature: strings.TrimSuffix(msg, " FOUND")}, nil
+	default:
+		return Result{}, fmt.Errorf("scanner: clamd error: %s", reply)
+	}
+}
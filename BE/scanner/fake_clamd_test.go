@@ -0,0 +1,90 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+)
+
+// EICAR là chuỗi kiểm thử antivirus chuẩn, được FakeClamd coi là virus
+const EICAR = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// FakeClamd là máy chủ giả lập clamd (chỉ hỗ trợ INSTREAM và PING) dùng cho kiểm thử:
+// file chứa chuỗi EICAR bị báo nhiễm "Eicar-Test-Signature",
+// file lớn hơn MaxLength (nếu đặt) bị báo lỗi như StreamMaxLength của clamd.
+type FakeClamd struct {
+	Listener  net.Listener
+	MaxLength int64
+}
+
+// StartFakeClamd lắng nghe trên network/address (vd "tcp", "127.0.0.1:0") và phục vụ ở goroutine riêng
+func StartFakeClamd(network, address string, maxLength int64) (*FakeClamd, error) {
+	l, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	f := &FakeClamd{Listener: l, MaxLength: maxLength}
+	go f.serve()
+	return f, nil
+}
+
+// Address trả về địa chỉ dạng dùng được cho CLAMD_ADDRESS
+func (f *FakeClamd) Address() string {
+	return f.Listener.Addr().Network() + "://" + f.Listener.Addr().String()
+}
+
+func (f *FakeClamd) Close() error {
+	return f.Listener.Close()
+}
+
+func (f *FakeClamd) serve() {
+	for {
+		conn, err := f.Listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *FakeClamd) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	cmd, err := r.ReadString(0)
+	if err != nil {
+		return
+	}
+	switch strings.TrimRight(strings.TrimPrefix(cmd, "z"), "\x00") {
+	case "PING":
+		conn.Write([]byte("PONG\x00"))
+	case "INSTREAM":
+		var data bytes.Buffer
+		var size [4]byte
+		for {
+			if _, err := io.ReadFull(r, size[:]); err != nil {
+				return
+			}
+			n := binary.BigEndian.Uint32(size[:])
+			if n == 0 {
+				break
+			}
+			if _, err := io.CopyN(&data, r, int64(n)); err != nil {
+				return
+			}
+		}
+		if f.MaxLength > 0 && int64(data.Len()) > f.MaxLength {
+			conn.Write([]byte("INSTREAM size limit exceeded. ERROR\x00"))
+			return
+		}
+		if bytes.Contains(data.Bytes(), []byte(EICAR)) {
+			conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+		} else {
+			conn.Write([]byte("stream: OK\x00"))
+		}
+	default:
+		conn.Write([]byte("UNKNOWN COMMAND\x00"))
+	}
+}
@@ -0,0 +1,58 @@
+// Package scanner quét virus file tải lên trước khi cho phép người dùng truy cập.
+// Cấu hình qua CLAMD_ADDRESS (vd "tcp://127.0.0.1:3310" hoặc "unix:///var/run/clamav/clamd.ctl");
+// không cấu hình thì tắt quét; cấu hình sai thì dừng khi khởi động thay vì lặng lẽ bỏ qua quét.
+package scanner
+
+import (
+	"io"
+	"log"
+	"os"
+	"sync"
+)
+
+// Result là kết quả quét một file
+type Result struct {
+	Infected  bool
+	Signature string // tên mẫu virus khi Infected
+}
+
+// Scanner quét nội dung một file
+type Scanner interface {
+	Scan(r io.Reader) (Result, error)
+}
+
+var (
+	defaultScanner Scanner
+	defaultOnce    sync.Once
+)
+
+// FromEnv tạo scanner từ CLAMD_ADDRESS, trả về nil (không lỗi) nếu không cấu hình
+func FromEnv() (Scanner, error) {
+	addr := os.Getenv("CLAMD_ADDRESS")
+	if addr == "" {
+		return nil, nil
+	}
+	c, err := NewClamd(addr)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Default trả về scanner theo cấu hình, nil nếu tắt quét.
+// CLAMD_ADDRESS không hợp lệ thì dừng chương trình: file tải lên không được phép coi là sạch khi chưa quét.
+func Default() Scanner {
+	defaultOnce.Do(func() {
+		s, err := FromEnv()
+		if err != nil {
+			log.Fatalf("[SCANNER] CLAMD_ADDRESS không hợp lệ: %v", err)
+		}
+		defaultScanner = s
+	})
+	return defaultScanner
+}
+
+// Enabled cho biết có bật quét virus hay không
+func Enabled() bool {
+	return Default() != nil
+}
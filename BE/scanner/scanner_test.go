@@ -0,0 +1,102 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClamdScan(t *testing.T) {
+	fake, err := StartFakeClamd("tcp", "127.0.0.1:0", 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fake.Close()
+	c, err := NewClamd(fake.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		content       string
+		wantInfected  bool
+		wantSignature string
+		wantErr       bool
+	}{
+		{name: "clean", content: "báo cáo tháng 10"},
+		{name: "infected", content: "header " + EICAR + " footer", wantInfected: true, wantSignature: "Eicar-Test-Signature"},
+		{name: "clamd error", content: strings.Repeat("a", 2048), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Scan(strings.NewReader(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scan err = %v, wantErr %v", err, tt.wantErr)
+			}
+			// Lỗi quét không bao giờ được coi là file sạch
+			if tt.wantErr {
+				if got != (Result{}) {
+					t.Errorf("Scan lỗi nhưng vẫn trả kết quả %+v", got)
+				}
+				return
+			}
+			if got.Infected != tt.wantInfected || got.Signature != tt.wantSignature {
+				t.Errorf("Scan = %+v, want infected=%v signature=%q", got, tt.wantInfected, tt.wantSignature)
+			}
+		})
+	}
+}
+
+func TestClamdUnreachable(t *testing.T) {
+	fake, err := StartFakeClamd("tcp", "127.0.0.1:0", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := fake.Address()
+	fake.Close()
+	c, _ := NewClamd(addr)
+	if _, err := c.Scan(strings.NewReader("x")); err == nil {
+		t.Fatal("clamd không kết nối được phải trả lỗi để file giữ trạng thái pending")
+	}
+}
+
+func TestParseClamdReply(t *testing.T) {
+	tests := []struct {
+		reply   string
+		want    Result
+		wantErr bool
+	}{
+		{reply: "stream: OK\x00", want: Result{}},
+		{reply: "stream: Win.Test.EICAR_HDB-1 FOUND\x00", want: Result{Infected: true, Signature: "Win.Test.EICAR_HDB-1"}},
+		{reply: "INSTREAM size limit exceeded. ERROR\x00", wantErr: true},
+		{reply: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseClamdReply(tt.reply)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseClamdReply(%q) = %+v, %v", tt.reply, got, err)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		addr    string
+		enabled bool
+		wantErr bool
+	}{
+		{addr: "", enabled: false},
+		{addr: "tcp://127.0.0.1:3310", enabled: true},
+		{addr: "unix:///var/run/clamav/clamd.ctl", enabled: true},
+		{addr: "127.0.0.1:3310", enabled: true},
+		{addr: "http://127.0.0.1:3310", wantErr: true},
+		{addr: "unix://", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Setenv("CLAMD_ADDRESS", tt.addr)
+		s, err := FromEnv()
+		if (err != nil) != tt.wantErr || (s != nil) != tt.enabled {
+			t.Errorf("FromEnv(%q) = %v, %v", tt.addr, s, err)
+		}
+	}
+}
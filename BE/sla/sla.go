@@ -0,0 +1,170 @@
+// Package sla tính hạn phản hồi/xử lý của ticket theo chính sách SLA (tính bằng giờ làm việc),
+// tạm dừng đồng hồ khi ticket chờ khách hàng phản hồi và đánh dấu vi phạm.
+package sla
+
+import (
+	"awesomeProject/calendar"
+	"awesomeProject/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status là trạng thái SLA hiện tại của một ticket, RemainingSeconds tính theo giờ làm việc
+type Status struct {
+	DueAt            *time.Time
+	Breached         bool
+	Paused           bool
+	RemainingSeconds *int64
+}
+
+// FindPolicy tìm chính sách đang hoạt động cho priority/category, ưu tiên chính sách riêng của category
+func FindPolicy(priorityID, categoryID uint) *models.SLAPolicy {
+	var policy models.SLAPolicy
+	err := models.DB.Where("is_active = ? AND priority_id = ? AND (category_id = ? OR category_id IS NULL)", true, priorityID, categoryID).
+		Order("category_id IS NULL, id").First(&policy).Error
+	if err != nil {
+		return nil
+	}
+	return &policy
+}
+
+// Apply gắn chính sách SLA phù hợp cho ticket và tính lại các hạn (chưa lưu DB).
+// Gọi khi tạo ticket hoặc khi priority/category thay đổi.
+func Apply(t *models.Ticket, now time.Time) {
+	policy := FindPolicy(t.PriorityID, t.CategoryID)
+	if policy == nil {
+		t.SLAPolicyID = nil
+		t.FirstResponseDueAt = nil
+		t.ResolutionDueAt = nil
+		t.SLADueAt = nil
+		return
+	}
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = now
+	}
+	t.SLAPolicyID = &policy.ID
+	schedule(t, *policy)
+	refreshDue(t)
+}
+
+// Track cập nhật đồng hồ SLA sau khi ticket đổi trạng thái hoặc có phản hồi đầu tiên.
+// paused cho biết trạng thái mới có tạm dừng SLA hay không. Ticket đã xử lý/đã đóng cũng dừng đồng hồ,
+// nên khi mở lại các hạn được lùi thêm đúng khoảng thời gian ticket ở trạng thái đó.
+func Track(t *models.Ticket, paused bool, now time.Time) {
+	if t.SLAPolicyID == nil {
+		return
+	}
+	// Hạn hiện tại đã trôi qua trước thời điểm thay đổi => vi phạm
+	if t.SLAPausedAt == nil && t.SLADueAt != nil && now.After(*t.SLADueAt) {
+		MarkBreached(t)
+	}
+	paused = paused || t.ResolvedAt != nil || t.ClosedAt != nil
+	switch {
+	case paused && t.SLAPausedAt == nil:
+		t.SLAPausedAt = &now
+	case !paused && t.SLAPausedAt != nil:
+		d := calendar.Default().Between(*t.SLAPausedAt, now)
+		t.SLAPausedSeconds += int64(d.Seconds())
+		t.SLAPausedAt = nil
+		extend(t, d)
+	}
+	refreshDue(t)
+}
+
+// MarkBreached đánh dấu vi phạm cho hạn đang áp dụng (phản hồi đầu tiên hoặc xử lý)
+func MarkBreached(t *models.Ticket) {
+	if t.SLADueAt == nil {
+		return
+	}
+	t.SLABreached = true
+	if t.FirstResponseDueAt != nil && t.SLADueAt.Equal(*t.FirstResponseDueAt) {
+		t.ResponseBreached = true
+	}
+	if t.ResolutionDueAt != nil && t.SLADueAt.Equal(*t.ResolutionDueAt) {
+		t.ResolutionBreached = true
+	}
+}
+
+// Evaluate trả về trạng thái SLA của ticket tại thời điểm now
+func Evaluate(t models.Ticket, now time.Time) Status {
+	st := Status{DueAt: t.SLADueAt, Breached: t.SLABreached, Paused: t.SLAPausedAt != nil}
+	if t.SLADueAt == nil {
+		return st
+	}
+	ref := now
+	if st.Paused {
+		ref = *t.SLAPausedAt
+	}
+	remaining := int64(calendar.Default().Between(ref, *t.SLADueAt).Seconds())
+	st.RemainingSeconds = &remaining
+	if remaining < 0 {
+		st.Breached = true
+	}
+	return st
+}
+
+// ScopeBreached lọc các ticket đã vi phạm SLA
+func ScopeBreached(now time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(tickets.sla_breached = ? OR (tickets.sla_paused_at IS NULL AND tickets.sla_due_at < ?))", true, now)
+	}
+}
+
+// ScopeBreachingSoon lọc các ticket chưa vi phạm hạn đang áp dụng nhưng sẽ hết hạn trong khoảng window
+func ScopeBreachingSoon(now time.Time, window time.Duration) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(scopeCurrentTargetNotBreached).
+			Where("tickets.sla_paused_at IS NULL AND tickets.sla_due_at >= ? AND tickets.sla_due_at < ?", now, now.Add(window))
+	}
+}
+
+// ScopeNewlyBreached lọc các ticket vừa vượt hạn đang áp dụng nhưng chưa được đánh dấu vi phạm hạn đó
+func ScopeNewlyBreached(now time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(scopeCurrentTargetNotBreached).
+			Where("tickets.sla_policy_id IS NOT NULL AND tickets.sla_paused_at IS NULL AND tickets.sla_due_at < ?", now)
+	}
+}
+
+// scopeCurrentTargetNotBreached: chưa phản hồi thì xét hạn phản hồi, đã phản hồi thì xét hạn xử lý
+func scopeCurrentTargetNotBreached(db *gorm.DB) *gorm.DB {
+	return db.Where("((tickets.first_response_at IS NULL AND tickets.response_breached = ?) OR (tickets.first_response_at IS NOT NULL AND tickets.resolution_breached = ?))", false, false)
+}
+
+// schedule tính hạn phản hồi và hạn xử lý (theo giờ làm việc) từ thời điểm tạo ticket,
+// cộng thêm thời gian đã tạm dừng
+func schedule(t *models.Ticket, policy models.SLAPolicy) {
+	cal := calendar.Default()
+	paused := time.Duration(t.SLAPausedSeconds) * time.Second
+	firstDue := cal.Add(t.CreatedAt, time.Duration(policy.FirstResponseMinutes)*time.Minute+paused)
+	resolutionDue := cal.Add(t.CreatedAt, time.Duration(policy.ResolutionMinutes)*time.Minute+paused)
+	t.FirstResponseDueAt = &firstDue
+	t.ResolutionDueAt = &resolutionDue
+}
+
+// extend lùi các hạn thêm d giờ làm việc (thời gian vừa tạm dừng)
+func extend(t *models.Ticket, d time.Duration) {
+	cal := calendar.Default()
+	if t.FirstResponseDueAt != nil {
+		firstDue := cal.Add(*t.FirstResponseDueAt, d)
+		t.FirstResponseDueAt = &firstDue
+	}
+	if t.ResolutionDueAt != nil {
+		resolutionDue := cal.Add(*t.ResolutionDueAt, d)
+		t.ResolutionDueAt = &resolutionDue
+	}
+}
+
+// refreshDue chọn hạn đang áp dụng: hạn phản hồi khi chưa có phản hồi, hạn xử lý khi đã phản hồi,
+// không còn hạn khi ticket đã xử lý/đã đóng
+func refreshDue(t *models.Ticket) {
+	switch {
+	case t.ResolvedAt != nil || t.ClosedAt != nil:
+		t.SLADueAt = nil
+	case t.FirstResponseAt == nil:
+		t.SLADueAt = t.FirstResponseDueAt
+	default:
+		t.SLADueAt = t.ResolutionDueAt
+	}
+}
@@ -0,0 +1,164 @@
+package sla
+
+import (
+	"awesomeProject/calendar"
+	"awesomeProject/models"
+	"testing"
+	"time"
+)
+
+// Lịch thử: thứ Hai - thứ Sáu 09:00-17:00 UTC; 05/01/2026 là thứ Hai
+func useTestCalendar(t *testing.T) {
+	office := []calendar.Interval{{Start: 9 * 60, End: 17 * 60}}
+	calendar.SetDefault(&calendar.Calendar{
+		Location: time.UTC,
+		Hours: map[time.Weekday][]calendar.Interval{
+			time.Monday: office, time.Tuesday: office, time.Wednesday: office, time.Thursday: office, time.Friday: office,
+		},
+		Holidays: map[string]bool{},
+	})
+	t.Cleanup(func() { calendar.SetDefault(nil) })
+}
+
+func at(day, hour, min int) time.Time {
+	return time.Date(2026, 1, day, hour, min, 0, 0, time.UTC)
+}
+
+// newTicket tạo ticket có chính sách phản hồi trong 2 giờ, xử lý trong 16 giờ làm việc
+func newTicket(created time.Time) *models.Ticket {
+	policyID := uint(1)
+	tk := &models.Ticket{CreatedAt: created, SLAPolicyID: &policyID}
+	schedule(tk, models.SLAPolicy{FirstResponseMinutes: 120, ResolutionMinutes: 16 * 60})
+	refreshDue(tk)
+	return tk
+}
+
+func assertTime(t *testing.T, name string, got *time.Time, want time.Time) {
+	t.Helper()
+	if got == nil || !got.Equal(want) {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	useTestCalendar(t)
+	tests := []struct {
+		name          string
+		created       time.Time
+		pausedSeconds int64
+		firstDue      time.Time
+		resolutionDue time.Time
+	}{
+		{"trong giờ làm", at(5, 16, 0), 0, at(6, 10, 0), at(7, 16, 0)},
+		{"ngoài giờ làm tính từ đầu ngày làm tiếp theo", at(5, 20, 0), 0, at(6, 11, 0), at(7, 17, 0)},
+		{"cuối tuần", at(10, 12, 0), 0, at(12, 11, 0), at(13, 17, 0)},
+		{"cộng thời gian đã tạm dừng", at(5, 16, 0), 3600, at(6, 11, 0), at(7, 17, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policyID := uint(1)
+			tk := &models.Ticket{CreatedAt: tt.created, SLAPolicyID: &policyID, SLAPausedSeconds: tt.pausedSeconds}
+			schedule(tk, models.SLAPolicy{FirstResponseMinutes: 120, ResolutionMinutes: 16 * 60})
+			refreshDue(tk)
+			assertTime(t, "FirstResponseDueAt", tk.FirstResponseDueAt, tt.firstDue)
+			assertTime(t, "ResolutionDueAt", tk.ResolutionDueAt, tt.resolutionDue)
+			assertTime(t, "SLADueAt", tk.SLADueAt, tt.firstDue)
+		})
+	}
+}
+
+func TestTrackPauseExtendsDue(t *testing.T) {
+	useTestCalendar(t)
+	tk := newTicket(at(5, 16, 0))
+	Track(tk, true, at(5, 16, 30))
+	assertTime(t, "SLAPausedAt", tk.SLAPausedAt, at(5, 16, 30))
+
+	// Đang tạm dừng thì thời gian còn lại không đổi
+	if st := Evaluate(*tk, at(6, 15, 0)); !st.Paused || *st.RemainingSeconds != int64(90*60) {
+		t.Errorf("Evaluate khi tạm dừng = %+v, còn lại %d", st, *st.RemainingSeconds)
+	}
+
+	// Tạm dừng 30 phút thứ Hai + 1 giờ thứ Ba
+	Track(tk, false, at(6, 10, 0))
+	if tk.SLAPausedAt != nil || tk.SLAPausedSeconds != 5400 {
+		t.Errorf("SLAPausedAt = %v, SLAPausedSeconds = %d", tk.SLAPausedAt, tk.SLAPausedSeconds)
+	}
+	assertTime(t, "FirstResponseDueAt", tk.FirstResponseDueAt, at(6, 11, 30))
+	assertTime(t, "ResolutionDueAt", tk.ResolutionDueAt, at(8, 9, 30))
+	assertTime(t, "SLADueAt", tk.SLADueAt, at(6, 11, 30))
+	if tk.SLABreached {
+		t.Error("không được vi phạm")
+	}
+
+	// Hạn lùi bằng đúng cách tính lại từ đầu với thời gian tạm dừng
+	fresh := newTicket(at(5, 16, 0))
+	fresh.SLAPausedSeconds = tk.SLAPausedSeconds
+	schedule(fresh, models.SLAPolicy{FirstResponseMinutes: 120, ResolutionMinutes: 16 * 60})
+	assertTime(t, "schedule FirstResponseDueAt", fresh.FirstResponseDueAt, *tk.FirstResponseDueAt)
+	assertTime(t, "schedule ResolutionDueAt", fresh.ResolutionDueAt, *tk.ResolutionDueAt)
+}
+
+func TestTrackReopenExcludesResolvedInterval(t *testing.T) {
+	useTestCalendar(t)
+	tk := newTicket(at(5, 16, 0))
+	responded := at(5, 16, 30)
+	tk.FirstResponseAt = &responded
+	Track(tk, false, responded)
+	assertTime(t, "SLADueAt sau phản hồi", tk.SLADueAt, at(7, 16, 0))
+
+	// Đã xử lý thứ Ba 09:00: không còn hạn, đồng hồ dừng
+	resolved := at(6, 9, 0)
+	tk.ResolvedAt = &resolved
+	Track(tk, false, resolved)
+	if tk.SLADueAt != nil || tk.SLAPausedAt == nil {
+		t.Fatalf("sau khi xử lý: SLADueAt = %v, SLAPausedAt = %v", tk.SLADueAt, tk.SLAPausedAt)
+	}
+
+	// Mở lại thứ Hai tuần sau (sau hạn cũ): hạn lùi thêm 32 giờ làm việc đã ở trạng thái đã xử lý
+	reopened := at(12, 9, 0)
+	tk.ResolvedAt = nil
+	Track(tk, false, reopened)
+	assertTime(t, "SLADueAt sau khi mở lại", tk.SLADueAt, at(13, 16, 0))
+	st := Evaluate(*tk, reopened)
+	if st.Breached || tk.SLABreached || *st.RemainingSeconds != int64(15*3600) {
+		t.Errorf("Evaluate sau khi mở lại = %+v, còn lại %d", st, *st.RemainingSeconds)
+	}
+}
+
+func TestMarkBreachedPerTarget(t *testing.T) {
+	useTestCalendar(t)
+	tk := newTicket(at(5, 16, 0))
+
+	// Phản hồi đầu tiên trễ (hạn thứ Ba 10:00): chỉ vi phạm hạn phản hồi
+	responded := at(6, 11, 0)
+	tk.FirstResponseAt = &responded
+	Track(tk, false, responded)
+	if !tk.SLABreached || !tk.ResponseBreached || tk.ResolutionBreached {
+		t.Fatalf("sau phản hồi trễ: breached=%v response=%v resolution=%v", tk.SLABreached, tk.ResponseBreached, tk.ResolutionBreached)
+	}
+	assertTime(t, "SLADueAt", tk.SLADueAt, at(7, 16, 0))
+
+	// Hạn xử lý vẫn được theo dõi và đánh dấu riêng khi trôi qua
+	if st := Evaluate(*tk, at(7, 15, 0)); *st.RemainingSeconds != 3600 {
+		t.Errorf("còn lại %d giây, want 3600", *st.RemainingSeconds)
+	}
+	MarkBreached(tk)
+	if !tk.ResolutionBreached {
+		t.Error("hạn xử lý phải được đánh dấu vi phạm")
+	}
+	if st := Evaluate(*tk, at(8, 10, 0)); !st.Breached || *st.RemainingSeconds >= 0 {
+		t.Errorf("Evaluate sau hạn = %+v", st)
+	}
+}
+
+func TestTrackWithoutPolicy(t *testing.T) {
+	useTestCalendar(t)
+	tk := &models.Ticket{CreatedAt: at(5, 9, 0)}
+	Track(tk, true, at(5, 10, 0))
+	if tk.SLAPausedAt != nil || tk.SLADueAt != nil {
+		t.Errorf("ticket không có SLA không được thay đổi: %+v", tk)
+	}
+	if st := Evaluate(*tk, at(5, 10, 0)); st.DueAt != nil || st.RemainingSeconds != nil {
+		t.Errorf("Evaluate = %+v", st)
+	}
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Local lưu file trong một thư mục trên máy chủ
+type Local struct {
+	Root string
+}
+
+func NewLocal(root string) *Local {
+	return &Local{Root: root}
+}
+
+func (l *Local) path(key string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.Root, filepath.FromSlash(key)), nil
+}
+
+func (l *Local) Put(key string, r io.Reader, size int64, contentType string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return err
+	}
+	// Ghi ra file tạm rồi đổi tên để không để lại file dở dang khi lỗi
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (l *Local) Get(key string) (io.ReadCloser, *ObjectInfo, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, nil, mapLocalError(err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, localInfo(key, info), nil
+}
+
+func (l *Local) Delete(key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) Stat(key string) (*ObjectInfo, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, mapLocalError(err)
+	}
+	return localInfo(key, info), nil
+}
+
+func (l *Local) Presign(key string, ttl time.Duration) (string, error) {
+	return "", ErrPresignNotSupported
+}
+
+func localInfo(key string, info os.FileInfo) *ObjectInfo {
+	return &ObjectInfo{
+		Key:         key,
+		Size:        info.Size(),
+		ContentType: mime.TypeByExtension(filepath.Ext(key)),
+		ModTime:     info.ModTime(),
+	}
+}
+
+func mapLocalError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,292 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Config là cấu hình kết nối dịch vụ tương thích S3 (dùng path-style: endpoint/bucket/key)
+type S3Config struct {
+	Endpoint  string // vd https://s3.ap-southeast-1.amazonaws.com hoặc http://localhost:9000 (MinIO)
+	Region    string // mặc định us-east-1
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Prefix    string // tiền tố thêm vào mọi key, có thể rỗng
+}
+
+// S3 lưu file vào bucket S3, ký request bằng AWS Signature V4
+type S3 struct {
+	cfg      S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+const (
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3MaxPresignTTL   = 7 * 24 * time.Hour
+)
+
+func NewS3(cfg S3Config) (*S3, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, ErrMissingConfiguration
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	u, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("storage: invalid S3 endpoint %q", cfg.Endpoint)
+	}
+	cfg.Prefix = strings.Trim(cfg.Prefix, "/")
+	return &S3{
+		cfg:      cfg,
+		endpoint: u,
+		client:   &http.Client{Timeout: 5 * time.Minute},
+		now:      time.Now,
+	}, nil
+}
+
+func (s *S3) Put(key string, r io.Reader, size int64, contentType string) error {
+	req, err := s.newRequest(http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3) Get(key string) (io.ReadCloser, *ObjectInfo, error) {
+	req, err := s.newRequest(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Body, s.info(key, resp), nil
+}
+
+func (s *S3) Delete(key string) error {
+	req, err := s.newRequest(http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req)
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3) Stat(key string) (*ObjectInfo, error) {
+	req, err := s.newRequest(http.MethodHead, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return s.info(key, resp), nil
+}
+
+// Presign tạo URL GET có chữ ký trên query string, hết hạn sau ttl (tối đa 7 ngày theo giới hạn của S3)
+func (s *S3) Presign(key string, ttl time.Duration) (string, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+	if ttl <= 0 || ttl > s3MaxPresignTTL {
+		ttl = s3MaxPresignTTL
+	}
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := s.scope(now)
+	q := url.Values{}
+	q.Set("X-Amz-Algorithm", s3Algorithm)
+	q.Set("X-Amz-Credential", s.cfg.AccessKey+"/"+scope)
+	q.Set("X-Amz-Date", amzDate)
+	q.Set("X-Amz-Expires", strconv.Itoa(int(ttl.Seconds())))
+	q.Set("X-Amz-SignedHeaders", "host")
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		canonicalQuery(q),
+		"host:" + u.Host + "\n",
+		"host",
+		s3UnsignedPayload,
+	}, "\n")
+	q.Set("X-Amz-Signature", s.signature(now, amzDate, canonical))
+	u.RawQuery = canonicalQuery(q)
+	return u.String(), nil
+}
+
+func (s *S3) objectURL(key string) (*url.URL, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.Prefix != "" {
+		key = s.cfg.Prefix + "/" + key
+	}
+	u := *s.endpoint
+	p := strings.TrimRight(u.Path, "/") + "/" + s.cfg.Bucket + "/" + key
+	u.Path = p
+	u.RawPath = uriEncode(p, false)
+	return &u, nil
+}
+
+func (s *S3) newRequest(method, key string, body io.Reader) (*http.Request, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	return http.NewRequest(method, u.String(), body)
+}
+
+// do ký request và gửi, trả ErrNotFound với 404 và lỗi kèm mã HTTP với các phản hồi lỗi khác
+func (s *S3) do(req *http.Request) (*http.Response, error) {
+	s.sign(req)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("storage: S3 %s %s: %s %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+// sign thêm header Authorization theo AWS Signature V4, payload không ký (UNSIGNED-PAYLOAD)
+func (s *S3) sign(req *http.Request) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": s3UnsignedPayload,
+		"x-amz-date":           amzDate,
+	}
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+		headers["content-type"] = ct
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		s3UnsignedPayload,
+	}, "\n")
+	sig := s.signature(now, amzDate, canonical)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.cfg.AccessKey, s.scope(now), signedHeaders, sig))
+}
+
+func (s *S3) scope(t time.Time) string {
+	return t.Format("20060102") + "/" + s.cfg.Region + "/s3/aws4_request"
+}
+
+func (s *S3) signature(t time.Time, amzDate, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{s3Algorithm, amzDate, s.scope(t), hex.EncodeToString(hash[:])}, "\n")
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), t.Format("20060102"))
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func (s *S3) info(key string, resp *http.Response) *ObjectInfo {
+	info := &ObjectInfo{Key: key, Size: resp.ContentLength, ContentType: resp.Header.Get("Content-Type")}
+	if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.ModTime = t
+	}
+	return info
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery sắp xếp và mã hóa query string theo quy tắc SigV4
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		vals := append([]string{}, q[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode mã hóa theo RFC 3986 như AWS yêu cầu: giữ A-Z a-z 0-9 - _ . ~, tùy chọn mã hóa "/"
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,94 @@
+// Package storage trừu tượng hóa nơi lưu file đính kèm: thư mục local hoặc dịch vụ tương thích S3 (AWS S3, MinIO...).
+// Driver được chọn qua biến môi trường STORAGE_DRIVER ("local" mặc định, hoặc "s3").
+package storage
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotFound             = errors.New("storage: object not found")
+	ErrPresignNotSupported  = errors.New("storage: presign not supported by driver")
+	ErrInvalidKey           = errors.New("storage: invalid key")
+	ErrMissingConfiguration = errors.New("storage: missing configuration")
+)
+
+// ObjectInfo là thông tin của một file đã lưu
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Storage là backend lưu file. Key là đường dẫn tương đối dạng "uploads/tickets/ticket_1.pdf".
+type Storage interface {
+	Put(key string, r io.Reader, size int64, contentType string) error
+	Get(key string) (io.ReadCloser, *ObjectInfo, error)
+	Delete(key string) error
+	Stat(key string) (*ObjectInfo, error)
+	// Presign trả về URL tải trực tiếp có hạn dùng; driver không hỗ trợ trả về ErrPresignNotSupported
+	Presign(key string, ttl time.Duration) (string, error)
+}
+
+var (
+	defaultStorage Storage
+	defaultOnce    sync.Once
+)
+
+// Default trả về backend cấu hình theo biến môi trường, khởi tạo một lần.
+// Cấu hình lỗi thì dừng server thay vì âm thầm ghi file đính kèm ra đĩa local;
+// main gọi Default khi khởi động để lỗi cấu hình lộ ra ngay.
+func Default() Storage {
+	defaultOnce.Do(func() {
+		s, err := FromEnv()
+		if err != nil {
+			log.Fatalf("[STORAGE] Cấu hình lưu trữ không hợp lệ: %v", err)
+		}
+		defaultStorage = s
+	})
+	return defaultStorage
+}
+
+// FromEnv tạo backend theo STORAGE_DRIVER
+func FromEnv() (Storage, error) {
+	switch strings.ToLower(os.Getenv("STORAGE_DRIVER")) {
+	case "", "local":
+		root := os.Getenv("STORAGE_LOCAL_ROOT")
+		if root == "" {
+			root = "."
+		}
+		return NewLocal(root), nil
+	case "s3":
+		return NewS3(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			Prefix:    os.Getenv("S3_PREFIX"),
+		})
+	default:
+		return nil, errors.New("storage: unknown driver " + os.Getenv("STORAGE_DRIVER"))
+	}
+}
+
+// cleanKey chuẩn hóa key và chặn thoát ra ngoài thư mục gốc
+func cleanKey(key string) (string, error) {
+	key = strings.TrimLeft(strings.ReplaceAll(key, "\\", "/"), "/")
+	if key == "" {
+		return "", ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." || part == "." || part == "" {
+			return "", ErrInvalidKey
+		}
+	}
+	return key, nil
+}
@@ -0,0 +1,76 @@
+package thumbnail
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+)
+
+var (
+	pdfObjectRe = regexp.MustCompile(`\d+\s+\d+\s+obj\b`)
+	pdfLengthRe = regexp.MustCompile(`/Length\s+(\d+)(\s+\d+\s+R)?`)
+)
+
+// firstPDFImage tìm ảnh JPEG (DCTDecode) đầu tiên được nhúng trong PDF và trả về dữ liệu JPEG thô.
+// Không dựng lại trang PDF; với tài liệu scan, ảnh đầu tiên chính là trang đầu.
+func firstPDFImage(data []byte) ([]byte, error) {
+	offset := 0
+	for offset < len(data) {
+		loc := pdfObjectRe.FindIndex(data[offset:])
+		if loc == nil {
+			break
+		}
+		start := offset + loc[1]
+		rest := data[start:]
+		streamAt := bytes.Index(rest, []byte("stream"))
+		endObj := bytes.Index(rest, []byte("endobj"))
+		if endObj < 0 {
+			break
+		}
+		if streamAt < 0 || streamAt > endObj {
+			// Object không có stream
+			offset = start + endObj
+			continue
+		}
+		dict := rest[:streamAt]
+		bodyStart := streamAt + len("stream")
+		// Sau từ khóa "stream" là CRLF hoặc LF
+		if bytes.HasPrefix(rest[bodyStart:], []byte("\r\n")) {
+			bodyStart += 2
+		} else if bytes.HasPrefix(rest[bodyStart:], []byte("\n")) {
+			bodyStart++
+		}
+		body := rest[bodyStart:]
+		n := streamLength(dict, body)
+		if n < 0 {
+			break
+		}
+		if isDCTImage(dict) {
+			return body[:n], nil
+		}
+		offset = start + bodyStart + n
+	}
+	return nil, ErrNoPreview
+}
+
+// streamLength lấy độ dài stream từ /Length trực tiếp, nếu là tham chiếu gián tiếp thì tìm tới "endstream"
+func streamLength(dict, body []byte) int {
+	if m := pdfLengthRe.FindSubmatch(dict); m != nil && len(m[2]) == 0 {
+		if n, err := strconv.Atoi(string(m[1])); err == nil && n >= 0 && n <= len(body) {
+			return n
+		}
+	}
+	end := bytes.Index(body, []byte("endstream"))
+	if end < 0 {
+		return -1
+	}
+	return len(bytes.TrimRight(body[:end], "\r\n"))
+}
+
+// isDCTImage kiểm tra dictionary là ảnh chỉ dùng bộ lọc DCTDecode (dữ liệu stream chính là file JPEG)
+func isDCTImage(dict []byte) bool {
+	compact := bytes.Join(bytes.Fields(dict), nil)
+	return bytes.Contains(compact, []byte("/Subtype/Image")) &&
+		bytes.Contains(compact, []byte("/DCTDecode")) &&
+		!bytes.Contains(compact, []byte("/FlateDecode"))
+}
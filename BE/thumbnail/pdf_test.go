@@ -0,0 +1,93 @@
+package thumbnail
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// pdfWithImage dựng PDF tối giản: một object không có stream, một stream nén (không phải ảnh) rồi đến ảnh JPEG
+func pdfWithImage(jpg []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	b.WriteString("3 0 obj\n<< /Length 6 /Filter /FlateDecode >>\nstream\nobj!!!\nendstream\nendobj\n")
+	fmt.Fprintf(&b, "4 0 obj\n<< /Type /XObject /Subtype /Image /Filter /DCTDecode /Length %d >>\nstream\r\n", len(jpg))
+	b.Write(jpg)
+	b.WriteString("\r\nendstream\nendobj\n%%EOF\n")
+	return b.Bytes()
+}
+
+func TestFirstPDFImage(t *testing.T) {
+	jpg := []byte("\xff\xd8JPEG endobj stream\xff\xd9")
+	tests := []struct {
+		name string
+		pdf  string
+		want string // rỗng = không tìm thấy ảnh
+	}{
+		{
+			"/Length trực tiếp",
+			string(pdfWithImage(jpg)),
+			string(jpg),
+		},
+		{
+			"/Length gián tiếp thì tìm endstream",
+			"1 0 obj\n<</Subtype /Image /Filter /DCTDecode /Length 9 0 R>>\nstream\nJPEGDATA\r\nendstream\nendobj\n9 0 obj\n8\nendobj\n",
+			"JPEGDATA",
+		},
+		{
+			"/Length lớn hơn dữ liệu thì tìm endstream",
+			"1 0 obj\n<</Subtype/Image/Filter/DCTDecode/Length 99999>>\nstream\nJPEGDATA\nendstream\nendobj\n",
+			"JPEGDATA",
+		},
+		{
+			"ảnh nén Flate không phải JPEG",
+			"1 0 obj\n<</Subtype/Image/Filter[/FlateDecode/DCTDecode]/Length 4>>\nstream\nDATA\nendstream\nendobj\n",
+			"",
+		},
+		{
+			"stream bị cắt cụt, không có endstream",
+			"1 0 obj\n<</Subtype/Image/Filter/DCTDecode/Length 9 0 R>>\nstream\nJPEGDA",
+			"",
+		},
+		{
+			"thiếu endobj",
+			"1 0 obj\n<</Subtype/Image/Filter/DCTDecode/Length 4>>\nstream\nDATA\nendstream\n",
+			"",
+		},
+		{
+			"object cuối thiếu endobj sau một ảnh không hợp lệ",
+			"1 0 obj\n<</Length 2>>\nstream\nxx\nendstream\nendobj\n2 0 obj\n<</Subtype/Image",
+			"",
+		},
+		{
+			"dừng ngay sau từ khóa stream",
+			"1 0 obj\n<</Subtype/Image/Filter/DCTDecode/Length 0>>\nstream",
+			"",
+		},
+		{"rỗng", "", ""},
+		{"không phải PDF", "hello world", ""},
+	}
+	for _, tt := range tests {
+		got, err := firstPDFImage([]byte(tt.pdf))
+		if tt.want == "" {
+			if err != ErrNoPreview {
+				t.Errorf("%s: = %q, %v, want ErrNoPreview", tt.name, got, err)
+			}
+			continue
+		}
+		if err != nil || string(got) != tt.want {
+			t.Errorf("%s: = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestFirstPDFImageTruncatedAtEveryOffset(t *testing.T) {
+	// PDF bị cắt ở bất kỳ vị trí nào cũng không được panic hay trả về dữ liệu ngoài file
+	full := pdfWithImage(encodeJPEG(t, 16, 16))
+	for i := range full {
+		got, err := firstPDFImage(full[:i])
+		if err == nil && !bytes.Contains(full[:i], got) {
+			t.Fatalf("cắt tại %d: dữ liệu trả về không nằm trong file", i)
+		}
+	}
+}
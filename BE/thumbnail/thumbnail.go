@@ -0,0 +1,157 @@
+// Package thumbnail tạo ảnh thu nhỏ JPEG cho file đính kèm: ảnh JPEG/PNG/GIF và trang đầu của PDF
+// khi trang đó là ảnh nhúng dạng JPEG (thường gặp với tài liệu scan). Chỉ dùng thư viện chuẩn.
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+
+	_ "image/gif"
+	_ "image/png"
+)
+
+// Kích thước tối đa của ảnh thu nhỏ (giữ tỉ lệ)
+const MaxDimension = 320
+
+const (
+	maxInputBytes  = 50 << 20   // không đọc file lớn hơn
+	maxInputPixels = 50_000_000 // chặn ảnh có kích thước giải nén quá lớn
+	jpegQuality    = 80
+)
+
+var (
+	ErrUnsupported = errors.New("thumbnail: unsupported file type")
+	ErrTooLarge    = errors.New("thumbnail: source too large")
+	ErrNoPreview   = errors.New("thumbnail: no previewable image in document")
+)
+
+// Supported cho biết có tạo được ảnh thu nhỏ cho loại MIME này không
+func Supported(mimeType string) bool {
+	switch mimeType {
+	case "image/jpeg", "image/png", "image/gif", "application/pdf":
+		return true
+	}
+	return false
+}
+
+// Generate đọc file nguồn và trả về ảnh thu nhỏ dạng JPEG
+func Generate(r io.Reader, mimeType string) ([]byte, error) {
+	if !Supported(mimeType) {
+		return nil, ErrUnsupported
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxInputBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxInputBytes {
+		return nil, ErrTooLarge
+	}
+	if mimeType == "application/pdf" {
+		if data, err = firstPDFImage(data); err != nil {
+			return nil, err
+		}
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width*cfg.Height > maxInputPixels {
+		return nil, ErrTooLarge
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, Resize(src, MaxDimension), &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Số điểm lấy mẫu tối đa theo mỗi chiều trong một ô nguồn, giới hạn thời gian thu nhỏ ảnh rất lớn
+const samplesPerAxis = 4
+
+// Resize thu nhỏ ảnh để cạnh dài nhất không vượt quá max, lấy trung bình các điểm ảnh nguồn (box filter,
+// tối đa samplesPerAxis x samplesPerAxis điểm mỗi ô). Nền trong suốt được phủ trắng vì JPEG không có kênh alpha.
+func Resize(src image.Image, max int) *image.RGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dw, dh := sw, sh
+	if sw > max || sh > max {
+		if sw >= sh {
+			dw, dh = max, sh*max/sw
+		} else {
+			dw, dh = sw*max/sh, max
+		}
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+	at := pixelReader(src)
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0 := b.Min.Y + y*sh/dh
+		y1 := b.Min.Y + (y+1)*sh/dh
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		ystep := sampleStep(y1 - y0)
+		for x := 0; x < dw; x++ {
+			x0 := b.Min.X + x*sw/dw
+			x1 := b.Min.X + (x+1)*sw/dw
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			xstep := sampleStep(x1 - x0)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy += ystep {
+				for sx := x0; sx < x1; sx += xstep {
+					cr, cg, cb, ca := at(sx, sy)
+					r += uint64(cr)
+					g += uint64(cg)
+					bl += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+			// Trộn với nền trắng theo độ trong suốt (màu đã nhân alpha)
+			white := (0xffff*n - a)
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(((r + white) / n) >> 8),
+				G: uint8(((g + white) / n) >> 8),
+				B: uint8(((bl + white) / n) >> 8),
+				A: 0xff,
+			})
+		}
+	}
+	return dst
+}
+
+// sampleStep là bước nhảy để lấy tối đa samplesPerAxis điểm trên một đoạn dài n
+func sampleStep(n int) int {
+	return (n + samplesPerAxis - 1) / samplesPerAxis
+}
+
+// pixelReader đọc màu (đã nhân alpha, 16 bit) trực tiếp từ các kiểu ảnh do bộ giải mã chuẩn trả về,
+// tránh gọi At() qua interface và cấp phát color.Color cho từng điểm ảnh
+func pixelReader(src image.Image) func(x, y int) (r, g, b, a uint32) {
+	switch img := src.(type) {
+	case *image.YCbCr:
+		return func(x, y int) (uint32, uint32, uint32, uint32) { return img.YCbCrAt(x, y).RGBA() }
+	case *image.RGBA:
+		return func(x, y int) (uint32, uint32, uint32, uint32) { return img.RGBAAt(x, y).RGBA() }
+	case *image.NRGBA:
+		return func(x, y int) (uint32, uint32, uint32, uint32) { return img.NRGBAAt(x, y).RGBA() }
+	case *image.Gray:
+		return func(x, y int) (uint32, uint32, uint32, uint32) { return img.GrayAt(x, y).RGBA() }
+	}
+	return func(x, y int) (uint32, uint32, uint32, uint32) { return src.At(x, y).RGBA() }
+}
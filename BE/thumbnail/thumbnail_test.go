@@ -0,0 +1,172 @@
+package thumbnail
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestResizeDimensions(t *testing.T) {
+	tests := []struct {
+		name         string
+		w, h         int
+		wantW, wantH int
+	}{
+		{"ảnh ngang", 1600, 1200, 320, 240},
+		{"ảnh dọc", 600, 1800, 106, 320},
+		{"ảnh nhỏ giữ nguyên", 100, 50, 100, 50},
+		{"ảnh rất dài không về 0", 10000, 5, 320, 1},
+		{"ảnh 1x1", 1, 1, 1, 1},
+	}
+	for _, tt := range tests {
+		src := image.NewGray(image.Rect(0, 0, tt.w, tt.h))
+		got := Resize(src, MaxDimension).Bounds()
+		if got.Dx() != tt.wantW || got.Dy() != tt.wantH {
+			t.Errorf("%s: %dx%d -> %dx%d, want %dx%d", tt.name, tt.w, tt.h, got.Dx(), got.Dy(), tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestResizeColors(t *testing.T) {
+	checker := image.NewRGBA(image.Rect(0, 0, 640, 640))
+	for y := 0; y < 640; y++ {
+		for x := 0; x < 640; x++ {
+			if (x+y)%2 == 0 {
+				checker.SetRGBA(x, y, color.RGBA{A: 0xff})
+			} else {
+				checker.SetRGBA(x, y, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff})
+			}
+		}
+	}
+	transparent := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	halfRed := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			halfRed.SetNRGBA(x, y, color.NRGBA{R: 0xff, A: 0x80})
+		}
+	}
+	// Ảnh con có Bounds không bắt đầu từ (0,0)
+	sub := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			if x >= 10 && y >= 10 {
+				sub.SetRGBA(x, y, color.RGBA{B: 0xff, A: 0xff})
+			}
+		}
+	}
+	ycbcr := image.NewYCbCr(image.Rect(0, 0, 4, 4), image.YCbCrSubsampleRatio420)
+	for i := range ycbcr.Y {
+		ycbcr.Y[i] = 0xff
+	}
+	for i := range ycbcr.Cb {
+		ycbcr.Cb[i], ycbcr.Cr[i] = 0x80, 0x80
+	}
+	tests := []struct {
+		name string
+		src  image.Image
+		want color.RGBA
+		tol  uint8
+	}{
+		{"bàn cờ đen trắng thành xám", checker, color.RGBA{0x7f, 0x7f, 0x7f, 0xff}, 2},
+		{"trong suốt thành nền trắng", transparent, color.RGBA{0xff, 0xff, 0xff, 0xff}, 0},
+		{"đỏ bán trong suốt trộn nền trắng", halfRed, color.RGBA{0xff, 0x7f, 0x7f, 0xff}, 2},
+		{"ảnh con lệch gốc tọa độ", sub.SubImage(image.Rect(10, 10, 20, 20)), color.RGBA{0, 0, 0xff, 0xff}, 0},
+		{"YCbCr trắng", ycbcr, color.RGBA{0xff, 0xff, 0xff, 0xff}, 1},
+	}
+	for _, tt := range tests {
+		dst := Resize(tt.src, MaxDimension)
+		got := dst.RGBAAt(dst.Bounds().Dx()/2, dst.Bounds().Dy()/2)
+		if !near(got, tt.want, tt.tol) {
+			t.Errorf("%s: màu = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func near(a, b color.RGBA, tol uint8) bool {
+	d := func(x, y uint8) uint8 {
+		if x > y {
+			return x - y
+		}
+		return y - x
+	}
+	return d(a.R, b.R) <= tol && d(a.G, b.G) <= tol && d(a.B, b.B) <= tol && a.A == b.A
+}
+
+func encodeJPEG(t testing.TB, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGenerate(t *testing.T) {
+	var pngBuf bytes.Buffer
+	png.Encode(&pngBuf, image.NewNRGBA(image.Rect(0, 0, 800, 400)))
+	tests := []struct {
+		name     string
+		data     []byte
+		mime     string
+		wantW    int
+		wantErr  error
+		anyError bool
+	}{
+		{"JPEG", encodeJPEG(t, 1000, 500), "image/jpeg", 320, nil, false},
+		{"PNG", pngBuf.Bytes(), "image/png", 320, nil, false},
+		{"PDF scan", pdfWithImage(encodeJPEG(t, 640, 900)), "application/pdf", 227, nil, false},
+		{"loại không hỗ trợ", []byte("x"), "text/plain", 0, ErrUnsupported, false},
+		{"ảnh hỏng", []byte("\xff\xd8\xff garbage"), "image/jpeg", 0, nil, true},
+		{"PDF không có ảnh", []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n"), "application/pdf", 0, ErrNoPreview, false},
+	}
+	for _, tt := range tests {
+		out, err := Generate(bytes.NewReader(tt.data), tt.mime)
+		if tt.wantErr != nil || tt.anyError {
+			if err == nil || (tt.wantErr != nil && err != tt.wantErr) {
+				t.Errorf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Generate: %v", tt.name, err)
+			continue
+		}
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(out))
+		if err != nil || cfg.Width != tt.wantW {
+			t.Errorf("%s: ảnh thu nhỏ rộng %d (%v), want %d", tt.name, cfg.Width, err, tt.wantW)
+		}
+	}
+}
+
+func TestGenerateRejectsHugeDimensions(t *testing.T) {
+	// Header PNG khai báo 10000x10000 điểm ảnh: phải từ chối trước khi giải nén
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1)))
+	data := buf.Bytes()
+	// IHDR: width/height nằm ở byte 16-23
+	copy(data[16:24], []byte{0, 0, 0x27, 0x10, 0, 0, 0x27, 0x10})
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+	if _, err := Generate(bytes.NewReader(data), "image/png"); err != ErrTooLarge {
+		t.Errorf("err = %v, want ErrTooLarge", err)
+	}
+	if _, err := Generate(strings.NewReader(strings.Repeat("x", maxInputBytes+1)), "image/jpeg"); err != ErrTooLarge {
+		t.Errorf("file quá lớn: err = %v, want ErrTooLarge", err)
+	}
+}
+
+func BenchmarkResize(b *testing.B) {
+	img, err := jpeg.Decode(bytes.NewReader(encodeJPEG(b, 6000, 4000)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Resize(img, MaxDimension)
+	}
+}
@@ -0,0 +1,49 @@
+package upload
+
+import (
+	"awesomeProject/models"
+	"strings"
+)
+
+// PolicyFor trả về chính sách upload của ngữ cảnh, dùng giá trị mặc định nếu chưa cấu hình
+func PolicyFor(context string) Policy {
+	var p models.UploadPolicy
+	if err := models.DB.Where("context = ?", context).First(&p).Error; err != nil {
+		for _, d := range models.DefaultUploadPolicies {
+			if d.Context == context {
+				return FromModel(d)
+			}
+		}
+		return FromModel(models.DefaultUploadPolicies[0])
+	}
+	return FromModel(p)
+}
+
+// FromModel chuyển cấu hình trong DB thành Policy
+func FromModel(p models.UploadPolicy) Policy {
+	policy := Policy{
+		MaxSize:          int64(p.MaxSizeMB) << 20,
+		MaxFiles:         p.MaxFiles,
+		AllowedMimeTypes: SplitList(p.AllowedMimeTypes),
+	}
+	for _, ext := range SplitList(p.AllowedExtensions) {
+		policy.AllowedExtensions = append(policy.AllowedExtensions, NormalizeExtension(ext))
+	}
+	return policy
+}
+
+// IsValidContext kiểm tra tên ngữ cảnh upload
+func IsValidContext(context string) bool {
+	return context == ContextTicket || context == ContextComment || context == ContextKnowledge
+}
+
+// SplitList tách chuỗi phân tách bằng dấu phẩy, bỏ phần tử rỗng
+func SplitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
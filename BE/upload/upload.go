@@ -0,0 +1,302 @@
+// Package upload kiểm tra file người dùng tải lên theo chính sách từng ngữ cảnh (ticket, bình luận, knowledge base):
+// giới hạn dung lượng, danh sách đuôi file/MIME cho phép, nhận diện nội dung thực và chặn file thực thi.
+package upload
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/gosimple/slug"
+)
+
+// Ngữ cảnh upload
+const (
+	ContextTicket    = "ticket"
+	ContextComment   = "comment"
+	ContextKnowledge = "knowledge"
+)
+
+// Mã lỗi kiểm tra file
+const (
+	ErrTooLarge       = "file_too_large"
+	ErrTooManyFiles   = "too_many_files"
+	ErrExtension      = "extension_not_allowed"
+	ErrMimeType       = "mime_type_not_allowed"
+	ErrExecutable     = "executable_rejected"
+	ErrEmptyFile      = "empty_file"
+	ErrUnreadableFile = "unreadable_file"
+)
+
+// Error là lỗi kiểm tra file, Message dùng để trả trực tiếp cho người dùng
+type Error struct {
+	Code     string `json:"code"`
+	Filename string `json:"filename"`
+	Message  string `json:"message"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Policy là chính sách upload của một ngữ cảnh
+type Policy struct {
+	MaxSize           int64    // byte, 0 = không giới hạn
+	MaxFiles          int      // 0 = không giới hạn
+	AllowedExtensions []string // vd ".pdf"; rỗng = mọi đuôi (trừ file thực thi)
+	AllowedMimeTypes  []string // vd "image/*", "application/pdf"; rỗng = mọi loại
+}
+
+// File là kết quả kiểm tra một file hợp lệ
+type File struct {
+	Header      *multipart.FileHeader // nil nếu file không đến từ form, vd file đính kèm email
+	Size        int64
+	DisplayName string // tên gốc đã chuẩn hóa để hiển thị
+	SafeName    string // tên ASCII an toàn để lưu trữ (không kèm đuôi)
+	Ext         string // đuôi file viết thường, có dấu chấm
+	MimeType    string // MIME xác định từ nội dung
+	open        func() (io.ReadCloser, error)
+}
+
+// Open mở nội dung file để lưu trữ
+func (f *File) Open() (io.ReadCloser, error) {
+	return f.open()
+}
+
+// Đuôi file thực thi/script luôn bị chặn
+var blockedExtensions = map[string]bool{
+	".exe": true, ".dll": true, ".com": true, ".scr": true, ".msi": true, ".msp": true,
+	".bat": true, ".cmd": true, ".ps1": true, ".vbs": true, ".vbe": true, ".js": true,
+	".jse": true, ".wsf": true, ".wsh": true, ".hta": true, ".cpl": true, ".jar": true,
+	".sh": true, ".bin": true, ".run": true, ".app": true, ".apk": true, ".elf": true,
+	".so": true, ".dylib": true, ".lnk": true, ".reg": true, ".php": true, ".phtml": true,
+}
+
+// Chữ ký đầu file của định dạng thực thi phổ biến
+var executableMagic = [][]byte{
+	[]byte("MZ"),             // Windows PE
+	[]byte("\x7fELF"),        // Linux ELF
+	{0xfe, 0xed, 0xfa, 0xce}, // Mach-O 32
+	{0xfe, 0xed, 0xfa, 0xcf}, // Mach-O 64
+	{0xce, 0xfa, 0xed, 0xfe}, // Mach-O 32 LE
+	{0xcf, 0xfa, 0xed, 0xfe}, // Mach-O 64 LE
+	{0xca, 0xfe, 0xba, 0xbe}, // Mach-O universal / Java class
+	[]byte("#!"),             // script có shebang
+}
+
+// CheckCount kiểm tra số lượng file trong một lần gửi
+func CheckCount(n int, p Policy) error {
+	if p.MaxFiles > 0 && n > p.MaxFiles {
+		return &Error{Code: ErrTooManyFiles, Message: fmt.Sprintf("Chỉ được đính kèm tối đa %d file", p.MaxFiles)}
+	}
+	return nil
+}
+
+// Validate kiểm tra một file từ form theo chính sách và trả về thông tin đã chuẩn hóa
+func Validate(fh *multipart.FileHeader, p Policy) (*File, error) {
+	f, err := validate(fh.Filename, fh.Size, func() (io.ReadCloser, error) { return fh.Open() }, p)
+	if err != nil {
+		return nil, err
+	}
+	f.Header = fh
+	return f, nil
+}
+
+// ValidateBytes kiểm tra file có sẵn nội dung trong bộ nhớ (vd file đính kèm email)
+func ValidateBytes(name string, data []byte, p Policy) (*File, error) {
+	return validate(name, int64(len(data)), func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}, p)
+}
+
+func validate(name string, size int64, open func() (io.ReadCloser, error), p Policy) (*File, error) {
+	display := SanitizeDisplayName(name)
+	ext := strings.ToLower(filepath.Ext(display))
+	fail := func(code, msg string) (*File, error) {
+		return nil, &Error{Code: code, Filename: display, Message: msg}
+	}
+
+	if size <= 0 {
+		return fail(ErrEmptyFile, fmt.Sprintf("File %s rỗng", display))
+	}
+	if p.MaxSize > 0 && size > p.MaxSize {
+		return fail(ErrTooLarge, fmt.Sprintf("File %s vượt quá dung lượng cho phép (%s)", display, FormatSize(p.MaxSize)))
+	}
+	if blockedExtensions[ext] {
+		return fail(ErrExecutable, fmt.Sprintf("Không cho phép tải lên file thực thi (%s)", display))
+	}
+	if len(p.AllowedExtensions) > 0 && !containsFold(p.AllowedExtensions, ext) {
+		return fail(ErrExtension, fmt.Sprintf("Định dạng file %s không được phép", display))
+	}
+
+	head, err := readHead(open)
+	if err != nil {
+		return fail(ErrUnreadableFile, fmt.Sprintf("Không đọc được file %s", display))
+	}
+	if IsExecutable(head) {
+		return fail(ErrExecutable, fmt.Sprintf("Không cho phép tải lên file thực thi (%s)", display))
+	}
+	mimeType := DetectMimeType(head, ext)
+	if !contentMatchesExtension(mimeType, ext) {
+		return fail(ErrMimeType, fmt.Sprintf("Nội dung file %s không khớp với định dạng %s", display, ext))
+	}
+	if len(p.AllowedMimeTypes) > 0 && !matchMime(p.AllowedMimeTypes, mimeType) {
+		return fail(ErrMimeType, fmt.Sprintf("Loại nội dung %s của file %s không được phép", mimeType, display))
+	}
+
+	return &File{
+		Size:        size,
+		DisplayName: display,
+		SafeName:    SafeBaseName(display),
+		Ext:         ext,
+		MimeType:    mimeType,
+		open:        open,
+	}, nil
+}
+
+func readHead(open func() (io.ReadCloser, error)) ([]byte, error) {
+	f, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// IsExecutable nhận diện file thực thi theo chữ ký đầu file
+func IsExecutable(head []byte) bool {
+	for _, magic := range executableMagic {
+		if bytes.HasPrefix(head, magic) {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectMimeType xác định MIME từ nội dung; với kết quả chung chung (zip, text, octet-stream)
+// thì dùng MIME theo đuôi file nếu có, vd .docx là zip nhưng nên trả về MIME của Word
+func DetectMimeType(head []byte, ext string) string {
+	sniffed := http.DetectContentType(head)
+	if mt, _, err := mime.ParseMediaType(sniffed); err == nil {
+		sniffed = mt
+	}
+	switch sniffed {
+	case "application/octet-stream", "application/zip", "text/plain":
+		if byExt := mime.TypeByExtension(ext); byExt != "" {
+			if mt, _, err := mime.ParseMediaType(byExt); err == nil && compatibleMime(sniffed, mt) {
+				return mt
+			}
+		}
+	}
+	return sniffed
+}
+
+// compatibleMime chỉ cho phép "nâng" MIME chung chung lên MIME theo đuôi khi cùng nhóm nội dung,
+// tránh file nhị phân đặt đuôi .png được coi là ảnh
+func compatibleMime(sniffed, byExt string) bool {
+	switch sniffed {
+	case "text/plain":
+		return strings.HasPrefix(byExt, "text/") || byExt == "application/json" || byExt == "application/xml"
+	case "application/zip":
+		return strings.Contains(byExt, "openxmlformats") || strings.Contains(byExt, "opendocument") || byExt == "application/zip"
+	default:
+		return !strings.HasPrefix(byExt, "image/") && !strings.HasPrefix(byExt, "text/") && byExt != "application/pdf"
+	}
+}
+
+// contentMatchesExtension chặn file giả mạo đuôi ảnh/PDF (vd file văn bản đổi tên thành .png)
+func contentMatchesExtension(mimeType, ext string) bool {
+	byExt, _, err := mime.ParseMediaType(mime.TypeByExtension(ext))
+	if err != nil {
+		return true
+	}
+	if strings.HasPrefix(byExt, "image/") {
+		return strings.HasPrefix(mimeType, "image/")
+	}
+	if byExt == "application/pdf" {
+		return mimeType == "application/pdf"
+	}
+	return true
+}
+
+func matchMime(allowed []string, mimeType string) bool {
+	for _, a := range allowed {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a == mimeType || (strings.HasSuffix(a, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(a, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, v string) bool {
+	for _, item := range list {
+		if strings.EqualFold(NormalizeExtension(item), v) {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeExtension đưa đuôi file về dạng ".pdf"
+func NormalizeExtension(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// SanitizeDisplayName bỏ đường dẫn, ký tự điều khiển và khoảng trắng thừa trong tên file gốc
+func SanitizeDisplayName(name string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	name = filepath.Base(name)
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" || name == "." || name == "/" {
+		name = "file"
+	}
+	if len([]rune(name)) > 200 {
+		ext := filepath.Ext(name)
+		name = string([]rune(strings.TrimSuffix(name, ext))[:200-len([]rune(ext))]) + ext
+	}
+	return name
+}
+
+// SafeBaseName chuyển tên file (bỏ đuôi) thành dạng ASCII không dấu, vd "Hướng dẫn sử dụng.pdf" -> "huong-dan-su-dung"
+func SafeBaseName(name string) string {
+	base := slug.Make(strings.TrimSuffix(name, filepath.Ext(name)))
+	if len(base) > 80 {
+		base = strings.Trim(base[:80], "-")
+	}
+	if base == "" {
+		base = "file"
+	}
+	return base
+}
+
+// FormatSize hiển thị dung lượng dễ đọc
+func FormatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.0fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.0fKB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
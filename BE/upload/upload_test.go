@@ -0,0 +1,130 @@
+package upload
+
+import (
+	"awesomeProject/models"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var (
+	pngData  = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+	jpegData = []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00")
+	pdfData  = []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n")
+	zipData  = []byte("PK\x03\x04\x14\x00\x06\x00\x08\x00\x00\x00!\x00[Content_Types].xml")
+	textData = []byte("2026-01-05 09:00:00 ERROR không kết nối được máy chủ\n")
+)
+
+func TestValidateBytes(t *testing.T) {
+	ticket := FromModel(models.DefaultUploadPolicies[0])
+	imagesOnly := Policy{AllowedMimeTypes: []string{"image/*"}}
+	tests := []struct {
+		name     string
+		file     string
+		data     []byte
+		policy   Policy
+		wantCode string
+		wantMime string
+	}{
+		{"ảnh png", "screenshot.png", pngData, ticket, "", "image/png"},
+		{"đuôi viết hoa", "ẢNH.JPG", jpegData, ticket, "", "image/jpeg"},
+		{"pdf", "hóa đơn.pdf", pdfData, ticket, "", "application/pdf"},
+		{"văn bản", "notes.txt", textData, ticket, "", "text/plain"},
+		{"log", "server.log", textData, ticket, "", ""},
+		{"file office là zip", "báo cáo.docx", zipData, ticket, "", ""},
+		{"rỗng", "a.txt", nil, ticket, ErrEmptyFile, ""},
+		{"quá dung lượng", "a.txt", textData, Policy{MaxSize: 10}, ErrTooLarge, ""},
+		{"đuôi không được phép", "logo.svg", []byte("<svg></svg>"), ticket, ErrExtension, ""},
+		{"đuôi thực thi luôn bị chặn", "setup.exe", []byte("MZ\x90\x00"), Policy{}, ErrExecutable, ""},
+		{"script không có trong danh sách", "run.sh", []byte("echo hi"), Policy{}, ErrExecutable, ""},
+		{"nội dung thực thi đổi đuôi", "invoice.pdf", []byte("MZ\x90\x00\x03\x00\x00\x00"), ticket, ErrExecutable, ""},
+		{"shebang trong file văn bản", "notes.txt", []byte("#!/bin/sh\nrm -rf /\n"), ticket, ErrExecutable, ""},
+		{"văn bản giả ảnh", "photo.png", textData, ticket, ErrMimeType, ""},
+		{"văn bản giả pdf", "contract.pdf", textData, ticket, ErrMimeType, ""},
+		{"MIME không được phép", "contract.pdf", pdfData, imagesOnly, ErrMimeType, ""},
+		{"MIME theo nhóm", "photo.png", pngData, imagesOnly, "", "image/png"},
+		{"tên có đường dẫn", "../../etc/passwd.txt", textData, ticket, "", "text/plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ValidateBytes(tt.file, tt.data, tt.policy)
+			if tt.wantCode != "" {
+				var ue *Error
+				if !errors.As(err, &ue) || ue.Code != tt.wantCode {
+					t.Fatalf("err = %v, want %s", err, tt.wantCode)
+				}
+				if ue.Message == "" || ue.Filename == "" {
+					t.Errorf("lỗi thiếu thông tin: %+v", ue)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if tt.wantMime != "" && f.MimeType != tt.wantMime {
+				t.Errorf("MimeType = %s, want %s", f.MimeType, tt.wantMime)
+			}
+			if f.Size != int64(len(tt.data)) || strings.ContainsAny(f.DisplayName, "/\\") || f.Ext != strings.ToLower(f.Ext) {
+				t.Errorf("File = %+v", f)
+			}
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			rc.Close()
+		})
+	}
+}
+
+func TestCheckCount(t *testing.T) {
+	p := Policy{MaxFiles: 2}
+	if err := CheckCount(2, p); err != nil {
+		t.Errorf("CheckCount(2) = %v", err)
+	}
+	var ue *Error
+	if err := CheckCount(3, p); !errors.As(err, &ue) || ue.Code != ErrTooManyFiles {
+		t.Errorf("CheckCount(3) = %v", err)
+	}
+	if err := CheckCount(100, Policy{}); err != nil {
+		t.Errorf("không giới hạn: %v", err)
+	}
+}
+
+func TestFromModel(t *testing.T) {
+	p := FromModel(models.UploadPolicy{MaxSizeMB: 5, MaxFiles: 3, AllowedExtensions: "pdf, .PNG,,jpg ", AllowedMimeTypes: "image/*, application/pdf"})
+	if p.MaxSize != 5<<20 || p.MaxFiles != 3 {
+		t.Errorf("Policy = %+v", p)
+	}
+	if strings.Join(p.AllowedExtensions, ",") != ".pdf,.png,.jpg" {
+		t.Errorf("AllowedExtensions = %v", p.AllowedExtensions)
+	}
+	if strings.Join(p.AllowedMimeTypes, ",") != "image/*,application/pdf" {
+		t.Errorf("AllowedMimeTypes = %v", p.AllowedMimeTypes)
+	}
+}
+
+func TestFileNames(t *testing.T) {
+	tests := []struct {
+		name, display, safe string
+	}{
+		{"Hướng dẫn sử dụng.pdf", "Hướng dẫn sử dụng.pdf", "huong-dan-su-dung"},
+		{"C:\\Users\\an\\báo cáo.xlsx", "báo cáo.xlsx", "bao-cao"},
+		{"  nhiều   khoảng trắng .txt", "nhiều khoảng trắng .txt", "nhieu-khoang-trang"},
+		{"tên\"có\x00ký tự lạ.png", "têncóký tự lạ.png", "tencoky-tu-la"},
+		{"", "file", "file"},
+		{"...", "...", "file"},
+	}
+	for _, tt := range tests {
+		display := SanitizeDisplayName(tt.name)
+		if display != tt.display {
+			t.Errorf("SanitizeDisplayName(%q) = %q, want %q", tt.name, display, tt.display)
+		}
+		if safe := SafeBaseName(display); safe != tt.safe {
+			t.Errorf("SafeBaseName(%q) = %q, want %q", display, safe, tt.safe)
+		}
+	}
+	long := SanitizeDisplayName(strings.Repeat("a", 300) + ".pdf")
+	if len([]rune(long)) != 200 || !strings.HasSuffix(long, ".pdf") {
+		t.Errorf("tên dài = %d ký tự %q", len([]rune(long)), long[len(long)-8:])
+	}
+}
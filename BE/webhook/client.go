@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Thời gian chờ tối đa cho một lần gửi
+	defaultTimeout = 10 * time.Second
+	// Chỉ lưu phần đầu phản hồi của bên nhận vào nhật ký gửi
+	maxResponseBody = 2048
+)
+
+// Request là một lần gửi sự kiện đến một webhook
+type Request struct {
+	URL        string
+	Secret     string
+	Event      string
+	DeliveryID string
+	Body       []byte
+}
+
+// Result là kết quả gửi
+type Result struct {
+	StatusCode int
+	Body       string
+	Duration   time.Duration
+}
+
+// Client gửi request webhook. HTTP để trống thì dùng http.Client với thời gian chờ mặc định.
+type Client struct {
+	HTTP *http.Client
+}
+
+var defaultClient = &Client{HTTP: &http.Client{Timeout: defaultTimeout}}
+
+// Default trả về client dùng chung
+func Default() *Client {
+	return defaultClient
+}
+
+// SetDefault thay client dùng chung (vd. client trỏ đến server giả lập khi kiểm thử)
+func SetDefault(c *Client) {
+	defaultClient = c
+}
+
+// Send ký và gửi sự kiện. Bên nhận trả mã khác 2xx được tính là lỗi, kết quả vẫn chứa mã và phản hồi.
+func (c *Client) Send(ctx context.Context, req Request) (Result, error) {
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return Result{}, err
+	}
+	timestamp := time.Now().Unix()
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "HelpdeskWebhook/1.0")
+	httpReq.Header.Set(HeaderEvent, req.Event)
+	httpReq.Header.Set(HeaderDelivery, req.DeliveryID)
+	httpReq.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	httpReq.Header.Set(HeaderSignature, Sign(req.Secret, timestamp, req.Body))
+
+	start := time.Now()
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return Result{Duration: time.Since(start)}, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	result := Result{StatusCode: resp.StatusCode, Body: strings.ToValidUTF8(string(body), ""), Duration: time.Since(start)}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return result, fmt.Errorf("webhook: bên nhận trả về mã %d", resp.StatusCode)
+	}
+	return result, nil
+}
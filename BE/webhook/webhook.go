@@ -0,0 +1,100 @@
+// Package webhook gửi sự kiện ticket đến hệ thống bên ngoài bằng HTTP POST JSON.
+// Mỗi request kèm chữ ký HMAC-SHA256 để bên nhận kiểm tra nguồn gửi:
+//
+//	X-Webhook-Event:     loại sự kiện (ticket.created...)
+//	X-Webhook-Delivery:  mã lần gửi, giữ nguyên khi gửi lại
+//	X-Webhook-Timestamp: thời điểm gửi (Unix giây)
+//	X-Webhook-Signature: "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body))
+//
+// Bên nhận nên từ chối request có timestamp lệch quá vài phút để tránh bị phát lại.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Các loại sự kiện
+const (
+	EventTicketCreated       = "ticket.created"
+	EventTicketCommented     = "ticket.commented"
+	EventTicketAssigned      = "ticket.assigned"
+	EventTicketStatusChanged = "ticket.status_changed"
+	EventPing                = "ping" // sự kiện thử gửi từ trang quản trị, luôn được gửi
+)
+
+// Events là các sự kiện có thể đăng ký
+var Events = []string{EventTicketCreated, EventTicketCommented, EventTicketAssigned, EventTicketStatusChanged}
+
+// Tên các tiêu đề HTTP gửi kèm
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// IsValidEvent kiểm tra sự kiện có thể đăng ký
+func IsValidEvent(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Payload là nội dung JSON gửi đi
+type Payload struct {
+	ID        string      `json:"id"` // mã sự kiện, giống nhau giữa các webhook nhận cùng sự kiện
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Encode tạo payload JSON cho một sự kiện mới, trả về mã sự kiện và nội dung
+func Encode(event string, data interface{}) (string, []byte, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return "", nil, err
+	}
+	body, err := json.Marshal(Payload{ID: id, Event: event, CreatedAt: time.Now().UTC(), Data: data})
+	return id, body, err
+}
+
+// Sign tính chữ ký của body tại thời điểm timestamp
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify kiểm tra chữ ký nhận được (dùng cho bên nhận hoặc khi kiểm thử)
+func Verify(secret, signature string, timestamp int64, body []byte) bool {
+	return hmac.Equal([]byte(strings.TrimSpace(signature)), []byte(Sign(secret, timestamp, body)))
+}
+
+// GenerateSecret tạo khóa ký ngẫu nhiên cho webhook mới
+func GenerateSecret() (string, error) {
+	s, err := randomHex(24)
+	if err != nil {
+		return "", err
+	}
+	return "whsec_" + s, nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
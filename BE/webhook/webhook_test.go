@@ -0,0 +1,96 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"id":"1","event":"ping"}`)
+	sig := Sign("whsec_test", 1700000000, body)
+	if !strings.HasPrefix(sig, "sha256=") {
+		t.Fatalf("Sign = %s", sig)
+	}
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		timestamp int64
+		body      []byte
+		want      bool
+	}{
+		{"hợp lệ", "whsec_test", sig, 1700000000, body, true},
+		{"có khoảng trắng", "whsec_test", " " + sig + "\n", 1700000000, body, true},
+		{"sai khóa", "whsec_other", sig, 1700000000, body, false},
+		{"sai timestamp", "whsec_test", sig, 1700000001, body, false},
+		{"body bị sửa", "whsec_test", sig, 1700000000, []byte(`{"id":"2","event":"ping"}`), false},
+		{"thiếu chữ ký", "whsec_test", "", 1700000000, body, false},
+	}
+	for _, tt := range tests {
+		if got := Verify(tt.secret, tt.signature, tt.timestamp, tt.body); got != tt.want {
+			t.Errorf("%s: Verify = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeAndGenerateSecret(t *testing.T) {
+	id, body, err := Encode(EventTicketCreated, map[string]int{"ticket_id": 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p Payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		t.Fatal(err)
+	}
+	if len(id) != 32 || p.ID != id || p.Event != EventTicketCreated || p.CreatedAt.IsZero() {
+		t.Errorf("Encode = %s %s", id, body)
+	}
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := GenerateSecret()
+	if !strings.HasPrefix(a, "whsec_") || len(a) != len("whsec_")+48 || a == b {
+		t.Errorf("GenerateSecret = %s, %s", a, b)
+	}
+}
+
+func TestClientSend(t *testing.T) {
+	const secret = "whsec_0123456789abcdef"
+	var status int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if err != nil || !Verify(secret, r.Header.Get(HeaderSignature), ts, body) {
+			t.Errorf("chữ ký không hợp lệ: %s", r.Header.Get(HeaderSignature))
+		}
+		if r.Header.Get(HeaderEvent) != EventPing || r.Header.Get(HeaderDelivery) != "42" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("header = %v", r.Header)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(strings.Repeat("x", maxResponseBody+100)))
+	}))
+	defer srv.Close()
+
+	c := &Client{HTTP: srv.Client()}
+	req := Request{URL: srv.URL, Secret: secret, Event: EventPing, DeliveryID: "42", Body: []byte(`{"event":"ping"}`)}
+	for _, tt := range []struct {
+		status  int
+		wantErr bool
+	}{{200, false}, {204, false}, {301, true}, {500, true}} {
+		status = tt.status
+		result, err := c.Send(context.Background(), req)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("status %d: err = %v", tt.status, err)
+		}
+		if result.StatusCode != tt.status || len(result.Body) > maxResponseBody {
+			t.Errorf("status %d: result = %d, %d byte", tt.status, result.StatusCode, len(result.Body))
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Package workflow định nghĩa máy trạng thái của ticket: các trạng thái hợp lệ,
+// các bước chuyển được phép theo vai trò và các mốc thời gian đi kèm.
+package workflow
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/sla"
+	"fmt"
+	"time"
+)
+
+// Các trạng thái ticket (khớp với enum của cột tickets.status)
+const (
+	StatusNew        = "Mới"
+	StatusInProgress = "Đang xử lý"
+	StatusWaiting    = "Chờ phản hồi"
+	StatusResolved   = "Đã xử lý"
+	StatusClosed     = "Đã đóng"
+)
+
+// Các vai trò người dùng
+const (
+	RoleCustomer = "customer"
+	RoleStaff    = "staff"
+	RoleAdmin    = "admin"
+)
+
+// Mã lỗi của TransitionError
+const (
+	ErrInvalidStatus        = "INVALID_STATUS"
+	ErrSameStatus           = "SAME_STATUS"
+	ErrTransitionNotAllowed = "TRANSITION_NOT_ALLOWED"
+	ErrRoleNotAllowed       = "ROLE_NOT_ALLOWED"
+)
+
+// Statuses liệt kê các trạng thái theo thứ tự vòng đời
+var Statuses = []string{StatusNew, StatusInProgress, StatusWaiting, StatusResolved, StatusClosed}
+
+var agents = []string{RoleStaff, RoleAdmin}
+
+// transitions[from][to] = các vai trò được phép thực hiện bước chuyển
+var transitions = map[string]map[string][]string{
+	StatusNew: {
+		StatusInProgress: agents,
+		StatusWaiting:    agents,
+		StatusResolved:   agents,
+		StatusClosed:     {RoleCustomer, RoleAdmin},
+	},
+	StatusInProgress: {
+		StatusWaiting:  agents,
+		StatusResolved: agents,
+		StatusClosed:   {RoleCustomer, RoleAdmin},
+	},
+	StatusWaiting: {
+		StatusInProgress: {RoleCustomer, RoleStaff, RoleAdmin},
+		StatusResolved:   agents,
+		StatusClosed:     {RoleCustomer, RoleStaff, RoleAdmin},
+	},
+	StatusResolved: {
+		StatusInProgress: {RoleCustomer, RoleStaff, RoleAdmin},
+		StatusClosed:     {RoleCustomer, RoleStaff, RoleAdmin},
+	},
+	StatusClosed: {
+		StatusInProgress: {RoleAdmin},
+	},
+}
+
+// TransitionError mô tả một bước chuyển trạng thái không hợp lệ
+type TransitionError struct {
+	Code    string   `json:"code"`
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Role    string   `json:"role"`
+	Allowed []string `json:"allowed"`
+}
+
+func (e *TransitionError) Error() string {
+	switch e.Code {
+	case ErrInvalidStatus:
+		return fmt.Sprintf("Trạng thái '%s' không hợp lệ", e.To)
+	case ErrSameStatus:
+		return fmt.Sprintf("Ticket đã ở trạng thái '%s'", e.To)
+	case ErrRoleNotAllowed:
+		return fmt.Sprintf("Vai trò '%s' không được chuyển ticket từ '%s' sang '%s'", e.Role, e.From, e.To)
+	default:
+		return fmt.Sprintf("Không thể chuyển ticket từ '%s' sang '%s'", e.From, e.To)
+	}
+}
+
+// IsValidStatus kiểm tra status có thuộc danh sách trạng thái hay không
+func IsValidStatus(status string) bool {
+	_, ok := transitions[status]
+	return ok
+}
+
+// IsOpen trả về true nếu ticket vẫn còn cần xử lý
+func IsOpen(status string) bool {
+	return status == StatusNew || status == StatusInProgress || status == StatusWaiting
+}
+
+// AllowedTransitions trả về các trạng thái mà role có thể chuyển tới từ from
+func AllowedTransitions(from, role string) []string {
+	allowed := []string{}
+	for _, to := range Statuses {
+		if roles, ok := transitions[from][to]; ok && hasRole(roles, role) {
+			allowed = append(allowed, to)
+		}
+	}
+	return allowed
+}
+
+// CanTransition kiểm tra bước chuyển from -> to của role, trả về *TransitionError nếu không hợp lệ
+func CanTransition(from, to, role string) error {
+	if !IsValidStatus(to) {
+		return &TransitionError{Code: ErrInvalidStatus, From: from, To: to, Role: role, Allowed: AllowedTransitions(from, role)}
+	}
+	if from == to {
+		return &TransitionError{Code: ErrSameStatus, From: from, To: to, Role: role, Allowed: AllowedTransitions(from, role)}
+	}
+	roles, ok := transitions[from][to]
+	if !ok {
+		return &TransitionError{Code: ErrTransitionNotAllowed, From: from, To: to, Role: role, Allowed: AllowedTransitions(from, role)}
+	}
+	if !hasRole(roles, role) {
+		return &TransitionError{Code: ErrRoleNotAllowed, From: from, To: to, Role: role, Allowed: AllowedTransitions(from, role)}
+	}
+	return nil
+}
+
+// Transition kiểm tra và áp dụng bước chuyển trạng thái lên ticket (chưa lưu DB),
+// đồng thời cập nhật các mốc first-response/resolved/closed/reopened.
+func Transition(ticket *models.Ticket, to, role string, now time.Time) error {
+	from := ticket.Status
+	if err := CanTransition(from, to, role); err != nil {
+		return err
+	}
+	ticket.Status = to
+
+	// Nhân viên chuyển ticket ra khỏi trạng thái "Mới" được tính là phản hồi đầu tiên
+	markFirstResponse(ticket, role, now)
+
+	switch to {
+	case StatusResolved:
+		ticket.ResolvedAt = &now
+		ticket.ClosedAt = nil
+	case StatusClosed:
+		ticket.ClosedAt = &now
+	default:
+		// Mở lại ticket đã xử lý/đã đóng
+		if from == StatusResolved || from == StatusClosed {
+			ticket.ReopenedAt = &now
+			ticket.ReopenCount++
+			ticket.ResolvedAt = nil
+			ticket.ClosedAt = nil
+		}
+	}
+	sla.Track(ticket, to == StatusWaiting, now)
+	return nil
+}
+
+// MarkFirstResponse ghi nhận thời điểm phản hồi đầu tiên của nhân viên, trả về true nếu vừa được ghi nhận
+func MarkFirstResponse(ticket *models.Ticket, role string, now time.Time) bool {
+	if !markFirstResponse(ticket, role, now) {
+		return false
+	}
+	sla.Track(ticket, ticket.Status == StatusWaiting, now)
+	return true
+}
+
+func markFirstResponse(ticket *models.Ticket, role string, now time.Time) bool {
+	if role != RoleStaff && role != RoleAdmin {
+		return false
+	}
+	if ticket.FirstResponseAt != nil {
+		return false
+	}
+	ticket.FirstResponseAt = &now
+	return true
+}
+
+func hasRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
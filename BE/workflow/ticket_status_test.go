@@ -0,0 +1,157 @@
+package workflow
+
+import (
+	"awesomeProject/models"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCanTransitionMatrix(t *testing.T) {
+	// allowed[from][to] = vai trò được phép; cặp không có trong bảng thì không ai được chuyển
+	allowed := map[string]map[string]string{
+		StatusNew:        {StatusInProgress: "sa", StatusWaiting: "sa", StatusResolved: "sa", StatusClosed: "ca"},
+		StatusInProgress: {StatusWaiting: "sa", StatusResolved: "sa", StatusClosed: "ca"},
+		StatusWaiting:    {StatusInProgress: "csa", StatusResolved: "sa", StatusClosed: "csa"},
+		StatusResolved:   {StatusInProgress: "csa", StatusClosed: "csa"},
+		StatusClosed:     {StatusInProgress: "a"},
+	}
+	roles := map[string]byte{RoleCustomer: 'c', RoleStaff: 's', RoleAdmin: 'a'}
+	for _, from := range Statuses {
+		for _, to := range Statuses {
+			for role, code := range roles {
+				err := CanTransition(from, to, role)
+				rule, exists := allowed[from][to]
+				var te *TransitionError
+				switch {
+				case from == to:
+					if !errors.As(err, &te) || te.Code != ErrSameStatus {
+						t.Errorf("%s -> %s (%s): err = %v, want %s", from, to, role, err, ErrSameStatus)
+					}
+				case !exists:
+					if !errors.As(err, &te) || te.Code != ErrTransitionNotAllowed {
+						t.Errorf("%s -> %s (%s): err = %v, want %s", from, to, role, err, ErrTransitionNotAllowed)
+					}
+				case containsByte(rule, code):
+					if err != nil {
+						t.Errorf("%s -> %s (%s): err = %v, want nil", from, to, role, err)
+					}
+				default:
+					if !errors.As(err, &te) || te.Code != ErrRoleNotAllowed {
+						t.Errorf("%s -> %s (%s): err = %v, want %s", from, to, role, err, ErrRoleNotAllowed)
+					}
+				}
+			}
+		}
+	}
+
+	var te *TransitionError
+	if err := CanTransition(StatusNew, "Không tồn tại", RoleAdmin); !errors.As(err, &te) || te.Code != ErrInvalidStatus {
+		t.Errorf("trạng thái không hợp lệ: err = %v", err)
+	}
+	if err := CanTransition(StatusNew, StatusInProgress, "guest"); !errors.As(err, &te) || te.Code != ErrRoleNotAllowed {
+		t.Errorf("vai trò lạ: err = %v", err)
+	}
+}
+
+func containsByte(s string, b byte) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] == b {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAllowedTransitions(t *testing.T) {
+	tests := []struct {
+		from, role string
+		want       []string
+	}{
+		{StatusNew, RoleCustomer, []string{StatusClosed}},
+		{StatusNew, RoleStaff, []string{StatusInProgress, StatusWaiting, StatusResolved}},
+		{StatusWaiting, RoleCustomer, []string{StatusInProgress, StatusClosed}},
+		{StatusClosed, RoleStaff, []string{}},
+		{StatusClosed, RoleAdmin, []string{StatusInProgress}},
+	}
+	for _, tt := range tests {
+		got := AllowedTransitions(tt.from, tt.role)
+		if len(got) != len(tt.want) {
+			t.Errorf("AllowedTransitions(%s, %s) = %v, want %v", tt.from, tt.role, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("AllowedTransitions(%s, %s) = %v, want %v", tt.from, tt.role, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestTransitionTimestamps(t *testing.T) {
+	t1 := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	t3 := t2.Add(time.Hour)
+	t4 := t3.Add(time.Hour)
+
+	tk := &models.Ticket{Status: StatusNew}
+	// Bước chuyển không hợp lệ thì ticket giữ nguyên
+	if err := Transition(tk, StatusInProgress, RoleCustomer, t1); err == nil {
+		t.Fatal("khách hàng không được chuyển ticket mới sang đang xử lý")
+	}
+	if tk.Status != StatusNew || tk.FirstResponseAt != nil {
+		t.Fatalf("bước chuyển lỗi không được thay đổi ticket: %+v", tk)
+	}
+
+	if err := Transition(tk, StatusInProgress, RoleStaff, t1); err != nil {
+		t.Fatal(err)
+	}
+	if tk.FirstResponseAt == nil || !tk.FirstResponseAt.Equal(t1) {
+		t.Errorf("FirstResponseAt = %v, want %v", tk.FirstResponseAt, t1)
+	}
+
+	if err := Transition(tk, StatusResolved, RoleStaff, t2); err != nil {
+		t.Fatal(err)
+	}
+	if tk.ResolvedAt == nil || !tk.ResolvedAt.Equal(t2) || tk.ClosedAt != nil {
+		t.Errorf("sau khi xử lý: resolved = %v closed = %v", tk.ResolvedAt, tk.ClosedAt)
+	}
+
+	if err := Transition(tk, StatusClosed, RoleCustomer, t3); err != nil {
+		t.Fatal(err)
+	}
+	if tk.ClosedAt == nil || !tk.ClosedAt.Equal(t3) || tk.ResolvedAt == nil {
+		t.Errorf("sau khi đóng: resolved = %v closed = %v", tk.ResolvedAt, tk.ClosedAt)
+	}
+
+	if err := Transition(tk, StatusInProgress, RoleAdmin, t4); err != nil {
+		t.Fatal(err)
+	}
+	if tk.ReopenedAt == nil || !tk.ReopenedAt.Equal(t4) || tk.ReopenCount != 1 || tk.ResolvedAt != nil || tk.ClosedAt != nil {
+		t.Errorf("sau khi mở lại: %+v", tk)
+	}
+	if !tk.FirstResponseAt.Equal(t1) {
+		t.Errorf("mở lại không được đổi FirstResponseAt: %v", tk.FirstResponseAt)
+	}
+}
+
+func TestMarkFirstResponse(t *testing.T) {
+	now := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		role     string
+		existing *time.Time
+		want     bool
+	}{
+		{RoleCustomer, nil, false},
+		{RoleStaff, nil, true},
+		{RoleAdmin, nil, true},
+		{RoleStaff, &now, false},
+	}
+	for _, tt := range tests {
+		tk := &models.Ticket{Status: StatusInProgress, FirstResponseAt: tt.existing}
+		if got := MarkFirstResponse(tk, tt.role, now.Add(time.Hour)); got != tt.want {
+			t.Errorf("MarkFirstResponse(%s, existing=%v) = %v, want %v", tt.role, tt.existing != nil, got, tt.want)
+		}
+	}
+}
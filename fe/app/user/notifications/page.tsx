@@ -23,7 +23,7 @@ interface Notification {
   content: string
   is_read: boolean
   created_at: string
-  data?: string
+  data?: { ticket_id?: number; comment_id?: number }
 }
 
 export default function NotificationsPage() {
@@ -138,15 +138,7 @@ export default function NotificationsPage() {
 
   // Parse ticket ID from notification data
   const getTicketIdFromNotification = (notification: Notification): number | null => {
-    if (!notification.data) return null
-    
-    try {
-      const data = JSON.parse(notification.data)
-      return data.ticket_id || null
-    } catch (error) {
-      console.error("Failed to parse notification data:", error)
-      return null
-    }
+    return notification.data?.ticket_id || null
   }
 
   // Navigate to ticket when clicking on ticket-related notification
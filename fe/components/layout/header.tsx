@@ -27,7 +27,7 @@ interface Notification {
   content: string
   is_read: boolean
   created_at: string
-  data?: string
+  data?: { ticket_id?: number; comment_id?: number }
 }
 
 interface HeaderProps {
@@ -130,15 +130,7 @@ export function Header({ sidebarCollapsed }: HeaderProps) {
 
   // Parse ticket ID from notification data
   const getTicketIdFromNotification = (notification: Notification): number | null => {
-    if (!notification.data) return null
-    
-    try {
-      const data = JSON.parse(notification.data)
-      return data.ticket_id || null
-    } catch (error) {
-      // Silent error handling
-      return null
-    }
+    return notification.data?.ticket_id || null
   }
 
   // Navigate to ticket when clicking on ticket-related notification
@@ -3,7 +3,7 @@ interface Notification {
   user_id: number
   type: string
   content: string
-  data?: string
+  data?: { ticket_id?: number; comment_id?: number }
   is_read: boolean
   created_at: string
 }